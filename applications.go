@@ -20,20 +20,24 @@ type AppParameter struct {
 }
 
 type InstallAppArgs struct {
-	Name              string         `json:"name" jsonschema:"required,description=The name of the application instance"`
-	Namespace         string         `json:"namespace" jsonschema:"required,description=The namespace to install the application in"`
-	ProjectID         int32          `json:"projectId" jsonschema:"required,description=The project ID to install the application in"`
-	CatalogAppID      int32          `json:"catalogAppId" jsonschema:"required,description=The catalog application ID to install"`
-	ExtraValues       string         `json:"extraValues,omitempty" jsonschema:"description=Base64-encoded YAML extra values for the application (optional)"`
-	AutoSync          bool           `json:"autoSync,omitempty" jsonschema:"description=Enable automatic synchronization (default: false)"`
-	TaikunLinkEnabled bool           `json:"taikunLinkEnabled,omitempty" jsonschema:"description=Enable Cloudera Cloud Factory (Taikun) link integration (default: false)"`
-	Timeout           int32          `json:"timeout,omitempty" jsonschema:"description=Installation timeout in seconds (optional)"`
-	Parameters        []AppParameter `json:"parameters,omitempty" jsonschema:"description=Application parameters as key-value pairs (optional)"`
-	UseCatalogDefaults *bool         `json:"useCatalogDefaults,omitempty" jsonschema:"description=Use catalog default parameters as a base (default: true)"`
-	WaitForReady      bool           `json:"waitForReady,omitempty" jsonschema:"description=Wait for application to be ready before returning (default: false)"`
-	WaitTimeout       int32          `json:"waitTimeout,omitempty" jsonschema:"description=Wait timeout in seconds (default: 600)"`
+	Name               string         `json:"name" jsonschema:"required,description=The name of the application instance"`
+	Namespace          string         `json:"namespace" jsonschema:"required,description=The namespace to install the application in"`
+	ProjectID          int32          `json:"projectId" jsonschema:"required,description=The project ID to install the application in"`
+	CatalogAppID       int32          `json:"catalogAppId" jsonschema:"required,description=The catalog application ID to install"`
+	ExtraValues        string         `json:"extraValues,omitempty" jsonschema:"description=Base64-encoded YAML extra values for the application (optional)"`
+	AutoSync           bool           `json:"autoSync,omitempty" jsonschema:"description=Enable automatic synchronization (default: false)"`
+	TaikunLinkEnabled  bool           `json:"taikunLinkEnabled,omitempty" jsonschema:"description=Enable Cloudera Cloud Factory (Taikun) link integration (default: false)"`
+	Timeout            int32          `json:"timeout,omitempty" jsonschema:"description=Installation timeout in seconds (optional)"`
+	Parameters         []AppParameter `json:"parameters,omitempty" jsonschema:"description=Application parameters as key-value pairs (optional)"`
+	UseCatalogDefaults *bool          `json:"useCatalogDefaults,omitempty" jsonschema:"description=Use catalog default parameters as a base (default: true)"`
+	WaitForReady       bool           `json:"waitForReady,omitempty" jsonschema:"description=Wait for application to be ready before returning (default: false)"`
+	WaitTimeout        int32          `json:"waitTimeout,omitempty" jsonschema:"description=Wait timeout in seconds (default: 600)"`
+	DryRun             bool           `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	Async              bool           `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
 }
 
+func (a InstallAppArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
 type ListAppsArgs struct {
 	ProjectID int32  `json:"projectId" jsonschema:"required,description=The project ID to list applications from"`
 	Limit     int32  `json:"limit,omitempty" jsonschema:"description=Maximum number of results to return (optional)"`
@@ -50,15 +54,17 @@ type UpdateSyncAppArgs struct {
 	ExtraValues  string `json:"extraValues,omitempty" jsonschema:"description=Base64-encoded YAML extra values (optional - if not provided, will only sync)"`
 	Timeout      int32  `json:"timeout,omitempty" jsonschema:"description=Operation timeout in seconds (optional)"`
 	SyncOnly     bool   `json:"syncOnly,omitempty" jsonschema:"description=If true, only sync without updating values (default: false)"`
+	DryRun       bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type UninstallAppArgs struct {
-	ProjectAppID int32 `json:"projectAppId" jsonschema:"required,description=The project application ID to uninstall"`
+	ProjectAppID      int32  `json:"projectAppId" jsonschema:"required,description=The project application ID to uninstall"`
+	DryRun            bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	ConfirmationToken string `json:"confirmationToken,omitempty" jsonschema:"description=Token returned by the first call after reviewing its summary; the deletion only runs when this is provided"`
 }
 
 // waitForAppReady waits for an application to reach READY status or be deleted
-func waitForAppReady(client *taikungoclient.Client, projectAppID int32, timeoutSeconds int32, waitDeleted bool) error {
-	ctx := context.Background()
+func waitForAppReady(ctx context.Context, client *taikungoclient.Client, projectAppID int32, timeoutSeconds int32, waitDeleted bool) error {
 	timeout := time.Duration(timeoutSeconds) * time.Second
 	if timeoutSeconds == 0 {
 		timeout = 60 * time.Second // Default 60 seconds
@@ -71,28 +77,34 @@ func waitForAppReady(client *taikungoclient.Client, projectAppID int32, timeoutS
 	for {
 		// Check if we've exceeded the timeout
 		if time.Since(start) > timeout {
-			return fmt.Errorf("timeout waiting for application ID %d after %v", projectAppID, timeout)
+			return newCodedError(errorCodeTimeout, "timeout waiting for application ID %d after %v", projectAppID, timeout)
 		}
 
 		status, found, response, err := fetchProjectAppStatus(ctx, client, projectAppID)
 		if response != nil && (response.StatusCode < 200 || response.StatusCode >= 300) && response.StatusCode != http.StatusNotFound {
-			return taikungoclient.CreateError(response, err)
+			return upstreamError(response, err)
 		}
 		if err != nil {
-			return fmt.Errorf("error checking application status: %v", err)
+			return newCodedError(errorCodeUpstream5xx, "error checking application status: %v", err)
 		}
 
 		if !found {
 			if waitDeleted {
 				return nil // App is gone, which is what we wanted
 			}
-			return fmt.Errorf("application ID %d not found in project", projectAppID)
+			return newCodedError(errorCodeNotFound, "application ID %d not found in project", projectAppID)
 		}
 
+		progress, total := elapsedProgress(start, timeout)
 		if waitDeleted {
-			logger.Printf("Application ID %d still exists - Status: %s", projectAppID, status)
+			logger.InfoContext(ctx, "Application still exists", "projectAppId", projectAppID, "status", status)
+			reportProgress(ctx, progress, total, fmt.Sprintf("Application %d: %s, still exists (%s)", projectAppID, status, formatElapsed(start)))
 		} else {
-			logger.Printf("Application ID %d status: %s", projectAppID, status)
+			logger.InfoContext(ctx, "Application status", "projectAppId", projectAppID, "status", status)
+			if status == "Ready" {
+				progress = total
+			}
+			reportProgress(ctx, progress, total, fmt.Sprintf("Application %d: %s (%s)", projectAppID, status, formatElapsed(start)))
 
 			// Check if app is ready
 			if status == "Ready" {
@@ -101,12 +113,14 @@ func waitForAppReady(client *taikungoclient.Client, projectAppID int32, timeoutS
 
 			// Check for failure states
 			if status == "Failed" {
-				return fmt.Errorf("application ID %d installation failed - status: %s", projectAppID, status)
+				return newCodedError(errorCodeResourceFailed, "application ID %d installation failed - status: %s", projectAppID, status)
 			}
 		}
 
-		// Wait before next poll
-		time.Sleep(10 * time.Second)
+		// Wait before next poll, giving up as soon as the request is cancelled
+		if err := sleepContext(ctx, 10*time.Second); err != nil {
+			return fmt.Errorf("stopped waiting for application ID %d: %w", projectAppID, err)
+		}
 	}
 }
 
@@ -141,9 +155,7 @@ func fetchProjectAppStatus(ctx context.Context, client *taikungoclient.Client, p
 	return "", false, response, err
 }
 
-func installApp(client *taikungoclient.Client, args InstallAppArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func installApp(ctx context.Context, client *taikungoclient.Client, args InstallAppArgs) (*mcp_golang.ToolResponse, error) {
 	createCmd := taikuncore.NewCreateProjectAppCommand()
 	createCmd.SetName(args.Name)
 	createCmd.SetNamespace(args.Namespace)
@@ -205,6 +217,25 @@ func installApp(client *taikungoclient.Client, args InstallAppArgs) (*mcp_golang
 		createCmd.SetParameters(params)
 	}
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		catalogApp, errorResp := resolveCatalogApp(ctx, client, args.CatalogAppID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would install '%s' into namespace '%s' of project '%s'", args.Name, args.Namespace, project.GetName()),
+			[]string{
+				fmt.Sprintf("Project %d (%s) exists", args.ProjectID, project.GetName()),
+				fmt.Sprintf("Catalog app %d (%s) exists in catalog '%s'", args.CatalogAppID, catalogApp.GetName(), catalogApp.GetCatalogName()),
+			},
+			dryRunPost("/api/v1/projectapp/install", createCmd),
+		), nil
+	}
+
 	response, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappInstall(ctx).
 		CreateProjectAppCommand(*createCmd).
 		Execute()
@@ -231,7 +262,7 @@ func installApp(client *taikungoclient.Client, args InstallAppArgs) (*mcp_golang
 			for _, app := range appList.Data {
 				if app.GetName() == args.Name && app.GetNamespace() == args.Namespace {
 					projectAppID = app.GetId()
-					logger.Printf("Found application '%s' with ID: %d", args.Name, projectAppID)
+					logger.InfoContext(ctx, "Found application", "name", args.Name, "projectAppId", projectAppID)
 					break
 				}
 			}
@@ -240,21 +271,19 @@ func installApp(client *taikungoclient.Client, args InstallAppArgs) (*mcp_golang
 
 	var resultMsg string
 	if args.WaitForReady && projectAppID > 0 {
-		logger.Printf("Waiting for application '%s' (ID: %d) to be ready...", args.Name, projectAppID)
+		logger.InfoContext(ctx, "Waiting for application to be ready", "name", args.Name, "projectAppId", projectAppID)
 		waitTimeout := args.WaitTimeout
 		if waitTimeout == 0 {
 			waitTimeout = 60 // Default 60 seconds
 		}
 
-		err := waitForAppReady(client, projectAppID, waitTimeout, false)
+		err := waitForAppReady(ctx, client, projectAppID, waitTimeout, false)
 		if err != nil {
-			errorResp := ErrorResponse{
-				Error: fmt.Sprintf("Application '%s' installation initiated but failed during wait: %v", args.Name, err),
-			}
+			errorResp := errorResponseFromError(err, fmt.Sprintf("Application '%s' installation initiated but failed during wait: %v", args.Name, err))
 			return createJSONResponse(errorResp), nil
 		}
 		resultMsg = fmt.Sprintf("Application '%s' (ID: %d) installed successfully and is ready in namespace '%s'", args.Name, projectAppID, args.Namespace)
-		logger.Printf("Application '%s' (ID: %d) is now ready", args.Name, projectAppID)
+		logger.InfoContext(ctx, "Application is ready", "name", args.Name, "projectAppId", projectAppID)
 	} else {
 		if response != nil && response.GetMessage() != "" {
 			resultMsg = fmt.Sprintf("Application '%s' installation initiated successfully. Message: %s", args.Name, response.GetMessage())
@@ -310,9 +339,7 @@ func installApp(client *taikungoclient.Client, args InstallAppArgs) (*mcp_golang
 	return createJSONResponse(responseData), nil
 }
 
-func listApps(client *taikungoclient.Client, args ListAppsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listApps(ctx context.Context, client *taikungoclient.Client, args ListAppsArgs) (*mcp_golang.ToolResponse, error) {
 	req := client.Client.ProjectAppsAPI.ProjectappList(ctx).ProjectId(args.ProjectID)
 
 	if args.Limit > 0 {
@@ -419,9 +446,7 @@ func listApps(client *taikungoclient.Client, args ListAppsArgs) (*mcp_golang.Too
 	return createJSONResponse(listResp), nil
 }
 
-func getApp(client *taikungoclient.Client, args GetAppArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func getApp(ctx context.Context, client *taikungoclient.Client, args GetAppArgs) (*mcp_golang.ToolResponse, error) {
 	appDetails, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappDetails(ctx, args.ProjectAppID).Execute()
 	if err != nil {
 		return createError(httpResponse, err), nil
@@ -434,6 +459,7 @@ func getApp(client *taikungoclient.Client, args GetAppArgs) (*mcp_golang.ToolRes
 	if appDetails == nil {
 		errorResp := ErrorResponse{
 			Error: fmt.Sprintf("Application with ID %d not found", args.ProjectAppID),
+			Code:  errorCodeNotFound,
 		}
 		return createJSONResponse(errorResp), nil
 	}
@@ -527,9 +553,7 @@ func getApp(client *taikungoclient.Client, args GetAppArgs) (*mcp_golang.ToolRes
 	return createJSONResponse(appDetail), nil
 }
 
-func updateSyncApp(client *taikungoclient.Client, args UpdateSyncAppArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func updateSyncApp(ctx context.Context, client *taikungoclient.Client, args UpdateSyncAppArgs) (*mcp_golang.ToolResponse, error) {
 	var resultMsg string
 
 	// First, get the app details to check autosync status
@@ -545,12 +569,39 @@ func updateSyncApp(client *taikungoclient.Client, args UpdateSyncAppArgs) (*mcp_
 	if appDetails == nil {
 		errorResp := ErrorResponse{
 			Error: fmt.Sprintf("Application with ID %d not found", args.ProjectAppID),
+			Code:  errorCodeNotFound,
 		}
 		return createJSONResponse(errorResp), nil
 	}
 
 	hasAutoSync := appDetails.GetAutoSync()
-	logger.Printf("Application ID %d has autosync enabled: %t", args.ProjectAppID, hasAutoSync)
+	logger.DebugContext(ctx, "Application autosync setting", "projectAppId", args.ProjectAppID, "autoSync", hasAutoSync)
+
+	if isDryRun(args.DryRun) {
+		var requests []DryRunRequest
+		if !args.SyncOnly && args.ExtraValues != "" {
+			updateCmd := taikuncore.NewEditProjectAppExtraValuesCommand()
+			updateCmd.SetProjectAppId(args.ProjectAppID)
+			updateCmd.SetExtraValues(args.ExtraValues)
+			if args.Timeout > 0 {
+				updateCmd.SetTimeout(args.Timeout)
+			}
+			requests = append(requests, dryRunPost("/api/v1/projectapp/update-extra-values", updateCmd))
+		}
+		if !hasAutoSync {
+			syncCmd := taikuncore.NewSyncProjectAppCommand()
+			syncCmd.SetProjectAppId(args.ProjectAppID)
+			if args.Timeout > 0 {
+				syncCmd.SetTimeout(args.Timeout)
+			}
+			requests = append(requests, dryRunPost("/api/v1/projectapp/sync", syncCmd))
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would send %d request(s) to update application '%s'", len(requests), appDetails.GetName()),
+			[]string{fmt.Sprintf("Application %d (%s) exists, autosync enabled: %t", args.ProjectAppID, appDetails.GetName(), hasAutoSync)},
+			requests...,
+		), nil
+	}
 
 	if !args.SyncOnly && args.ExtraValues != "" {
 		// Update the values
@@ -635,8 +686,33 @@ func updateSyncApp(client *taikungoclient.Client, args UpdateSyncAppArgs) (*mcp_
 	return createJSONResponse(successResp), nil
 }
 
-func uninstallApp(client *taikungoclient.Client, args UninstallAppArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
+func uninstallApp(ctx context.Context, client *taikungoclient.Client, args UninstallAppArgs) (*mcp_golang.ToolResponse, error) {
+	if isDryRun(args.DryRun) {
+		appDetails, errorResp := resolveProjectApp(ctx, client, args.ProjectAppID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would uninstall application '%s' from project '%s'", appDetails.GetName(), appDetails.GetProjectName()),
+			[]string{fmt.Sprintf("Application %d (%s) exists in namespace '%s'", args.ProjectAppID, appDetails.GetName(), appDetails.GetNamespace())},
+			dryRunPost(fmt.Sprintf("/api/v1/projectapp/uninstall/%d", args.ProjectAppID), nil),
+		), nil
+	}
+
+	action := fmt.Sprintf("uninstall-app:%d", args.ProjectAppID)
+	if confirmation := confirmDestructive(ctx, action, args.ConfirmationToken, "uninstall-app", func() (*DestructionSummary, *mcp_golang.ToolResponse) {
+		appDetails, errorResp := resolveProjectApp(ctx, client, args.ProjectAppID)
+		if errorResp != nil {
+			return nil, errorResp
+		}
+		summary, errorResp := summarizeProject(ctx, client, appDetails.GetProjectId())
+		if summary != nil {
+			summary.Removes = fmt.Sprintf("application '%s' in namespace '%s'", appDetails.GetName(), appDetails.GetNamespace())
+		}
+		return summary, errorResp
+	}); confirmation != nil {
+		return confirmation, nil
+	}
 
 	uninstallResponse, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappDelete(ctx, args.ProjectAppID).Execute()
 	if err != nil {
@@ -660,7 +736,7 @@ func uninstallApp(client *taikungoclient.Client, args UninstallAppArgs) (*mcp_go
 	return createJSONResponse(successResp), nil
 }
 
-func waitForApp(client *taikungoclient.Client, args WaitForAppArgs) (*mcp_golang.ToolResponse, error) {
+func waitForApp(ctx context.Context, client *taikungoclient.Client, args WaitForAppArgs) (*mcp_golang.ToolResponse, error) {
 	timeout := args.Timeout
 	if timeout == 0 {
 		timeout = 60 // Default 60s for creation
@@ -670,16 +746,14 @@ func waitForApp(client *taikungoclient.Client, args WaitForAppArgs) (*mcp_golang
 	}
 
 	if args.WaitDeleted {
-		logger.Printf("Waiting for application ID %d to be deleted (timeout: %d seconds)", args.ProjectAppId, timeout)
+		logger.InfoContext(ctx, "Waiting for application to be deleted", "projectAppId", args.ProjectAppId, "timeoutSeconds", timeout)
 	} else {
-		logger.Printf("Waiting for application ID %d to be ready (timeout: %d seconds)", args.ProjectAppId, timeout)
+		logger.InfoContext(ctx, "Waiting for application to be ready", "projectAppId", args.ProjectAppId, "timeoutSeconds", timeout)
 	}
 
-	err := waitForAppReady(client, args.ProjectAppId, timeout, args.WaitDeleted)
+	err := waitForAppReady(ctx, client, args.ProjectAppId, timeout, args.WaitDeleted)
 	if err != nil {
-		return createJSONResponse(ErrorResponse{
-			Error: err.Error(),
-		}), nil
+		return createJSONResponse(errorResponseFromError(err, err.Error())), nil
 	}
 
 	message := fmt.Sprintf("Application ID %d is now ready", args.ProjectAppId)
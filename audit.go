@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// Audit outcomes
+const (
+	auditOutcomeSuccess              = "success"
+	auditOutcomeError                = "error"
+	auditOutcomeRefused              = "refused"
+	auditOutcomeDryRun               = "dry_run"
+	auditOutcomeConfirmationRequired = "confirmation_required"
+)
+
+const redactedValue = "[REDACTED]"
+
+// auditLog receives one record per tool call; nil when auditing is disabled
+var auditLog *auditLogger
+
+// auditCaller identifies who issued a tool call
+type auditCaller struct {
+	Identity   string `json:"identity"`
+	Credential string `json:"credential,omitempty"`
+	SessionID  string `json:"sessionId,omitempty"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+}
+
+// auditRecord is one JSON line of the audit trail
+type auditRecord struct {
+	Tool       string                 `json:"tool"`
+	RequestID  string                 `json:"requestId,omitempty"`
+	JobID      string                 `json:"jobId,omitempty"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	Caller     auditCaller            `json:"caller"`
+	StartedAt  time.Time              `json:"startedAt"`
+	EndedAt    time.Time              `json:"endedAt"`
+	DurationMs int64                  `json:"durationMs"`
+	Outcome    string                 `json:"outcome"`
+	HTTPStatus int                    `json:"httpStatus,omitempty"`
+	ProjectID  int32                  `json:"projectId,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// auditSink is a destination for audit records
+type auditSink interface {
+	write(line []byte) error
+	close() error
+}
+
+// auditLogger fans records out to every configured sink
+type auditLogger struct {
+	sinks []auditSink
+}
+
+// newAuditLogger builds the sinks from a comma-separated list such as
+// "file:/var/log/mcp-audit.jsonl,stdout,udp://syslog.example.com:514"
+func newAuditLogger(spec string) (*auditLogger, error) {
+	audit := &auditLogger{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		var sink auditSink
+		var err error
+		switch {
+		case entry == "":
+			continue
+		case entry == "stdout":
+			sink = &writerSink{file: os.Stdout}
+		case strings.HasPrefix(entry, "file:"):
+			sink, err = newFileSink(strings.TrimPrefix(entry, "file:"))
+		case strings.HasPrefix(entry, "udp://"):
+			sink, err = newSyslogSink(strings.TrimPrefix(entry, "udp://"))
+		default:
+			err = fmt.Errorf("unknown audit sink %q (expected stdout, file:<path> or udp://<host:port>)", entry)
+		}
+		if err != nil {
+			audit.close()
+			return nil, err
+		}
+		audit.sinks = append(audit.sinks, sink)
+	}
+	if len(audit.sinks) == 0 {
+		return nil, fmt.Errorf("no audit sinks configured")
+	}
+	return audit, nil
+}
+
+func (a *auditLogger) record(record auditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		logger.Error("Failed to marshal audit record", "error", err)
+		return
+	}
+	for _, sink := range a.sinks {
+		if err := sink.write(line); err != nil {
+			logger.Error("Failed to write audit record", "error", err)
+		}
+	}
+}
+
+func (a *auditLogger) close() {
+	for _, sink := range a.sinks {
+		if err := sink.close(); err != nil {
+			logger.Error("Failed to close audit sink", "error", err)
+		}
+	}
+}
+
+// writerSink appends JSON lines to a file or standard output
+type writerSink struct {
+	mu    sync.Mutex
+	file  *os.File
+	owned bool
+}
+
+func newFileSink(path string) (*writerSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("audit file sink requires a path")
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	return &writerSink{file: file, owned: true}, nil
+}
+
+func (s *writerSink) write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *writerSink) close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// syslogSink sends each record as an RFC 5424 message over UDP
+type syslogSink struct {
+	conn     net.Conn
+	hostname string
+}
+
+func newSyslogSink(addr string) (*syslogSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog %s: %w", addr, err)
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSink{conn: conn, hostname: hostname}, nil
+}
+
+func (s *syslogSink) write(line []byte) error {
+	// Facility local0, severity informational
+	message := fmt.Sprintf("<134>1 %s %s cloudera-cloud-factory-mcp %d audit - %s",
+		time.Now().UTC().Format(time.RFC3339Nano), s.hostname, os.Getpid(), line)
+	_, err := s.conn.Write([]byte(message))
+	return err
+}
+
+func (s *syslogSink) close() error {
+	return s.conn.Close()
+}
+
+// withAudit records the outcome of every call of a tool handler
+func withAudit[T any](name string, handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		if auditLog == nil {
+			return handler(ctx, args)
+		}
+
+		arguments := sanitizeArguments(args)
+		record := auditRecord{
+			Tool:      name,
+			RequestID: requestIDFromContext(ctx),
+			Arguments: arguments,
+			Caller:    callerFromContext(ctx),
+			StartedAt: time.Now().UTC(),
+			ProjectID: projectIDFromArguments(arguments),
+		}
+		// Jobs keep the caller and request ID of the call that started them
+		if job, ok := ctx.Value(progressContextKey).(jobProgress); ok {
+			record.JobID = job.id
+		}
+
+		response, err := handler(ctx, args)
+
+		record.EndedAt = time.Now().UTC()
+		record.DurationMs = record.EndedAt.Sub(record.StartedAt).Milliseconds()
+		if err != nil {
+			record.Outcome = auditOutcomeError
+			record.Error = err.Error()
+		} else {
+			record.Outcome, record.HTTPStatus, record.Error = classifyToolResponse(response)
+		}
+		auditLog.record(record)
+
+		return response, err
+	}
+}
+
+// callerFromContext describes the credentials and session behind a tool call
+func callerFromContext(ctx context.Context) auditCaller {
+	caller := auditCaller{
+		SessionID:  sessionIDFromContext(ctx),
+		RemoteAddr: remoteAddrFromContext(ctx),
+	}
+	if creds, ok := credentialsFromContext(ctx); ok {
+		caller.Credential = creds.fingerprint()
+		if creds.AccessKey != "" {
+			// Audit sinks may leave the host, so the key itself is never written
+			caller.Identity = "access-key:" + caller.Credential
+		} else {
+			caller.Identity = "bearer-token"
+		}
+		return caller
+	}
+	_, caller.Identity = defaultClient.get()
+	return caller
+}
+
+var sensitiveArgumentPattern = regexp.MustCompile(`(?i)(secret|password|token|kubeconfig|yaml|extravalues|authorization|credential$|privatekey)`)
+
+// sanitizeArguments converts tool arguments to a map with secrets and manifests redacted
+func sanitizeArguments(args interface{}) map[string]interface{} {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil
+	}
+	var arguments map[string]interface{}
+	if err := json.Unmarshal(data, &arguments); err != nil {
+		return nil
+	}
+	redactValue(arguments)
+	return arguments
+}
+
+func redactValue(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		// Key/value parameter pairs hide the value when the key looks sensitive
+		if key, ok := typed["key"].(string); ok && sensitiveArgumentPattern.MatchString(key) {
+			if _, ok := typed["value"]; ok {
+				typed["value"] = redactedValue
+			}
+		}
+		for key, nested := range typed {
+			if sensitiveArgumentPattern.MatchString(key) {
+				typed[key] = redactedValue
+				continue
+			}
+			redactValue(nested)
+		}
+	case []interface{}:
+		for _, nested := range typed {
+			redactValue(nested)
+		}
+	}
+}
+
+func projectIDFromArguments(arguments map[string]interface{}) int32 {
+	for _, key := range []string{"projectId", "ProjectId", "parentProjectId"} {
+		if value, ok := arguments[key].(float64); ok && value > 0 {
+			return int32(value)
+		}
+	}
+	return 0
+}
+
+var httpStatusPattern = regexp.MustCompile(`HTTP(?: Status:)? (\d{3})`)
+
+// classifyToolResponse derives the audit outcome, HTTP status and error text from a tool response
+func classifyToolResponse(response *mcp_golang.ToolResponse) (string, int, string) {
+	if response == nil || len(response.Content) == 0 || response.Content[0].TextContent == nil {
+		return auditOutcomeSuccess, 0, ""
+	}
+	text := response.Content[0].TextContent.Text
+
+	var fields struct {
+		Error                string `json:"error"`
+		HTTPStatus           int    `json:"httpStatus"`
+		DryRun               bool   `json:"dryRun"`
+		ConfirmationRequired bool   `json:"confirmationRequired"`
+	}
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		// Plain text responses are only produced for failures
+		if strings.HasPrefix(text, "Failed to") || strings.HasPrefix(text, "No response") {
+			return auditOutcomeError, httpStatusFromText(text), text
+		}
+		return auditOutcomeSuccess, 0, ""
+	}
+
+	switch {
+	case fields.DryRun:
+		return auditOutcomeDryRun, 0, ""
+	case fields.ConfirmationRequired:
+		return auditOutcomeConfirmationRequired, 0, ""
+	case fields.Error != "" && strings.Contains(fields.Error, "read-only mode"):
+		return auditOutcomeRefused, 0, fields.Error
+	case fields.Error != "":
+		status := fields.HTTPStatus
+		if status == 0 {
+			status = httpStatusFromText(fields.Error)
+		}
+		return auditOutcomeError, status, fields.Error
+	}
+	return auditOutcomeSuccess, 0, ""
+}
+
+func httpStatusFromText(text string) int {
+	match := httpStatusPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	status, _ := strconv.Atoi(match[1])
+	return status
+}
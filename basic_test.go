@@ -1,15 +1,33 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
-	"log"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
 )
 
 func TestMain(m *testing.M) {
 	// Initialize logger for tests
-	logger = log.New(os.Stdout, "[test] ", log.LstdFlags)
+	logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 	os.Exit(m.Run())
 }
 
@@ -41,6 +59,17 @@ func TestResponseStructMarshaling(t *testing.T) {
 				Type:   "Kubernetes",
 			},
 		},
+		{
+			name: "DryRunResponse",
+			data: DryRunResponse{
+				DryRun:  true,
+				Message: "Dry run: would commit the deployment of project 'test-project'. No changes were made.",
+				Checks:  []string{"Project 123 (test-project) exists"},
+				Requests: []DryRunRequest{
+					dryRunPost("/api/v1/project-deployment/commit", map[string]int32{"projectId": 123}),
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -290,8 +319,1524 @@ func TestArgumentStructs(t *testing.T) {
 	}
 }
 
+func TestSessionClientCache(t *testing.T) {
+	alice := taikunCredentials{AccessKey: "alice", SecretKey: "secret-a", AuthMode: "token"}
+	bob := taikunCredentials{AccessKey: "bob", SecretKey: "secret-b", AuthMode: "token"}
+
+	now := time.Now()
+	cache := newClientCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	aliceClient := cache.get(context.Background(), alice, "api.example.com")
+	if cache.get(context.Background(), alice, "api.example.com") != aliceClient {
+		t.Fatalf("Expected the same credentials to reuse the cached client")
+	}
+	if cache.get(context.Background(), bob, "api.example.com") == aliceClient {
+		t.Fatalf("Expected different credentials to get a separate client")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if cache.get(context.Background(), alice, "api.example.com") == aliceClient {
+		t.Fatalf("Expected idle client to be evicted after the TTL")
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc123")
+	if creds := credentialsFromHeaders(header); creds.Token != "abc123" || creds.isEmpty() {
+		t.Fatalf("Expected bearer token to be read from headers, got %+v", creds)
+	}
+
+	// refresh-client swaps the default client while tool calls read it; run with -race
+	shared := &sharedClient{identity: "env"}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			shared.set(aliceClient, "env:access-key:alice")
+		}()
+		go func() {
+			defer wg.Done()
+			shared.get()
+		}()
+	}
+	wg.Wait()
+	if client, identity := shared.get(); client != aliceClient || identity != "env:access-key:alice" {
+		t.Fatalf("Expected the default client and its identity to be replaced together, got %s", identity)
+	}
+
+	t.Logf("✅ Session client cache isolates credentials and evicts idle clients")
+}
+
+// readSSEEvent returns the data of the next event on an SSE stream
+func readSSEEvent(t *testing.T, stream *bufio.Reader) string {
+	t.Helper()
+	var data string
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read SSE stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" && data != "" {
+			return data
+		}
+		if value, ok := strings.CutPrefix(line, "data: "); ok {
+			data = value
+		}
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	server := newHTTPServerTransport(transportSSE, "")
+	received := make(chan *transport.BaseJsonRpcMessage, 8)
+	server.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		if message.Type != transport.BaseMessageTypeJSONRPCRequestType {
+			received <- message
+			return
+		}
+		request := message.JsonRpcRequest
+		if request.Method == "tools/call" {
+			// Progress about the call; it must only reach the caller
+			_ = server.Send(ctx, transport.NewBaseMessageNotification(&transport.BaseJSONRPCNotification{
+				Jsonrpc: "2.0", Method: "notifications/progress", Params: json.RawMessage(`{"progressToken":"p-1","progress":1,"message":"Project 412: Updating"}`),
+			}))
+		}
+		if request.Method == "slow" {
+			received <- message
+			return
+		}
+		result, _ := json.Marshal(map[string]int64{"serverId": int64(request.Id)})
+		go func() {
+			_ = server.Send(ctx, transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Jsonrpc: "2.0", Id: request.Id, Result: result}))
+		}()
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc(mcpEndpoint, server.handleMCP)
+	mux.HandleFunc(sseEndpoint, server.handleSSE)
+	mux.HandleFunc(sseMessageEndpoint, server.handleSSEMessage)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+	defer server.closeSessions()
+
+	post := func(path, body string, header http.Header) *http.Response {
+		request, _ := http.NewRequest(http.MethodPost, httpServer.URL+path, strings.NewReader(body))
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Failed to post %s: %v", path, err)
+		}
+		return response
+	}
+	openSession := func() (string, *bufio.Reader) {
+		response, err := http.Get(httpServer.URL + sseEndpoint)
+		if err != nil {
+			t.Fatalf("Failed to open SSE stream: %v", err)
+		}
+		t.Cleanup(func() { response.Body.Close() })
+		stream := bufio.NewReader(response.Body)
+		endpoint := readSSEEvent(t, stream)
+		return strings.TrimPrefix(endpoint, sseMessageEndpoint+"?sessionId="), stream
+	}
+	cancelledID := func() int64 {
+		t.Helper()
+		select {
+		case message := <-received:
+			var params struct {
+				RequestID int64 `json:"requestId"`
+			}
+			_ = json.Unmarshal(message.JsonRpcNotification.Params, &params)
+			return params.RequestID
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the cancellation to reach the MCP server")
+			return 0
+		}
+	}
+
+	// Two plain HTTP clients using the same request ID get their own response back
+	var wg sync.WaitGroup
+	serverIDs := make([]int64, 2)
+	for i := range serverIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response := post(mcpEndpoint, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, nil)
+			defer response.Body.Close()
+			var reply struct {
+				ID     int64 `json:"id"`
+				Result struct {
+					ServerID int64 `json:"serverId"`
+				} `json:"result"`
+			}
+			if err := json.NewDecoder(response.Body).Decode(&reply); err != nil || reply.ID != 1 {
+				t.Errorf("Expected the response to carry the client's ID 1, got %+v (%v)", reply, err)
+			}
+			serverIDs[i] = reply.Result.ServerID
+		}()
+	}
+	wg.Wait()
+	if serverIDs[0] == serverIDs[1] {
+		t.Errorf("Expected each request to get its own server ID, got %v", serverIDs)
+	}
+
+	// A cancellation only reaches the request of the session that sent it
+	sessionA, streamA := openSession()
+	sessionB, _ := openSession()
+	post(sseMessageEndpoint+"?sessionId="+sessionA, `{"jsonrpc":"2.0","id":7,"method":"slow"}`, nil).Body.Close()
+	var slowID int64
+	select {
+	case message := <-received:
+		slowID = int64(message.JsonRpcRequest.Id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the request to reach the MCP server")
+	}
+	cancel := `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`
+	post(sseMessageEndpoint+"?sessionId="+sessionB, cancel, nil).Body.Close()
+	if id := cancelledID(); id != -1 {
+		t.Errorf("Expected another session's cancellation to match no request, got %d", id)
+	}
+	post(mcpEndpoint, cancel, http.Header{"Mcp-Session-Id": {"not-issued"}}).Body.Close()
+	if id := cancelledID(); id != -1 {
+		t.Errorf("Expected a cancellation with an unknown session ID to match no request, got %d", id)
+	}
+	post(sseMessageEndpoint+"?sessionId="+sessionA, cancel, nil).Body.Close()
+	if id := cancelledID(); id != slowID {
+		t.Errorf("Expected the session's cancellation to target server ID %d, got %d", slowID, id)
+	}
+
+	// Progress of a plain HTTP call is dropped rather than broadcast to SSE clients
+	response := post(mcpEndpoint, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"_meta":{"progressToken":"p-1"}}}`, nil)
+	response.Body.Close()
+	broadcast := transport.NewBaseMessageNotification(&transport.BaseJSONRPCNotification{Jsonrpc: "2.0", Method: "notifications/tools/list_changed"})
+	if err := server.Send(context.Background(), broadcast); err != nil {
+		t.Fatalf("Failed to broadcast: %v", err)
+	}
+	if event := readSSEEvent(t, streamA); !strings.Contains(event, "list_changed") {
+		t.Errorf("Expected only the broadcast notification on the SSE stream, got %s", event)
+	}
+
+	t.Logf("✅ HTTP transport rewrites request IDs and scopes cancellations and notifications to their session")
+}
+
 func TestBuildInfo(t *testing.T) {
 	t.Logf("✅ Go build successful")
 	t.Logf("✅ All imports resolved")
 	t.Logf("✅ Struct definitions valid")
 }
+
+func TestToolClassification(t *testing.T) {
+	for _, name := range []string{"delete-project", "delete-servers-from-project", "delete-kubernetes-resource", "catalog-delete", "unclassified-tool"} {
+		if isReadTool(name) {
+			t.Errorf("Expected %s to be classified as a write tool", name)
+		}
+	}
+	for _, name := range []string{"list-projects", "get-app", "describe-kubernetes-resource", "wait-for-project"} {
+		if !isReadTool(name) {
+			t.Errorf("Expected %s to be classified as a read tool", name)
+		}
+	}
+
+	t.Logf("✅ Tool classification covers read and write tools")
+}
+
+func TestToolConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tools.yaml")
+	content := "allow:\n  - catalog-*\n  - list-projects\ndeny:\n  - catalog-delete\ndescriptions:\n  catalog-list: Catalogs of the platform team\ntimeouts:\n  catalog-list: 20m\n"
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write tools config: %v", err)
+	}
+
+	cfg, err := loadToolConfig(filename)
+	if err != nil {
+		t.Fatalf("Failed to load tools config: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "catalog-list", enabled: true},
+		{name: "catalog-app-add", enabled: true},
+		{name: "list-projects", enabled: true},
+		{name: "catalog-delete", enabled: false},
+		{name: "add-server-to-project", enabled: false},
+	}
+	for _, tt := range tests {
+		if got := cfg.enabled(tt.name); got != tt.enabled {
+			t.Errorf("Expected %s enabled=%t, got %t", tt.name, tt.enabled, got)
+		}
+	}
+
+	if got := cfg.description("catalog-list", "default"); got != "Catalogs of the platform team" {
+		t.Errorf("Expected description override, got %q", got)
+	}
+	if got := cfg.description("list-projects", "default"); got != "default" {
+		t.Errorf("Expected default description, got %q", got)
+	}
+
+	if got := cfg.timeout("catalog-list"); got != 20*time.Minute {
+		t.Errorf("Expected timeout override of 20m, got %v", got)
+	}
+	if got := cfg.timeout("list-projects"); got != defaultToolTimeout {
+		t.Errorf("Expected default timeout, got %v", got)
+	}
+
+	t.Logf("✅ Tool config applies globs, deny precedence, description and timeout overrides")
+}
+
+func TestConfirmationTokens(t *testing.T) {
+	now := time.Now()
+	store := &confirmationStore{tokens: make(map[string]pendingConfirmation), now: func() time.Time { return now }}
+
+	token, _ := store.issue("delete-project:123")
+	if store.consume(token, "delete-project:456") {
+		t.Fatalf("Expected token to be rejected for a different project")
+	}
+	if !store.consume(token, "delete-project:123") {
+		t.Fatalf("Expected token to confirm the action it was issued for")
+	}
+	if store.consume(token, "delete-project:123") {
+		t.Fatalf("Expected token to be single-use")
+	}
+
+	token, _ = store.issue("uninstall-app:7")
+	now = now.Add(confirmationTTL + time.Second)
+	if store.consume(token, "uninstall-app:7") {
+		t.Fatalf("Expected expired token to be rejected")
+	}
+
+	t.Logf("✅ Confirmation tokens are single-use, action-bound and expire")
+}
+
+func TestAuditSanitization(t *testing.T) {
+	arguments := sanitizeArguments(InstallAppArgs{
+		Name:         "db",
+		ProjectID:    123,
+		CatalogAppID: 456,
+		ExtraValues:  "c2VjcmV0",
+		Parameters: []AppParameter{
+			{Key: "auth.password", Value: "hunter2"},
+			{Key: "replicaCount", Value: "2"},
+		},
+	})
+
+	if arguments["extraValues"] != redactedValue {
+		t.Errorf("Expected extraValues to be redacted, got %v", arguments["extraValues"])
+	}
+	params := arguments["parameters"].([]interface{})
+	if params[0].(map[string]interface{})["value"] != redactedValue {
+		t.Errorf("Expected password parameter value to be redacted, got %v", params[0])
+	}
+	if params[1].(map[string]interface{})["value"] != "2" {
+		t.Errorf("Expected non-sensitive parameter to be kept, got %v", params[1])
+	}
+	if projectIDFromArguments(arguments) != 123 {
+		t.Errorf("Expected project ID 123, got %d", projectIDFromArguments(arguments))
+	}
+
+	outcome, status, _ := classifyToolResponse(createJSONResponse(ErrorResponse{Error: "Taikun Error: not found (HTTP 404)"}))
+	if outcome != auditOutcomeError || status != 404 {
+		t.Errorf("Expected error outcome with HTTP 404, got %s/%d", outcome, status)
+	}
+
+	creds := taikunCredentials{AccessKey: "AK123", SecretKey: "SK456", AuthMode: "token"}
+	caller := callerFromContext(withCredentials(context.Background(), creds))
+	if strings.Contains(caller.Identity, "AK123") || caller.Identity != "access-key:"+creds.fingerprint() {
+		t.Errorf("Expected the caller to be identified by the credential fingerprint, got %s", caller.Identity)
+	}
+
+	t.Logf("✅ Audit records redact secrets and capture outcome")
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := slog.New(contextHandler{slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactLogAttr})})
+
+	ctx := context.WithValue(context.Background(), requestIDContextKey, "abc123")
+	testLogger.InfoContext(ctx, "Tool call", "secretKey", "s3cr3t", "header", "Bearer eyJhbGciOiJIUzI1NiJ9.e30.sig", "projectId", 42)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Failed to parse log line: %v", err)
+	}
+	if line["requestId"] != "abc123" {
+		t.Errorf("Expected request ID on log line, got %v", line["requestId"])
+	}
+	if line["secretKey"] != redactedValue {
+		t.Errorf("Expected secretKey to be redacted, got %v", line["secretKey"])
+	}
+	if line["header"] != "Bearer "+redactedValue {
+		t.Errorf("Expected bearer token to be redacted, got %v", line["header"])
+	}
+	if line["projectId"] != float64(42) {
+		t.Errorf("Expected projectId to be kept, got %v", line["projectId"])
+	}
+
+	path := filepath.Join(t.TempDir(), "server.log")
+	file, err := newRotatingFile(path, 64, 2)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer file.Close()
+	for i := 0; i < 5; i++ {
+		if _, err := file.Write(bytes.Repeat([]byte("x"), 40)); err != nil {
+			t.Fatalf("Failed to write log file: %v", err)
+		}
+	}
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 64 {
+			t.Errorf("Expected %s to stay under the size limit, got %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected at most 2 rotated files")
+	}
+
+	t.Logf("✅ Log lines carry the request ID, secrets are redacted and files rotate by size")
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	statusCodes := map[int]string{
+		http.StatusBadRequest:          errorCodeValidation,
+		http.StatusUnauthorized:        errorCodeUnauthorized,
+		http.StatusForbidden:           errorCodeUnauthorized,
+		http.StatusNotFound:            errorCodeNotFound,
+		http.StatusConflict:            errorCodeConflict,
+		http.StatusTooManyRequests:     errorCodeRateLimited,
+		http.StatusInternalServerError: errorCodeUpstream5xx,
+		http.StatusBadGateway:          errorCodeUpstream5xx,
+		http.StatusGatewayTimeout:      errorCodeTimeout,
+	}
+	for status, expected := range statusCodes {
+		if code := errorCodeForStatus(status); code != expected {
+			t.Errorf("Expected HTTP %d to map to %s, got %s", status, expected, code)
+		}
+	}
+
+	errorResp := checkResponse(&http.Response{StatusCode: http.StatusServiceUnavailable}, "list projects")
+	var envelope ErrorResponse
+	if err := json.Unmarshal([]byte(errorResp.Content[0].TextContent.Text), &envelope); err != nil {
+		t.Fatalf("Expected a JSON error envelope: %v", err)
+	}
+	if envelope.Code != errorCodeUpstream5xx || envelope.HTTPStatus != http.StatusServiceUnavailable || !envelope.Retryable {
+		t.Errorf("Unexpected envelope for HTTP 503: %+v", envelope)
+	}
+
+	timeout := errorResponseFromError(newCodedError(errorCodeTimeout, "timeout waiting for project"), "wait failed")
+	if timeout.Code != errorCodeTimeout || !timeout.Retryable {
+		t.Errorf("Expected a retryable TIMEOUT envelope, got %+v", timeout)
+	}
+
+	handler := withErrorFlag(func(ctx context.Context, failing bool) (*mcp_golang.ToolResponse, error) {
+		if failing {
+			return createJSONResponse(ErrorResponse{Error: "Project with ID 1 not found", Code: errorCodeNotFound}), nil
+		}
+		return createJSONResponse(SuccessResponse{Message: "ok", Success: true}), nil
+	})
+	if _, err := handler(context.Background(), true); err == nil || !strings.Contains(err.Error(), `"code":"NOT_FOUND"`) {
+		t.Errorf("Expected error envelopes to be returned as tool errors, got %v", err)
+	}
+	if response, err := handler(context.Background(), false); err != nil || response == nil {
+		t.Errorf("Expected successful responses to pass through, got %v", err)
+	}
+
+	// A server that is created but never shows up is a failure with a code, so jobs record it too
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newTestTaikunClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/servers/create":
+			writeTestJSON(w, map[string]any{})
+		case "/api/v1/servers/1":
+			writeTestJSON(w, testServersDetails(5, nil))
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				cancel()
+			}()
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	response, _ := addServerToProject(ctx, client, AddServerArgs{ProjectId: 1, Name: "worker", Role: "Kubeworker", Flavor: "m1.large"})
+	text, ok := errorEnvelope(response)
+	if !ok || !strings.Contains(text, `"code":"CANCELLED"`) || !strings.Contains(text, `"verified":false`) {
+		t.Errorf("Expected an unverified server to return a CANCELLED envelope, got %s", text)
+	}
+
+	t.Logf("✅ Errors carry a stable code, HTTP status and retryable flag, and set isError")
+}
+
+func TestRetryTransport(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls%3 != 0 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	policy := retryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 500 * time.Millisecond}
+	client := &http.Client{Transport: &retryTransport{
+		next:   http.DefaultTransport,
+		policy: &policy,
+		sleep: func(ctx context.Context, delay time.Duration) error {
+			delays = append(delays, delay)
+			return nil
+		},
+	}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("Expected GET to succeed on the third attempt, got HTTP %d after %d calls", resp.StatusCode, calls)
+	}
+	for _, delay := range delays {
+		if delay != policy.MaxDelay {
+			t.Errorf("Expected Retry-After to be honored and capped at %v, got %v", policy.MaxDelay, delay)
+		}
+	}
+
+	calls = 0
+	resp, err = client.Post(server.URL, "application/json", strings.NewReader(`{"name":"x"}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("Expected POST not to be retried by default, got %d calls", calls)
+	}
+
+	calls = 0
+	ctx := context.WithValue(context.Background(), retryMutatingContextKey, true)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"name":"x"}`))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Opted-in POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("Expected opted-in POST to be retried, got HTTP %d after %d calls", resp.StatusCode, calls)
+	}
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		if delay := policy.backoff(attempt, nil); delay < 0 || delay > policy.MaxDelay {
+			t.Errorf("Backoff for attempt %d out of range: %v", attempt, delay)
+		}
+	}
+
+	t.Logf("✅ GETs retry with Retry-After, POSTs only retry when the tool opted in")
+}
+
+func TestCancellationAndDeadlines(t *testing.T) {
+	wait := func(ctx context.Context, args struct{}) (*mcp_golang.ToolResponse, error) {
+		if err := sleepContext(ctx, time.Minute); err != nil {
+			return createJSONResponse(errorResponseFromError(err, "Stopped waiting")), nil
+		}
+		return createJSONResponse(SuccessResponse{Success: true}), nil
+	}
+	code := func(response *mcp_golang.ToolResponse) string {
+		var errorResp ErrorResponse
+		if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &errorResp); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return errorResp.Code
+	}
+
+	start := time.Now()
+	response, _ := withDeadline(50*time.Millisecond, wait)(context.Background(), struct{}{})
+	if got := code(response); got != errorCodeTimeout {
+		t.Errorf("Expected %s once the deadline passes, got %q", errorCodeTimeout, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	response, _ = withDeadline(time.Hour, wait)(ctx, struct{}{})
+	if got := code(response); got != errorCodeCancelled {
+		t.Errorf("Expected %s once the client cancels, got %q", errorCodeCancelled, got)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected waits to stop promptly, took %v", elapsed)
+	}
+
+	httpTransport := newHTTPServerTransport(transportSSE, "")
+	alice := context.WithValue(context.Background(), sessionIDContextKey, "alice")
+	bob := context.WithValue(context.Background(), sessionIDContextKey, "bob")
+	request := func(ctx context.Context) {
+		httpTransport.dispatch(ctx, transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{Jsonrpc: "2.0", Id: 7, Method: "tools/call"}), &pendingCall{})
+	}
+	cancelled := func(ctx context.Context) string {
+		notification := &transport.BaseJSONRPCNotification{Jsonrpc: "2.0", Method: "notifications/cancelled", Params: json.RawMessage(`{"requestId":7}`)}
+		httpTransport.dispatch(ctx, transport.NewBaseMessageNotification(notification), nil)
+		return string(notification.Params)
+	}
+	request(alice)
+	request(bob)
+	if got := cancelled(bob); got != `{"requestId":2}` {
+		t.Errorf("Expected bob's cancellation to target server ID 2, got %s", got)
+	}
+	if got := cancelled(context.Background()); got != `{"requestId":-1}` {
+		t.Errorf("Expected a cancellation without session to match nothing, got %s", got)
+	}
+
+	t.Logf("✅ Deadlines and cancellations stop waits and reach only the sender's request")
+}
+
+type recordingTransport struct {
+	transport.Transport
+	handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+
+	mu   sync.Mutex
+	sent []*transport.BaseJsonRpcMessage
+}
+
+func (t *recordingTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.handler = handler
+}
+
+func (t *recordingTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, message)
+	return nil
+}
+
+func (t *recordingTransport) messages() []*transport.BaseJsonRpcMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*transport.BaseJsonRpcMessage(nil), t.sent...)
+}
+
+func TestProgressNotifications(t *testing.T) {
+	inner := &recordingTransport{}
+	var calls []context.Context
+	progressTransport{inner}.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		calls = append(calls, ctx)
+	})
+
+	call := func(params string) {
+		inner.handler(context.Background(), transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{
+			Jsonrpc: "2.0", Id: 1, Method: "tools/call", Params: json.RawMessage(params),
+		}))
+	}
+	call(`{"name":"wait-for-project","arguments":{"projectId":412}}`)
+	call(`{"name":"wait-for-project","arguments":{"projectId":412},"_meta":{"progressToken":"p-1"}}`)
+
+	reportProgress(calls[0], 1, 2, "ignored")
+	if len(inner.sent) != 0 {
+		t.Fatalf("Expected no notifications without a progress token, got %d", len(inner.sent))
+	}
+
+	reportProgress(calls[1], 7, 12, "Project 412: Updating, 7/12 servers ready")
+	reportProgress(calls[1], 5, 12, "Project 412: Updating, 5/12 servers ready")
+	if len(inner.sent) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d", len(inner.sent))
+	}
+	var params struct {
+		ProgressToken string  `json:"progressToken"`
+		Progress      float64 `json:"progress"`
+		Total         float64 `json:"total"`
+		Message       string  `json:"message"`
+	}
+	last := inner.sent[1].JsonRpcNotification
+	if err := json.Unmarshal(last.Params, &params); err != nil {
+		t.Fatalf("Failed to parse progress params: %v", err)
+	}
+	if last.Method != "notifications/progress" || params.ProgressToken != "p-1" || params.Total != 12 {
+		t.Errorf("Unexpected notification %s %s", last.Method, last.Params)
+	}
+	if params.Progress != 7 {
+		t.Errorf("Expected progress to never move backwards, got %v", params.Progress)
+	}
+
+	t.Logf("✅ Progress notifications follow the caller's progress token")
+}
+
+type asyncTestArgs struct {
+	Async bool `json:"async"`
+}
+
+func (a asyncTestArgs) runAsync() bool { return a.Async }
+
+func TestJobs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "jobs.json")
+	jobs = newJobManager(filename)
+	defer func() { jobs = newJobManager("") }()
+
+	handler := withAsync("wait-for-project", func(ctx context.Context, args asyncTestArgs) (*mcp_golang.ToolResponse, error) {
+		reportProgress(ctx, 1, 2, "Project 412: Updating")
+		if err := sleepContext(ctx, time.Minute); err != nil {
+			return createJSONResponse(errorResponseFromError(err, "Stopped waiting")), nil
+		}
+		return createJSONResponse(SuccessResponse{Success: true}), nil
+	})
+
+	response, _ := handler(context.Background(), asyncTestArgs{Async: true})
+	var accepted JobAcceptedResponse
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &accepted); err != nil || accepted.JobID == "" {
+		t.Fatalf("Expected a job ID, got %s", response.Content[0].TextContent.Text)
+	}
+
+	owner := jobOwner(context.Background())
+	if _, ok := jobs.get("someone-else", accepted.JobID); ok {
+		t.Error("Expected jobs to be hidden from other callers")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ := jobs.get(owner, accepted.JobID)
+		if len(job.Events) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the job to record its progress as an event")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if data, _ := os.ReadFile(filename); strings.Contains(string(data), "Project 412: Updating") {
+		t.Error("Expected progress events not to rewrite the jobs file")
+	}
+
+	// A restart interrupts the running job
+	restored, err := loadJobManager(filename)
+	if err != nil {
+		t.Fatalf("Failed to load jobs: %v", err)
+	}
+	if job, _ := restored.get(owner, accepted.JobID); job.Status != jobInterrupted {
+		t.Errorf("Expected a persisted running job to load as %s, got %s", jobInterrupted, job.Status)
+	}
+
+	if _, err := jobs.cancel(owner, accepted.JobID); err != nil {
+		t.Fatalf("Failed to cancel job: %v", err)
+	}
+	for {
+		job, _ := jobs.get(owner, accepted.JobID)
+		if job.Status == jobCancelled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the job to be cancelled, got %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := jobs.cancel(owner, accepted.JobID); err == nil {
+		t.Error("Expected cancelling a finished job to fail")
+	}
+
+	// An async call is audited with the outcome of its job, not when it is queued
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	if auditLog, err = newAuditLogger("file:" + auditFile); err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer func() { auditLog.close(); auditLog = nil }()
+	failing := withAsync("wait-for-project", withAudit("wait-for-project", func(ctx context.Context, args asyncTestArgs) (*mcp_golang.ToolResponse, error) {
+		return createJSONResponse(ErrorResponse{Error: "Project 412 failed", Code: errorCodeResourceFailed}), nil
+	}))
+	response, _ = failing(context.Background(), asyncTestArgs{Async: true})
+	if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &accepted); err != nil {
+		t.Fatalf("Expected a job ID, got %s", response.Content[0].TextContent.Text)
+	}
+	var record auditRecord
+	for {
+		data, _ := os.ReadFile(auditFile)
+		if len(data) > 0 {
+			if err := json.Unmarshal(bytes.TrimSpace(data), &record); err != nil {
+				t.Fatalf("Expected one audit record, got %s", data)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the finished job to be audited")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if record.JobID != accepted.JobID || record.Outcome != auditOutcomeError || record.Error == "" {
+		t.Errorf("Expected a failed audit record for job %s, got %+v", accepted.JobID, record)
+	}
+
+	t.Logf("✅ Async jobs record progress, persist, and can be cancelled by their owner")
+}
+
+func TestResources(t *testing.T) {
+	resource, ids, ok := matchResource("taikun://projects/412/apps/7")
+	if !ok || resource.Tool != "get-app" || len(ids) != 2 || ids[0] != 412 || ids[1] != 7 {
+		t.Errorf("Expected the app resource with IDs [412 7], got %v %v", resource, ids)
+	}
+	if _, _, ok := matchResource("taikun://projects/abc"); ok {
+		t.Error("Expected non-numeric IDs not to match")
+	}
+
+	initialize := json.RawMessage(`{"capabilities":{"resources":{"listChanged":false}},"protocolVersion":"2024-11-05","serverInfo":{"name":"x"}}`)
+	if got := string(withResourceSubscribe(initialize)); !strings.Contains(got, `"subscribe":true`) {
+		t.Errorf("Expected subscriptions to be advertised, got %s", got)
+	}
+
+	inner := &recordingTransport{}
+	resourceTransport{Transport: inner}.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		t.Errorf("Expected resource requests to be answered by the transport, got %s", message.JsonRpcRequest.Method)
+	})
+	rpcError := func(method, uri string) int {
+		inner.mu.Lock()
+		inner.sent = nil
+		inner.mu.Unlock()
+		inner.handler(context.Background(), transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{
+			Jsonrpc: "2.0", Id: 1, Method: method, Params: json.RawMessage(`{"uri":"` + uri + `"}`),
+		}))
+		deadline := time.Now().Add(5 * time.Second)
+		for len(inner.messages()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		sent := inner.messages()
+		if len(sent) == 0 || sent[0].JsonRpcError == nil {
+			t.Fatalf("Expected an error response to %s %s", method, uri)
+		}
+		return sent[0].JsonRpcError.Error.Code
+	}
+	if code := rpcError("resources/read", "taikun://clusters/1"); code != rpcResourceNotFound {
+		t.Errorf("Expected resource not found, got %d", code)
+	}
+	if code := rpcError("resources/subscribe", "taikun://projects/412"); code != rpcInvalidRequest {
+		t.Errorf("Expected subscriptions to be refused without notifications, got %d", code)
+	}
+
+	// An app URI only resolves under the project the app belongs to
+	client := newTestTaikunClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]any{
+			"id": 7, "name": "web", "namespace": "web", "status": "Ready", "version": "1.0.0", "catalogId": 3,
+			"catalogName": "apps", "catalogAppName": "nginx", "appRepoName": "bitnami", "logo": "", "values": "",
+			"autoSync": false, "releaseNotes": "", "projectName": "demo", "helmResult": "", "projectId": 99,
+			"hasJsonSchema": false, "catalogAppId": 5, "packageId": "nginx", "logs": "", "projectAppParams": []any{},
+		})
+	})
+	if envelope, ok := errorEnvelope(appInProject(context.Background(), client, ids)); !ok || !strings.Contains(envelope, errorCodeNotFound) {
+		t.Errorf("Expected app 7 of project 99 not to be found under project 412, got %s", envelope)
+	}
+	if errorResp := appInProject(context.Background(), client, []int32{99, 7}); errorResp != nil {
+		t.Errorf("Expected app 7 to be found under its own project, got %v", errorResp)
+	}
+
+	t.Logf("✅ Resource URIs resolve to their tools and subscriptions are advertised")
+}
+
+func TestWorkflowPrompts(t *testing.T) {
+	response, err := provisionClusterPrompt(ProvisionClusterPromptArgs{ProjectName: "demo", CloudCredentialId: "12", WorkerCount: "3"})
+	if err != nil {
+		t.Fatalf("provision-cluster failed: %v", err)
+	}
+	text := response.Messages[0].Content.TextContent.Text
+	order := []string{"`create-project`", "`bind-flavors-to-project`", "`add-server-to-project`", "`commit-project`"}
+	last := -1
+	for _, tool := range order {
+		index := strings.Index(text, tool)
+		if index <= last {
+			t.Errorf("Expected %s after the previous step in:\n%s", tool, text)
+		}
+		last = index
+	}
+	if !strings.Contains(text, "`Kubeworker` with count 3") {
+		t.Errorf("Expected the worker count in the guidance, got:\n%s", text)
+	}
+
+	if !strings.Contains(text, "`recommend-flavors`") {
+		t.Errorf("Expected flavors to be picked with recommend-flavors, got:\n%s", text)
+	}
+	toolSettings = &toolConfig{Deny: []string{"recommend-flavors"}}
+	if missing := unavailableTools(workflowPrompts[0].tools); len(missing) != 0 {
+		t.Errorf("Expected provision-cluster to stay available without recommend-flavors, missing %v", missing)
+	}
+	response, _ = provisionClusterPrompt(ProvisionClusterPromptArgs{ProjectName: "demo"})
+	if text := response.Messages[0].Content.TextContent.Text; strings.Contains(text, "recommend-flavors") || !strings.Contains(text, "`list-flavors`") {
+		t.Errorf("Expected the list-flavors fallback without recommend-flavors, got:\n%s", text)
+	}
+	toolSettings = &toolConfig{}
+
+	if _, err := triageProjectPrompt(TriageProjectPromptArgs{ProjectId: "abc"}); err == nil {
+		t.Error("Expected a non-numeric projectId to be rejected")
+	}
+
+	readOnlyMode = true
+	defer func() { readOnlyMode = false }()
+	if missing := unavailableTools(workflowPrompts[0].tools); len(missing) == 0 {
+		t.Error("Expected provision-cluster to be unavailable in read-only mode")
+	}
+	if missing := unavailableTools(workflowPrompts[1].tools); len(missing) != 0 {
+		t.Errorf("Expected triage-unhealthy-project to stay available in read-only mode, missing %v", missing)
+	}
+
+	t.Logf("✅ Workflow prompts list the tools in order and follow tool availability")
+}
+
+func TestProvisionSpec(t *testing.T) {
+	args := ProvisionClusterArgs{
+		Name:              "demo",
+		CloudCredentialID: 7,
+		NodePools: []NodePoolSpec{
+			{Role: "Kubeworker", Flavor: "m1.large", Count: 3},
+			{Role: "Bastion", Flavor: "m1.small"},
+			{Role: "Kubemaster", Flavor: "m1.large"},
+		},
+	}
+	pools, err := validateProvisionSpec(args)
+	if err != nil {
+		t.Fatalf("Expected a valid spec, got %v", err)
+	}
+	if pools[0].Role != "Bastion" || pools[0].Name != "bastion" || pools[0].Count != 1 || pools[2].Role != "Kubeworker" {
+		t.Errorf("Expected node pools ordered bastion first with defaults applied, got %+v", pools)
+	}
+	if flavors := provisionFlavors(pools); len(flavors) != 2 {
+		t.Errorf("Expected 2 distinct flavors, got %v", flavors)
+	}
+
+	args.NodePools = args.NodePools[:2]
+	if _, err := validateProvisionSpec(args); err == nil {
+		t.Error("Expected a spec without a Kubemaster to be rejected")
+	}
+
+	run := &provisionRun{response: ProvisionClusterResponse{Name: "demo"}, total: 3}
+	if _, ok := run.step(context.Background(), "add-server-to-project", func() (*mcp_golang.ToolResponse, error) {
+		return createJSONResponse(map[string]interface{}{"message": "not verified within timeout", "verified": false}), nil
+	}); ok {
+		t.Error("Expected an unverified server step to fail")
+	}
+	response, _ := run.fail(context.Background(), nil, args)
+	envelope, ok := errorEnvelope(response)
+	if !ok || !strings.Contains(envelope, `"status":"failed"`) || run.response.Rollback != nil {
+		t.Errorf("Expected an error envelope with the failed step and no rollback before the project exists, got %s", envelope)
+	}
+
+	// The rollback deletes the project, so a denied delete-project refuses the whole provisioning
+	toolSettings = &toolConfig{Deny: []string{"delete-*"}}
+	defer func() { toolSettings = &toolConfig{} }()
+	args.NodePools = append(args.NodePools, NodePoolSpec{Role: "Kubemaster", Flavor: "m1.large"})
+	response, _ = provisionCluster(context.Background(), nil, args)
+	if envelope, ok := errorEnvelope(response); !ok || !strings.Contains(envelope, "needs delete-project") || !strings.Contains(envelope, errorCodeValidation) {
+		t.Errorf("Expected provision-cluster to refuse when delete-project is denied, got %s", envelope)
+	}
+
+	t.Logf("✅ Cluster specs are validated and failed steps are reported")
+}
+
+func TestProjectSpecPlan(t *testing.T) {
+	spec, pools, err := parseProjectSpec(`
+apiVersion: taikun-mcp/v1
+kind: ProjectSpec
+name: demo
+cloudCredentialId: 7
+monitoring: false
+servers:
+  - {role: Bastion, flavor: small}
+  - {role: Kubemaster, flavor: large}
+  - {role: Kubeworker, flavor: large, count: 3, name: worker}
+catalogs: [apps]
+apps:
+  - {name: web, catalog: apps, package: nginx, parameters: {replicaCount: "2"}}
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+	if spec.Apps[0].Namespace != "web" {
+		t.Errorf("Expected the namespace to default to the app name, got %q", spec.Apps[0].Namespace)
+	}
+	if _, _, err := parseProjectSpec("apiVersion: taikun-mcp/v1\nkind: ProjectSpec\nname: demo\ncloudCredentialId: 7\nserverz: []\n"); err == nil {
+		t.Error("Expected unknown fields to be rejected")
+	}
+
+	str := func(value string) taikuncore.NullableString { return *taikuncore.NewNullableString(&value) }
+	server := func(id int32, name string, role taikuncore.CloudRole, flavor string) taikuncore.ServerListDto {
+		return taikuncore.ServerListDto{Id: id, Name: name, Role: role, Flavor: str(flavor)}
+	}
+	catalogID, projectID, catalogAppID := int32(3), int32(42), int32(9)
+	state := &projectState{
+		project: taikuncore.ProjectListDetailDto{Id: projectID, Name: "demo"},
+		servers: []taikuncore.ServerListDto{
+			server(1, "bastion", taikuncore.CLOUDROLE_BASTION, "small"),
+			server(2, "master", taikuncore.CLOUDROLE_KUBEMASTER, "large"),
+			server(3, "worker1", taikuncore.CLOUDROLE_KUBEWORKER, "large"),
+			server(4, "worker2", taikuncore.CLOUDROLE_KUBEWORKER, "large"),
+			server(5, "big1", taikuncore.CLOUDROLE_KUBEWORKER, "xlarge"),
+		},
+		flavors: []string{"small", "large", "xlarge"},
+		catalogs: []taikuncore.CatalogListDto{{
+			Id:                &catalogID,
+			Name:              str("apps"),
+			BoundProjects:     []taikuncore.ProjectCatalogDto{{Id: &projectID}},
+			BoundApplications: []taikuncore.AvailablePackagesDto{{Name: str("nginx"), CatalogAppId: *taikuncore.NewNullableInt32(&catalogAppID)}},
+		}},
+		apps: []taikuncore.ProjectAppDetailsDto{
+			{Id: 10, Name: "web", Namespace: "web", CatalogName: "apps", CatalogAppName: "nginx", CatalogAppId: catalogAppID,
+				ProjectAppParams: []taikuncore.ProjectAppParamDto{{Key: str("replicaCount"), Value: str("1")},
+					{Key: str("image.tag"), Value: str("1.25")}, {Key: str("service.type"), Value: str("ClusterIP")}}},
+			{Id: 11, Name: "old", Namespace: "old", CatalogName: "apps", CatalogAppName: "nginx"},
+		},
+		defaults: map[int32]map[string]string{catalogAppID: {"image.tag": "1.27", "service.type": "ClusterIP"}},
+	}
+
+	plan, err := planProjectSpec(nil, spec, pools, state, state.catalogs, 1800)
+	if err != nil {
+		t.Fatalf("Failed to plan: %v", err)
+	}
+	var actions []string
+	for _, change := range plan.changes {
+		actions = append(actions, change.Action+" "+change.Resource)
+	}
+	expected := []string{"remove servers", "add servers Kubeworker/large", "commit project demo", "uninstall app old/old", "replace app web/web"}
+	if strings.Join(actions, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("Expected changes %v, got %v", expected, actions)
+	}
+	if !plan.destructive() || len(plan.removedServers) != 1 || !strings.Contains(plan.removedServers[0], "big1") {
+		t.Errorf("Expected the xlarge worker to be removed, got %v", plan.removedServers)
+	}
+	// image.tag is not in the spec and differs from its default, which the reinstall restores
+	if diffs := plan.changes[4].Diffs; len(diffs) != 2 || diffs[0] != (ValueDiff{Key: "parameters.image.tag", From: "1.25", To: "1.27"}) ||
+		diffs[1] != (ValueDiff{Key: "parameters.replicaCount", From: "1", To: "2"}) {
+		t.Errorf("Expected image.tag and replicaCount diffs, got %+v", diffs)
+	}
+	if summary := plan.summary(); summary != (PlanSummary{Add: 2, Change: 1, Destroy: 3}) {
+		t.Errorf("Expected 2 to add, 1 to change and 3 to destroy, got %+v", summary)
+	}
+	rendered := plan.render()
+	if !strings.Contains(rendered, `-/+ app web/web`) || !strings.Contains(rendered, `parameters.replicaCount: "1" -> "2"`) ||
+		!strings.Contains(rendered, "Plan: 2 to add, 1 to change, 3 to destroy.") {
+		t.Errorf("Unexpected rendered plan:\n%s", rendered)
+	}
+	if plan.newServers["large"] != 1 {
+		t.Errorf("Expected the plan to count one new large server for the budget, got %v", plan.newServers)
+	}
+	var tools []string
+	for _, change := range plan.changes {
+		tools = append(tools, change.tools()...)
+	}
+	if !slices.Contains(tools, "delete-servers-from-project") || !slices.Contains(tools, "app-install") {
+		t.Errorf("Expected the plan to need delete-servers-from-project and app-install, got %v", tools)
+	}
+	for _, action := range []string{changeCreate, changeBind, changeRemove, changeAdd, changeCommit, changeUninstall, changeUpgrade, changeReplace, changeInstall, changeUnbind} {
+		for _, name := range (SpecChange{Action: action, Resource: "catalog apps"}).tools() {
+			if _, ok := toolAccessLevels[name]; !ok {
+				t.Errorf("Expected %s changes to need registered tools, got %s", action, name)
+			}
+		}
+	}
+	toolSettings = &toolConfig{Deny: []string{"delete-servers-from-project"}}
+	defer func() { toolSettings = &toolConfig{} }()
+	if envelope, ok := errorEnvelope(requireTools("apply-project-spec", tools...)); !ok || !strings.Contains(envelope, "needs delete-servers-from-project") {
+		t.Errorf("Expected a denied tool to refuse the spec, got %s", envelope)
+	}
+	withoutCatalogs := *spec
+	withoutCatalogs.Catalogs, withoutCatalogs.Apps = nil, nil
+	unbound, _ := planProjectSpec(nil, &withoutCatalogs, pools, state, state.catalogs, 1800)
+	if last := unbound.changes[len(unbound.changes)-1]; last.Action != changeUnbind || last.request.Method != http.MethodPut {
+		t.Errorf("Expected the catalog to be unbound with a PUT, got %s %s", last.Action, last.request.Method)
+	}
+	again, _ := planProjectSpec(nil, spec, pools, state, state.catalogs, 1800)
+	if plan.fingerprint() != again.fingerprint() {
+		t.Error("Expected the same plan to have the same fingerprint")
+	}
+
+	exported := serverPools(state.servers)
+	if len(exported) != 4 || exported[2].Name != "worker" || exported[2].Count != 2 {
+		t.Errorf("Expected servers grouped by role and flavor with a shared name prefix, got %+v", exported)
+	}
+
+	t.Logf("✅ Project specs are parsed and planned against live state")
+}
+
+func TestNodePoolScaling(t *testing.T) {
+	str := func(value string) taikuncore.NullableString { return *taikuncore.NewNullableString(&value) }
+	server := func(id int32, name string, role taikuncore.CloudRole, flavor, ip string) taikuncore.ServerListDto {
+		return taikuncore.ServerListDto{Id: id, Name: name, Role: role, Flavor: str(flavor), IpAddress: str(ip), DiskSize: 50 * 1024 * 1024 * 1024}
+	}
+	servers := []taikuncore.ServerListDto{
+		server(1, "bastion", taikuncore.CLOUDROLE_BASTION, "small", "10.0.0.1"),
+		server(2, "master", taikuncore.CLOUDROLE_KUBEMASTER, "large", "10.0.0.2"),
+		server(4, "worker-2", taikuncore.CLOUDROLE_KUBEWORKER, "large", "10.0.0.4"),
+		server(3, "worker-1", taikuncore.CLOUDROLE_KUBEWORKER, "large", "10.0.0.3"),
+		server(6, "worker-5", taikuncore.CLOUDROLE_KUBEWORKER, "large", "10.0.0.6"),
+	}
+
+	up, err := planNodePool(servers, ScaleNodePoolArgs{Count: 5})
+	if err != nil {
+		t.Fatalf("Failed to plan a scale-up: %v", err)
+	}
+	if strings.Join(up.add, ",") != "worker-6,worker-7" || up.flavor != "large" || up.diskSize != 50 {
+		t.Errorf("Expected worker-6 and worker-7 with flavor large and 50 GB, got %v %s %d", up.add, up.flavor, up.diskSize)
+	}
+
+	down, err := planNodePool(servers, ScaleNodePoolArgs{Count: 1})
+	if err != nil {
+		t.Fatalf("Failed to plan a scale-down: %v", err)
+	}
+	if len(down.remove) != 2 || down.remove[0].GetName() != "worker-5" || down.remove[1].GetName() != "worker-2" {
+		t.Errorf("Expected the newest workers worker-5 and worker-2 to be removed, got %v", down.remove)
+	}
+
+	masters := append(slices.Clone(servers), server(7, "master-2", taikuncore.CLOUDROLE_KUBEMASTER, "large", "10.0.0.7"))
+	if _, err := planNodePool(masters, ScaleNodePoolArgs{Role: "Kubemaster", Count: 1}); err == nil || !strings.Contains(err.Error(), "cannot be scaled down") {
+		t.Errorf("Expected a Kubemaster scale-down to be refused, got %v", err)
+	}
+	if up, err := planNodePool(masters, ScaleNodePoolArgs{Role: "Kubemaster", Count: 3}); err != nil || len(up.add) != 1 {
+		t.Errorf("Expected a Kubemaster scale-up to add one server, got %v", err)
+	}
+
+	for _, args := range []ScaleNodePoolArgs{
+		{Role: "Bastion", Count: 2},
+		{Role: "Kubemaster", Count: 0},
+		{Count: -1},
+		{NamePrefix: "gpu", Count: 1},
+	} {
+		if _, err := planNodePool(servers, args); err == nil {
+			t.Errorf("Expected %+v to be rejected", args)
+		}
+	}
+
+	nodes := []nodeListItem{{Name: "node-a", IP: "10.0.0.6"}, {Name: "node-b", IP: "10.0.0.3"}}
+	pods := []podListItem{
+		{Name: "web-1", Namespace: "default", Node: "node-a", State: "Running"},
+		{Name: "job-1", Namespace: "default", Node: "node-a", State: "Succeeded"},
+		{Name: "db-0", Namespace: "data", Node: "worker-2", State: "Running"},
+		{Name: "web-2", Namespace: "default", Node: "node-b", State: "Running"},
+	}
+	affected := podsOnServers(down.remove, nodes, pods)
+	if len(affected) != 2 || affected[0] != (NodePod{Server: "worker-5", Namespace: "default", Name: "web-1", Status: "Running"}) || affected[1].Server != "worker-2" {
+		t.Errorf("Expected web-1 on worker-5 and db-0 on worker-2, got %+v", affected)
+	}
+	if warnings := podWarnings(down.remove, affected); len(warnings) != 3 || !strings.Contains(warnings[0], "default/web-1") {
+		t.Errorf("Expected a warning per server with pods and a drain note, got %v", warnings)
+	}
+
+	toolSettings = &toolConfig{Deny: []string{"delete-servers-from-project"}}
+	defer func() { toolSettings = &toolConfig{} }()
+	if _, errorResp := removePoolServers(context.Background(), nil, ScaleNodePoolArgs{ProjectID: 1}, down, "demo", &ScaleNodePoolResponse{}); errorResp == nil ||
+		!strings.Contains(errorResp.Content[0].TextContent.Text, "needs delete-servers-from-project") {
+		t.Error("Expected a scale-down to be refused when delete-servers-from-project is denied")
+	}
+
+	t.Logf("✅ Node pools are scaled by name and drained servers are checked for pods")
+}
+
+func TestKubernetesUpgrade(t *testing.T) {
+	ready := taikuncore.ProjectListDetailDto{Status: taikuncore.PROJECTSTATUS_READY, Health: taikuncore.PROJECTHEALTH_HEALTHY}
+	if problems := upgradePreconditions(ready, nil); len(problems) != 0 {
+		t.Errorf("Expected a ready project to be upgradable, got %v", problems)
+	}
+	busy := taikuncore.ProjectListDetailDto{Status: taikuncore.PROJECTSTATUS_UPDATING, Health: taikuncore.PROJECTHEALTH_HEALTHY, IsLocked: true}
+	if problems := upgradePreconditions(busy, nil); len(problems) != 2 {
+		t.Errorf("Expected the status and the lock to be reported, got %v", problems)
+	}
+	disabled := false
+	visibility := &taikuncore.ProjectActionVisibilityDto{Upgrade: taikuncore.ButtonStatusDto{Enable: &disabled, Reasons: []string{"already on the latest version"}}}
+	if problems := upgradePreconditions(ready, visibility); len(problems) != 1 || problems[0] != "already on the latest version" {
+		t.Errorf("Expected the reason Taikun gives, got %v", problems)
+	}
+
+	before := []nodeListItem{{Name: "master-1", Version: "v1.28.9"}, {Name: "worker-1", Version: "v1.28.9"}}
+	after := []nodeListItem{{Name: "master-1", Version: "v1.29.4"}, {Name: "worker-1", Version: "v1.28.9"}}
+	if version := clusterVersion(after, "v1.28.9"); version != "v1.28.9, v1.29.4" {
+		t.Errorf("Expected both versions of a partial upgrade, got %q", version)
+	}
+	if version := clusterVersion(nil, "v1.29.4"); version != "v1.29.4" {
+		t.Errorf("Expected the project version without nodes, got %q", version)
+	}
+	if nodes := nodeVersions(before, after); len(nodes) != 2 || nodes[0] != (NodeVersion{Name: "master-1", Before: "v1.28.9", After: "v1.29.4"}) {
+		t.Errorf("Expected node versions paired by name, got %+v", nodes)
+	}
+
+	t.Logf("✅ Upgrade preconditions and node versions are checked")
+}
+
+func TestProjectDetails(t *testing.T) {
+	str := func(value string) taikuncore.NullableString { return *taikuncore.NewNullableString(&value) }
+	groups := groupServersByRole([]taikuncore.ServerListDto{
+		{Id: 3, Name: "worker-1", Role: taikuncore.CLOUDROLE_KUBEWORKER, Status: "Ready", Flavor: str("large")},
+		{Id: 1, Name: "bastion", Role: taikuncore.CLOUDROLE_BASTION, Status: "Ready", Flavor: str("small")},
+		{Id: 4, Name: "worker-2", Role: taikuncore.CLOUDROLE_KUBEWORKER, Status: "Failure", Flavor: str("large")},
+	})
+	if len(groups) != 2 || groups[0].Role != "Bastion" || groups[1].Count != 2 || groups[1].Statuses["Failure"] != 1 {
+		t.Errorf("Expected the bastion first and two workers with one failure, got %+v", groups)
+	}
+
+	if ref := profileRef(taikuncore.NullableInt32{}, ""); ref != nil {
+		t.Errorf("Expected no profile without an ID or name, got %+v", ref)
+	}
+	id := int32(5)
+	if ref := profileRef(*taikuncore.NewNullableInt32(&id), "default"); ref == nil || *ref != (ProfileRef{ID: 5, Name: "default"}) {
+		t.Errorf("Expected profile 5, got %+v", ref)
+	}
+
+	data, err := json.Marshal(ProjectDetailsResponse{ProjectStatusResponse: ProjectStatusResponse{ID: 42, Status: "Ready", Health: "Healthy"}})
+	if err != nil {
+		t.Fatalf("Failed to marshal project details: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil || fields["status"] != "Ready" || fields["health"] != "Healthy" {
+		t.Errorf("Expected status and health at the top level, got %s", data)
+	}
+
+	t.Logf("✅ Project details group servers by role and keep the status fields")
+}
+
+func TestProjectLifecycle(t *testing.T) {
+	if lifetime, err := parseLifetime("7d"); err != nil || lifetime != 7*24*time.Hour {
+		t.Errorf("Expected 7d to be a week, got %v (%v)", lifetime, err)
+	}
+	if lifetime, err := parseLifetime("48h"); err != nil || lifetime != 48*time.Hour {
+		t.Errorf("Expected 48h, got %v (%v)", lifetime, err)
+	}
+	for _, bad := range []string{"", "soon", "-1d", "-2h"} {
+		if _, err := parseLifetime(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	if expiresAt, err := expirationFromArgs(SetProjectExpirationArgs{ExpiresIn: "3d"}, now); err != nil || !expiresAt.Equal(now.Add(72*time.Hour)) {
+		t.Errorf("Expected an expiration three days from now, got %v (%v)", expiresAt, err)
+	}
+	if expiresAt, err := expirationFromArgs(SetProjectExpirationArgs{Clear: true}, now); err != nil || expiresAt != nil {
+		t.Errorf("Expected clear to remove the expiration, got %v (%v)", expiresAt, err)
+	}
+	for _, args := range []SetProjectExpirationArgs{
+		{},
+		{ExpiresAt: "2026-02-01T00:00:00Z", ExpiresIn: "7d"},
+		{ExpiresAt: "2026-01-01T00:00:00Z"},
+		{ExpiresAt: "tomorrow"},
+		{Clear: true, DeleteOnExpiration: true},
+	} {
+		if _, err := expirationFromArgs(args, now); err == nil {
+			t.Errorf("Expected %+v to be rejected", args)
+		}
+	}
+
+	str := func(value string) taikuncore.NullableString { return *taikuncore.NewNullableString(&value) }
+	projects := []taikuncore.ProjectListDetailDto{
+		{Id: 1, Name: "later", ExpiredAt: str("2026-01-15T12:00:00Z")},
+		{Id: 2, Name: "never"},
+		{Id: 3, Name: "soon", ExpiredAt: str("2026-01-11T00:00:00"), DeleteOnExpiration: true},
+		{Id: 4, Name: "gone", ExpiredAt: str("2026-01-09T12:00:00Z")},
+		{Id: 5, Name: "far", ExpiredAt: str("2026-03-01T00:00:00Z")},
+	}
+	expiring := expiringProjects(projects, now, 7*24*time.Hour, false)
+	if len(expiring) != 2 || expiring[0].ID != 3 || expiring[1].ID != 1 || !expiring[0].DeleteOnExpiration {
+		t.Errorf("Expected projects 3 and 1 soonest first, got %+v", expiring)
+	}
+	expiring = expiringProjects(projects, now, 7*24*time.Hour, true)
+	if len(expiring) != 3 || expiring[0].ID != 4 || !expiring[0].Expired || expiring[0].ExpiresIn != "expired 24h0m0s ago" {
+		t.Errorf("Expected the expired project first, got %+v", expiring)
+	}
+
+	// create-project validates its expiration before any request, like set-project-expiration
+	for _, args := range []CreateProjectArgs{
+		{Name: "demo", CloudCredentialID: 7, ExpiredAt: "2020-01-01T00:00:00Z"},
+		{Name: "demo", CloudCredentialID: 7, DeleteOnExpiration: true},
+	} {
+		response, _ := createProject(context.Background(), nil, args)
+		if envelope, ok := errorEnvelope(response); !ok || !strings.Contains(envelope, errorCodeValidation) {
+			t.Errorf("Expected %+v to be rejected, got %s", args, envelope)
+		}
+	}
+
+	t.Logf("✅ Project expirations parse and filter by window")
+}
+
+func TestCostReport(t *testing.T) {
+	str := func(value string) taikuncore.NullableString { return *taikuncore.NewNullableString(&value) }
+	servers := []taikuncore.ServerListDto{
+		{Id: 1, Name: "bastion", Role: taikuncore.CLOUDROLE_BASTION, Flavor: str("small"), Cpu: 1},
+		{Id: 2, Name: "worker-1", Role: taikuncore.CLOUDROLE_KUBEWORKER, Flavor: str("large"), Cpu: 3},
+	}
+	if costs := allocateServerCosts(1, servers, map[string]float64{"small": 0.1, "large": 0.4}); costs[0] != 0.2 || costs[1] != 0.8 {
+		t.Errorf("Expected the cost split by flavor price, got %v", costs)
+	}
+	if costs := allocateServerCosts(1, servers, map[string]float64{"large": 0.4}); costs[0] != 0.25 || costs[1] != 0.75 {
+		t.Errorf("Expected the cost split by vCPUs when a price is missing, got %v", costs)
+	}
+	if prices := flavorPrices([]taikuncore.BoundFlavorsForProjectsListDto{{Name: "small", LinuxPrice: str("0.05")}, {Name: "free"}}); len(prices) != 1 || prices["small"] != 0.05 {
+		t.Errorf("Expected only the priced flavor, got %v", prices)
+	}
+
+	pods := []NodePod{{Server: "worker-1", Namespace: "kube-system", Name: "kube-proxy"}, {Server: "worker-2", Namespace: "shop", Name: "web"}}
+	workers := []taikuncore.ServerListDto{{Name: "worker-1"}, {Name: "worker-2"}}
+	if idle := idleWorkers(workers, pods); len(idle) != 1 || idle[0] != "worker-1" {
+		t.Errorf("Expected worker-1 to be idle, got %v", idle)
+	}
+
+	usages := []projectUsage{
+		{
+			project:     taikuncore.ProjectListDetailDto{Id: 1, Name: "shop", OrganizationName: "acme", CloudType: "AWS", TotalHourlyCost: 1},
+			servers:     servers,
+			serverCosts: []float64{0.2, 0.8},
+			apps:        2,
+		},
+		{
+			project:     taikuncore.ProjectListDetailDto{Id: 2, Name: "sandbox", OrganizationName: "acme", CloudType: "OPENSTACK", TotalHourlyCost: 0.5, TotalServersCount: 3},
+			idleReasons: []string{"no apps installed"},
+		},
+	}
+	report := buildCostReport(usages, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if report.TotalHourlyCost != 1.5 || report.TotalMonthlyCost != 1095 || report.IdleMonthlyCost != 365 {
+		t.Errorf("Expected 1.5 per hour with 365 per month idle, got %+v", report)
+	}
+	if len(report.Organizations) != 1 || report.Organizations[0].Projects != 2 || report.Organizations[0].Servers != 5 {
+		t.Errorf("Expected one organization with both projects, got %+v", report.Organizations)
+	}
+	if len(report.Roles) != 3 || report.Roles[0].Name != "Kubeworker" || report.Roles[1].Name != unallocatedCost {
+		t.Errorf("Expected the workers first and the sandbox unallocated, got %+v", report.Roles)
+	}
+	if report.Projects[0].Name != "shop" || !report.Projects[1].Idle {
+		t.Errorf("Expected the most expensive project first and the sandbox idle, got %+v", report.Projects)
+	}
+
+	data, err := costReportCSV(report)
+	if err != nil {
+		t.Fatalf("Failed to render the report as CSV: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil || len(rows) != 13 || rows[1][0] != "total" || rows[len(rows)-1][8] != "no apps installed" {
+		t.Errorf("Expected a header, a total and a row per group and project, got %q (%v)", data, err)
+	}
+
+	t.Logf("✅ Cost reports allocate and group project costs")
+}
+
+// newTestTaikunClient returns a Taikun client whose calls are served by handler
+func newTestTaikunClient(t *testing.T, handler http.HandlerFunc) *taikungoclient.Client {
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	// A token that does not expire keeps the client from logging in
+	token := "test." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":4102444800}`)) + ".token"
+	return taikungoclient.NewClientFromToken(token, strings.TrimPrefix(server.URL, "https://"))
+}
+
+func writeTestJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// testServersDetails returns the servers of a project on a cloud credential, one per status with
+// the given flavor; the enums the client validates are set
+func testServersDetails(cloudID int32, flavors map[string]string) taikuncore.ServersListForDetails {
+	details := taikuncore.ServersListForDetails{Data: []taikuncore.ServerListDto{}}
+	statuses := make([]string, 0, len(flavors))
+	for status := range flavors {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	for i, status := range statuses {
+		server := taikuncore.ServerListDto{Id: int32(10 + i), Status: status, Role: taikuncore.CLOUDROLE_KUBEWORKER,
+			CloudType: taikuncore.CLOUDTYPE_OPENSTACK, ProxmoxRole: taikuncore.PROXMOXROLE_NONE}
+		server.SetFlavor(flavors[status])
+		details.Data = append(details.Data, server)
+	}
+	details.Project.SetCloudId(cloudID)
+	details.Project.SetStatus(taikuncore.PROJECTSTATUS_READY)
+	details.Project.SetHealth(taikuncore.PROJECTHEALTH_HEALTHY)
+	details.Project.SetCloudType(taikuncore.ECLOUDCREDENTIALTYPE_OPENSTACK)
+	details.Project.SetProxmoxStorage(taikuncore.PROXMOXSTORAGE_NFS)
+	return details
+}
+
+func TestBudgetPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "budget.yaml")
+	if err := os.WriteFile(policyFile, []byte("maxProjectHourlyCost: 2\nmaxOrganizationHourlyCost: 10\norganizations:\n  acme: 4\n  \"7\": 1\nflavorPrices:\n  m1.large: 0.5\ncpuHourlyRate: 0.1\nmemoryGBHourlyRate: 0.01\n"), 0600); err != nil {
+		t.Fatalf("Failed to write budget policy: %v", err)
+	}
+	policy, err := loadBudgetPolicy(policyFile)
+	if err != nil {
+		t.Fatalf("Failed to load budget policy: %v", err)
+	}
+	if policy.organizationLimit(3, "acme") != 4 || policy.organizationLimit(7, "other") != 1 || policy.organizationLimit(8, "other") != 10 {
+		t.Errorf("Expected organization limits by name then ID then the default, got %+v", policy.Organizations)
+	}
+
+	negative := filepath.Join(dir, "negative.yaml")
+	if err := os.WriteFile(negative, []byte("flavorPrices:\n  m1.small: -1\n"), 0600); err != nil {
+		t.Fatalf("Failed to write budget policy: %v", err)
+	}
+	if _, err := loadBudgetPolicy(negative); err == nil {
+		t.Error("Expected a negative price to be rejected")
+	}
+	misspelled := filepath.Join(dir, "misspelled.yaml")
+	if err := os.WriteFile(misspelled, []byte("maxProjectHourlyCosts: 2\n"), 0600); err != nil {
+		t.Fatalf("Failed to write budget policy: %v", err)
+	}
+	if _, err := loadBudgetPolicy(misspelled); err == nil || !strings.Contains(err.Error(), "maxProjectHourlyCosts") {
+		t.Errorf("Expected an unknown field to be rejected, got %v", err)
+	}
+
+	spec := &taikuncore.FlavorsListDto{Name: "m1.medium", Cpu: 4, Ram: 8192}
+	if price, ok := policy.priceFlavor("m1.large", map[string]float64{"m1.large": 0.9}, nil); !ok || price.price != 0.5 {
+		t.Errorf("Expected the policy price before the published one, got %+v", price)
+	}
+	if price, ok := policy.priceFlavor("m1.medium", map[string]float64{"m1.medium": 0.3}, spec); !ok || price.price != 0.3 {
+		t.Errorf("Expected the published price before the rates, got %+v", price)
+	}
+	if price, ok := policy.priceFlavor("m1.medium", nil, spec); !ok || math.Abs(price.price-0.48) > 1e-9 {
+		t.Errorf("Expected 4 vCPU and 8 GB at the rates to cost 0.48, got %+v", price)
+	}
+	if _, ok := (&budgetPolicy{}).priceFlavor("m1.medium", nil, spec); ok {
+		t.Error("Expected no price without prices or rates")
+	}
+
+	projects := []taikuncore.ProjectListDetailDto{
+		{Id: 1, OrganizationId: 3, TotalHourlyCost: 1.5},
+		{Id: 2, OrganizationId: 3, TotalHourlyCost: 2},
+		{Id: 3, OrganizationId: 9, TotalHourlyCost: 6},
+	}
+	usage := currentUsage(projects, 1, 3)
+	if usage.project != 1.5 || usage.organization != 3.5 || usage.total != 9.5 {
+		t.Errorf("Expected 1.5 for the project and 3.5 for the organization out of 9.5, got %+v", usage)
+	}
+	request := budgetRequest{projectID: 1, projectName: "shop", organizationID: 3, organizationName: "acme"}
+	if violations := policy.violations(request, usage, 0.4); len(violations) != 0 {
+		t.Errorf("Expected 0.4 more to fit, got %v", violations)
+	}
+	violations := policy.violations(request, usage, 0.6)
+	if len(violations) != 2 || !strings.Contains(violations[0], "Project 'shop' would cost 2.1000/h (1.5000 now + 0.6000), over its limit of 2.0000/h") || !strings.Contains(violations[1], "Organization 'acme'") {
+		t.Errorf("Expected the project and organization limits to be exceeded, got %v", violations)
+	}
+
+	if check, errorResp := checkBudget(context.Background(), nil, request); check != "" || errorResp != nil {
+		t.Errorf("Expected no check without a budget policy, got %q", check)
+	}
+
+	// Servers added but not committed yet count against the budget with the new ones
+	var requests int
+	client := newTestTaikunClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/api/v1/projects":
+			writeTestJSON(w, taikuncore.ProjectsList{TotalCount: 1, Data: []taikuncore.ProjectListDetailDto{
+				{Id: 1, Name: "shop", OrganizationId: 3, OrganizationName: "acme", TotalHourlyCost: 0.5,
+					Status: taikuncore.PROJECTSTATUS_READY, Health: taikuncore.PROJECTHEALTH_HEALTHY,
+					CloudType: taikuncore.ECLOUDCREDENTIALTYPE_OPENSTACK, ImportClusterType: taikuncore.IMPORTCLUSTERTYPE_NONE},
+			}})
+		case "/api/v1/servers/1":
+			writeTestJSON(w, testServersDetails(5, map[string]string{"Ready": "m1.large", "Pending": "m1.large"}))
+		case "/api/v1/flavors/projects/list":
+			writeTestJSON(w, taikuncore.BoundFlavorsForProjectsList{Data: []taikuncore.BoundFlavorsForProjectsListDto{}})
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	budget = policy
+	defer func() { budget = nil }()
+	request = budgetRequest{action: "add servers", projectID: 1, servers: map[string]int32{"m1.large": 1}}
+	if check, errorResp := checkBudget(context.Background(), client, request); errorResp != nil || !strings.Contains(check, "1.0000/h") {
+		t.Errorf("Expected one new and one uncommitted m1.large to fit, got %q %v", check, errorResp)
+	}
+	request.servers["m1.large"] = 3
+	_, errorResp := checkBudget(context.Background(), client, request)
+	if errorResp == nil || !strings.Contains(errorResp.Content[0].TextContent.Text, "1 not committed yet") {
+		t.Errorf("Expected the uncommitted server to push the project over its limit, got %v", errorResp)
+	}
+	requests = 0
+	if _, errorResp := checkBudget(withBudgetChecked(context.Background()), client, request); errorResp != nil || requests != 0 {
+		t.Errorf("Expected the steps of an already checked request to skip the check, got %d request(s) and %v", requests, errorResp)
+	}
+
+	t.Logf("✅ Budget policies price flavors and enforce their limits")
+}
+
+func TestFlavorRecommendation(t *testing.T) {
+	requirement, warnings, err := flavorRequirement(RecommendFlavorsArgs{}, "Bastion")
+	if err != nil || requirement.CPU != 2 || requirement.MemoryGB != 2 || len(warnings) != 0 {
+		t.Errorf("Expected the bastion minimum, got %+v %v (%v)", requirement, warnings, err)
+	}
+	if _, warnings, _ := flavorRequirement(RecommendFlavorsArgs{Pods: 10}, "Kubemaster"); len(warnings) != 1 {
+		t.Errorf("Expected workload hints on masters to be reported, got %v", warnings)
+	}
+	requirement, _, err = flavorRequirement(RecommendFlavorsArgs{Pods: 18, CPURequest: "300m", MemoryRequest: "512Mi", Count: 2}, "Kubeworker")
+	if err != nil || requirement.CPU != 4 || requirement.MemoryGB != 5.5 {
+		t.Errorf("Expected 4 vCPU and 5.5 GB for nine pods per worker, got %+v (%v)", requirement, err)
+	}
+	if _, _, err := flavorRequirement(RecommendFlavorsArgs{CPURequest: "lots"}, "Kubeworker"); err == nil {
+		t.Error("Expected an invalid CPU request to be rejected")
+	}
+	if _, warnings, _ := flavorRequirement(RecommendFlavorsArgs{Pods: 300, Count: 2}, "Kubeworker"); len(warnings) != 1 {
+		t.Errorf("Expected a warning for 150 pods per worker, got %v", warnings)
+	}
+
+	flavors := []taikuncore.FlavorsListDto{
+		{Name: "tiny", Cpu: 2, Ram: 4096},
+		{Name: "huge", Cpu: 16, Ram: 65536},
+		{Name: "large", Cpu: 4, Ram: 8192},
+		{Name: "xlarge", Cpu: 8, Ram: 16384},
+		{Name: "wide", Cpu: 8, Ram: 8192},
+	}
+	prices := map[string]float64{"xlarge": 0.3, "large": 0.5}
+	priceOf := func(flavor taikuncore.FlavorsListDto) (flavorPrice, bool) {
+		price, ok := prices[flavor.GetName()]
+		return flavorPrice{price: price, source: "cloud price"}, ok
+	}
+	candidates, excluded := rankFlavors(flavors, requirement, priceOf)
+	var names []string
+	for _, candidate := range candidates {
+		names = append(names, candidate.flavor.GetName())
+	}
+	if excluded != 1 || strings.Join(names, ",") != "xlarge,large,wide,huge" {
+		t.Errorf("Expected tiny left out and priced flavors first, got %v with %d excluded", names, excluded)
+	}
+	if reason := explainFlavor(candidates[0], requirement, 1); !strings.HasPrefix(reason, "Cheapest flavor that fits at 0.3000/h (cloud price)") {
+		t.Errorf("Expected the cheapest flavor to say so, got %q", reason)
+	}
+	if reason := explainFlavor(candidates[2], requirement, 3); !strings.Contains(reason, "No known price; 8 vCPU and 8 GB cover the 4 vCPU and 5.5 GB needed with 100% CPU and 45% memory to spare") {
+		t.Errorf("Expected the fit to be explained, got %q", reason)
+	}
+
+	t.Logf("✅ Flavor recommendations respect role minimums and rank by price and fit")
+}
+
+func TestDryRunSendsNoMutation(t *testing.T) {
+	var mutations []string
+	client := newTestTaikunClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			mutations = append(mutations, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/catalog":
+			catalogID := int32(3)
+			writeTestJSON(w, taikuncore.CatalogList{Data: []taikuncore.CatalogListDto{{Id: &catalogID, Name: *taikuncore.NewNullableString(taikuncore.PtrString("apps"))}}})
+		case "/api/v1/cloudcredentials":
+			credential := *taikuncore.NewCloudCredentialsForOrganizationEntity(7, []taikuncore.CommonDropdownDto{},
+				*taikuncore.NewNullableString(taikuncore.PtrString("openstack")), taikuncore.CLOUDTYPE_OPENSTACK, false)
+			writeTestJSON(w, []taikuncore.CloudCredentialsForOrganizationEntity{credential})
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	response, _ := deleteCatalog(context.Background(), client, DeleteCatalogArgs{CatalogID: 3, DryRun: true})
+	text := response.Content[0].TextContent.Text
+	if !strings.Contains(text, `"dryRun":true`) || !strings.Contains(text, `"method":"DELETE"`) || !strings.Contains(text, `"path":"/api/v1/catalog/3"`) {
+		t.Errorf("Expected the DELETE request in the dry run, got %s", text)
+	}
+
+	response, _ = createProject(context.Background(), client, CreateProjectArgs{Name: "demo", CloudCredentialID: 7, DryRun: true})
+	text = response.Content[0].TextContent.Text
+	if !strings.Contains(text, `"dryRun":true`) || !strings.Contains(text, `"method":"POST"`) || !strings.Contains(text, `"name":"demo"`) {
+		t.Errorf("Expected the POST request in the dry run, got %s", text)
+	}
+
+	if len(mutations) > 0 {
+		t.Errorf("Expected dry runs to only read, got %v", mutations)
+	}
+
+	t.Logf("✅ Dry runs return the request without sending it")
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	taikungoclient "github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"sigs.k8s.io/yaml"
+)
+
+// budget holds the policy given by --budget-policy; nil leaves provisioning unchecked
+var budget *budgetPolicy
+
+// budgetCheckedContextKey marks the steps of a tool that already checked all its servers at once
+const budgetCheckedContextKey contextKey = "budget-checked"
+
+// withBudgetChecked lets the steps of a composite tool skip the budget check that the tool ran for
+// the whole request, so an N-server change does not list every project N more times
+func withBudgetChecked(ctx context.Context) context.Context {
+	return context.WithValue(ctx, budgetCheckedContextKey, true)
+}
+
+func budgetChecked(ctx context.Context) bool {
+	checked, _ := ctx.Value(budgetCheckedContextKey).(bool)
+	return checked
+}
+
+// budgetPolicy is loaded from the YAML or JSON file given by --budget-policy. Costs are hourly,
+// in the currency of the cloud prices, and a limit of 0 is not enforced.
+type budgetPolicy struct {
+	// MaxProjectHourlyCost caps the cost of any single project
+	MaxProjectHourlyCost float64 `json:"maxProjectHourlyCost,omitempty"`
+	// MaxOrganizationHourlyCost caps the cost of all projects of an organization
+	MaxOrganizationHourlyCost float64 `json:"maxOrganizationHourlyCost,omitempty"`
+	// Organizations replaces MaxOrganizationHourlyCost for individual organizations, by name or ID
+	Organizations map[string]float64 `json:"organizations,omitempty"`
+	// MaxTotalHourlyCost caps the cost of every project the server's credentials can see
+	MaxTotalHourlyCost float64 `json:"maxTotalHourlyCost,omitempty"`
+
+	// FlavorPrices sets the hourly price of flavors by name, before any price the cloud publishes
+	FlavorPrices map[string]float64 `json:"flavorPrices,omitempty"`
+	// CPUHourlyRate and MemoryGBHourlyRate price the remaining flavors by their vCPUs and RAM
+	CPUHourlyRate      float64 `json:"cpuHourlyRate,omitempty"`
+	MemoryGBHourlyRate float64 `json:"memoryGBHourlyRate,omitempty"`
+	// AllowUnpriced counts flavors without any price as free instead of refusing them
+	AllowUnpriced bool `json:"allowUnpriced,omitempty"`
+}
+
+// loadBudgetPolicy reads and validates a budget policy file
+func loadBudgetPolicy(filename string) (*budgetPolicy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budget policy: %w", err)
+	}
+
+	// Unknown fields are rejected, so a misspelled limit cannot silently leave the budget unenforced
+	var policy budgetPolicy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse budget policy %s: %w", filename, err)
+	}
+
+	values := map[string]float64{
+		"maxProjectHourlyCost":      policy.MaxProjectHourlyCost,
+		"maxOrganizationHourlyCost": policy.MaxOrganizationHourlyCost,
+		"maxTotalHourlyCost":        policy.MaxTotalHourlyCost,
+		"cpuHourlyRate":             policy.CPUHourlyRate,
+		"memoryGBHourlyRate":        policy.MemoryGBHourlyRate,
+	}
+	for name, value := range policy.Organizations {
+		values["organizations."+name] = value
+	}
+	for name, value := range policy.FlavorPrices {
+		values["flavorPrices."+name] = value
+	}
+	for name, value := range values {
+		if value < 0 {
+			return nil, fmt.Errorf("invalid %s %v in %s: must not be negative", name, value, filename)
+		}
+	}
+	if policy.MaxProjectHourlyCost == 0 && policy.MaxOrganizationHourlyCost == 0 && len(policy.Organizations) == 0 && policy.MaxTotalHourlyCost == 0 {
+		logger.Warn("Budget policy sets no limit", "file", filename)
+	}
+
+	return &policy, nil
+}
+
+// organizationLimit returns the hourly limit of an organization, 0 when there is none
+func (p *budgetPolicy) organizationLimit(id int32, name string) float64 {
+	if limit, ok := p.Organizations[name]; ok && name != "" {
+		return limit
+	}
+	if limit, ok := p.Organizations[strconv.Itoa(int(id))]; ok {
+		return limit
+	}
+	return p.MaxOrganizationHourlyCost
+}
+
+// budgetRequest describes what a provisioning call is about to add
+type budgetRequest struct {
+	// action is shown in the refusal, such as "add 3 Kubeworker server(s) with flavor m1.large"
+	action string
+	// projectID is 0 for a project that does not exist yet; the name and organization of an
+	// existing project are looked up
+	projectID         int32
+	projectName       string
+	organizationID    int32
+	organizationName  string
+	cloudCredentialID int32
+	// servers counts the new servers by flavor
+	servers map[string]int32
+}
+
+// flavorPrice is the hourly price of one server of a flavor and where it came from
+type flavorPrice struct {
+	price  float64
+	source string
+}
+
+// priceFlavor prices a flavor from the policy, the price the cloud publishes or the policy's rates,
+// in that order. It returns false when none applies.
+func (p *budgetPolicy) priceFlavor(name string, published map[string]float64, spec *taikuncore.FlavorsListDto) (flavorPrice, bool) {
+	if price, ok := p.FlavorPrices[name]; ok {
+		return flavorPrice{price: price, source: "budget policy"}, true
+	}
+	if price, ok := published[name]; ok {
+		return flavorPrice{price: price, source: "cloud price"}, true
+	}
+	if spec != nil && (p.CPUHourlyRate > 0 || p.MemoryGBHourlyRate > 0) {
+		return flavorPrice{
+			price:  float64(spec.GetCpu())*p.CPUHourlyRate + flavorMemoryGB(*spec)*p.MemoryGBHourlyRate,
+			source: fmt.Sprintf("%d vCPU and %g GB RAM at the policy rates", spec.GetCpu(), flavorMemoryGB(*spec)),
+		}, true
+	}
+	return flavorPrice{}, false
+}
+
+// flavorMemoryGB converts the memory of a flavor, which the API reports in MiB, to GB
+func flavorMemoryGB(flavor taikuncore.FlavorsListDto) float64 {
+	return flavor.GetRam() / 1024
+}
+
+// budgetUsage is the current hourly cost of the scopes a request is checked against
+type budgetUsage struct {
+	project      float64
+	organization float64
+	total        float64
+}
+
+// currentUsage sums the hourly cost of the project, its organization and every project
+func currentUsage(projects []taikuncore.ProjectListDetailDto, projectID, organizationID int32) budgetUsage {
+	var usage budgetUsage
+	for _, project := range projects {
+		cost := project.GetTotalHourlyCost()
+		usage.total += cost
+		if organizationID != 0 && project.GetOrganizationId() == organizationID {
+			usage.organization += cost
+		}
+		if projectID != 0 && project.GetId() == projectID {
+			usage.project = cost
+		}
+	}
+	return usage
+}
+
+// violations compares the projected cost of every scope with its limit
+func (p *budgetPolicy) violations(request budgetRequest, usage budgetUsage, added float64) []string {
+	var result []string
+	check := func(scope string, current, limit float64) {
+		if limit > 0 && current+added > limit {
+			result = append(result, fmt.Sprintf("%s would cost %.4f/h (%.4f now + %.4f), over its limit of %.4f/h", scope, current+added, current, added, limit))
+		}
+	}
+	projectScope := fmt.Sprintf("Project '%s'", request.projectName)
+	if request.projectID == 0 {
+		projectScope = fmt.Sprintf("New project '%s'", request.projectName)
+	}
+	check(projectScope, usage.project, p.MaxProjectHourlyCost)
+	if request.organizationID != 0 {
+		organization := fmt.Sprintf("Organization %d", request.organizationID)
+		if request.organizationName != "" {
+			organization = fmt.Sprintf("Organization '%s'", request.organizationName)
+		}
+		check(organization, usage.organization, p.organizationLimit(request.organizationID, request.organizationName))
+	}
+	check("All projects", usage.total, p.MaxTotalHourlyCost)
+	return result
+}
+
+// cloudCredentialFlavor looks up the vCPUs and RAM of a flavor; nil when the cloud does not offer it
+func cloudCredentialFlavor(ctx context.Context, client *taikungoclient.Client, cloudCredentialID int32, name string) (*taikuncore.FlavorsListDto, *mcp_golang.ToolResponse) {
+	result, httpResponse, err := client.Client.CloudCredentialAPI.CloudcredentialsAllFlavors(ctx, cloudCredentialID).Search(name).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list flavors"); errorResp != nil {
+		return nil, errorResp
+	}
+	for i := range result.Data {
+		if result.Data[i].GetName() == name {
+			return &result.Data[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// uncommittedServers returns the cloud credential a project runs on and counts, by flavor, the servers
+// that were added to it but not committed yet. Those are not part of the hourly cost Taikun reports.
+func uncommittedServers(ctx context.Context, client *taikungoclient.Client, projectID int32) (int32, map[string]int32, *mcp_golang.ToolResponse) {
+	servers, httpResponse, err := client.Client.ServersAPI.ServersDetails(ctx, projectID).Execute()
+	if err != nil {
+		return 0, nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list project servers"); errorResp != nil {
+		return 0, nil, errorResp
+	}
+	pending := map[string]int32{}
+	for _, server := range servers.Data {
+		if strings.EqualFold(server.GetStatus(), "Pending") {
+			pending[server.GetFlavor()]++
+		}
+	}
+	return servers.Project.GetCloudId(), pending, nil
+}
+
+// checkBudget projects the hourly cost of a provisioning request and refuses it when it would exceed
+// the budget policy. It returns a line for dry run checks, or the refusal. Without a policy it does nothing.
+func checkBudget(ctx context.Context, client *taikungoclient.Client, request budgetRequest) (string, *mcp_golang.ToolResponse) {
+	if budget == nil || budgetChecked(ctx) {
+		return "", nil
+	}
+
+	projects, errorResp := listAllProjects(ctx, client, 0)
+	if errorResp != nil {
+		return "", errorResp
+	}
+	for _, project := range projects {
+		if request.projectID != 0 && project.GetId() == request.projectID {
+			request.projectName = project.GetName()
+			request.organizationID = project.GetOrganizationId()
+			request.organizationName = project.GetOrganizationName()
+			break
+		}
+		// Cloud credentials only carry the ID of their organization
+		if request.organizationName == "" && request.organizationID != 0 && project.GetOrganizationId() == request.organizationID {
+			request.organizationName = project.GetOrganizationName()
+		}
+	}
+
+	// Servers waiting for a commit will run too, so they count against the budget with the new ones
+	pending := map[string]int32{}
+	if request.projectID != 0 {
+		var cloudCredentialID int32
+		if cloudCredentialID, pending, errorResp = uncommittedServers(ctx, client, request.projectID); errorResp != nil {
+			return "", errorResp
+		}
+		if request.cloudCredentialID == 0 {
+			request.cloudCredentialID = cloudCredentialID
+		}
+	}
+
+	flavors := make([]string, 0, len(request.servers)+len(pending))
+	for flavor := range request.servers {
+		flavors = append(flavors, flavor)
+	}
+	for flavor := range pending {
+		if _, ok := request.servers[flavor]; !ok {
+			flavors = append(flavors, flavor)
+		}
+	}
+	sort.Strings(flavors)
+
+	// Servers of an existing project can use the prices the cloud publishes for its bound flavors
+	published := map[string]float64{}
+	if request.projectID != 0 && len(flavors) > 0 {
+		bound, httpResponse, err := client.Client.FlavorsAPI.FlavorsSelectedFlavorsForProject(ctx).ProjectId(request.projectID).Execute()
+		if err != nil {
+			return "", createError(httpResponse, err)
+		}
+		if errorResp := checkResponse(httpResponse, "list project flavors"); errorResp != nil {
+			return "", errorResp
+		}
+		published = flavorPrices(bound.Data)
+	}
+
+	var added float64
+	var details, unpriced []string
+	for _, flavor := range flavors {
+		var spec *taikuncore.FlavorsListDto
+		if _, ok := budget.priceFlavor(flavor, published, nil); !ok {
+			if spec, errorResp = cloudCredentialFlavor(ctx, client, request.cloudCredentialID, flavor); errorResp != nil {
+				return "", errorResp
+			}
+		}
+		count := request.servers[flavor] + pending[flavor]
+		label := fmt.Sprintf("%d x %s", count, flavor)
+		if pending[flavor] > 0 {
+			label += fmt.Sprintf(" (%d not committed yet)", pending[flavor])
+		}
+		price, ok := budget.priceFlavor(flavor, published, spec)
+		if !ok {
+			unpriced = append(unpriced, flavor)
+			details = append(details, label+": no price")
+			continue
+		}
+		added += price.price * float64(count)
+		details = append(details, fmt.Sprintf("%s at %.4f/h (%s)", label, price.price, price.source))
+	}
+	if len(unpriced) > 0 && !budget.AllowUnpriced {
+		return "", createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Refusing to %s: the budget policy cannot price flavor(s) %s. Set flavorPrices or cpuHourlyRate and memoryGBHourlyRate in the policy, or allowUnpriced to count them as free",
+				request.action, strings.Join(unpriced, ", ")),
+			Code:    errorCodeBudgetExceeded,
+			Details: strings.Join(details, "; "),
+		})
+	}
+
+	usage := currentUsage(projects, request.projectID, request.organizationID)
+	if violations := budget.violations(request, usage, added); len(violations) > 0 {
+		logger.WarnContext(ctx, "Refused by budget policy", "action", request.action, "projectId", request.projectID, "addedHourlyCost", added)
+		return "", createJSONResponse(ErrorResponse{
+			Error:   fmt.Sprintf("Refusing to %s: %s", request.action, strings.Join(violations, "; ")),
+			Code:    errorCodeBudgetExceeded,
+			Details: strings.Join(details, "; "),
+		})
+	}
+
+	return fmt.Sprintf("Projected cost of %.4f/h is within the budget policy", added), nil
+}
+
+// checkNewProjectBudget checks a project that is about to be created on a cloud credential, together
+// with the servers it will get, against the organization of the credential
+func checkNewProjectBudget(ctx context.Context, client *taikungoclient.Client, name string, cloudCredentialID int32, servers map[string]int32) (string, *mcp_golang.ToolResponse) {
+	if budget == nil || budgetChecked(ctx) {
+		return "", nil
+	}
+	credential, errorResp := resolveCloudCredential(ctx, client, cloudCredentialID)
+	if errorResp != nil {
+		return "", errorResp
+	}
+
+	action := fmt.Sprintf("create project '%s'", name)
+	var count int32
+	for _, n := range servers {
+		count += n
+	}
+	if count > 0 {
+		action += fmt.Sprintf(" with %d server(s)", count)
+	}
+	return checkBudget(ctx, client, budgetRequest{
+		action:            action,
+		projectName:       name,
+		organizationID:    credential.GetOrganizationId(),
+		cloudCredentialID: cloudCredentialID,
+		servers:           servers,
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 
@@ -14,6 +15,7 @@ import (
 type CreateCatalogArgs struct {
 	Name        string `json:"name" jsonschema:"required,description=The name of the catalog"`
 	Description string `json:"description" jsonschema:"required,description=The description of the catalog"`
+	DryRun      bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type ListCatalogsArgs struct {
@@ -31,25 +33,37 @@ type UpdateCatalogArgs struct {
 
 type DeleteCatalogArgs struct {
 	CatalogID int32 `json:"catalogId" jsonschema:"required,description=The ID of the catalog to delete"`
+	DryRun    bool  `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type BindProjectsToCatalogArgs struct {
 	CatalogID  int32   `json:"catalogId" jsonschema:"required,description=The ID of the catalog"`
 	ProjectIDs []int32 `json:"projectIds" jsonschema:"required,description=Array of project IDs to bind to the catalog"`
+	DryRun     bool    `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type UnbindProjectsFromCatalogArgs struct {
 	CatalogID  int32   `json:"catalogId" jsonschema:"required,description=The ID of the catalog"`
 	ProjectIDs []int32 `json:"projectIds" jsonschema:"required,description=Array of project IDs to unbind from the catalog"`
+	DryRun     bool    `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
-func createCatalog(client *taikungoclient.Client, args CreateCatalogArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func createCatalog(ctx context.Context, client *taikungoclient.Client, args CreateCatalogArgs) (*mcp_golang.ToolResponse, error) {
 	createCmd := taikuncore.NewCreateCatalogCommand()
 	createCmd.SetName(args.Name)
 	createCmd.SetDescription(args.Description)
 
+	if isDryRun(args.DryRun) {
+		if args.Name == "" {
+			return createJSONResponse(ErrorResponse{Error: "Catalog name is required", Code: errorCodeValidation}), nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would create catalog '%s'", args.Name),
+			nil,
+			dryRunPost("/api/v1/catalog/create", createCmd),
+		), nil
+	}
+
 	response, err := client.Client.CatalogAPI.CatalogCreate(ctx).
 		CreateCatalogCommand(*createCmd).
 		Execute()
@@ -69,9 +83,7 @@ func createCatalog(client *taikungoclient.Client, args CreateCatalogArgs) (*mcp_
 	return createJSONResponse(successResp), nil
 }
 
-func listCatalogs(client *taikungoclient.Client, args ListCatalogsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listCatalogs(ctx context.Context, client *taikungoclient.Client, args ListCatalogsArgs) (*mcp_golang.ToolResponse, error) {
 	req := client.Client.CatalogAPI.CatalogList(ctx)
 
 	if args.Limit > 0 {
@@ -180,9 +192,7 @@ func listCatalogs(client *taikungoclient.Client, args ListCatalogsArgs) (*mcp_go
 	return createJSONResponse(listResp), nil
 }
 
-func updateCatalog(client *taikungoclient.Client, args UpdateCatalogArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func updateCatalog(ctx context.Context, client *taikungoclient.Client, args UpdateCatalogArgs) (*mcp_golang.ToolResponse, error) {
 	editCmd := taikuncore.NewEditCatalogCommand()
 	editCmd.SetId(args.CatalogID)
 	editCmd.SetName(args.Name)
@@ -207,8 +217,18 @@ func updateCatalog(client *taikungoclient.Client, args UpdateCatalogArgs) (*mcp_
 	return createJSONResponse(successResp), nil
 }
 
-func deleteCatalog(client *taikungoclient.Client, args DeleteCatalogArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
+func deleteCatalog(ctx context.Context, client *taikungoclient.Client, args DeleteCatalogArgs) (*mcp_golang.ToolResponse, error) {
+	if isDryRun(args.DryRun) {
+		catalog, errorResp := resolveCatalog(ctx, client, args.CatalogID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would delete catalog '%s' (ID %d)", catalog.GetName(), args.CatalogID),
+			[]string{fmt.Sprintf("Catalog %d (%s) exists", args.CatalogID, catalog.GetName())},
+			DryRunRequest{Method: http.MethodDelete, Path: fmt.Sprintf("/api/v1/catalog/%d", args.CatalogID)},
+		), nil
+	}
 
 	response, err := client.Client.CatalogAPI.CatalogDelete(ctx, args.CatalogID).Execute()
 
@@ -227,8 +247,17 @@ func deleteCatalog(client *taikungoclient.Client, args DeleteCatalogArgs) (*mcp_
 	return createJSONResponse(successResp), nil
 }
 
-func bindProjectsToCatalog(client *taikungoclient.Client, args BindProjectsToCatalogArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
+func bindProjectsToCatalog(ctx context.Context, client *taikungoclient.Client, args BindProjectsToCatalogArgs) (*mcp_golang.ToolResponse, error) {
+	if isDryRun(args.DryRun) {
+		if len(args.ProjectIDs) == 0 {
+			return createJSONResponse(ErrorResponse{Error: "At least one project ID is required", Code: errorCodeValidation}), nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would bind %d project(s) to catalog %d", len(args.ProjectIDs), args.CatalogID),
+			nil,
+			catalogProjectsRequest(http.MethodPost, args.CatalogID, args.ProjectIDs),
+		), nil
+	}
 
 	response, err := client.Client.CatalogAPI.CatalogAddProject(ctx, args.CatalogID).
 		RequestBody(args.ProjectIDs).
@@ -249,8 +278,17 @@ func bindProjectsToCatalog(client *taikungoclient.Client, args BindProjectsToCat
 	return createJSONResponse(successResp), nil
 }
 
-func unbindProjectsFromCatalog(client *taikungoclient.Client, args UnbindProjectsFromCatalogArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
+func unbindProjectsFromCatalog(ctx context.Context, client *taikungoclient.Client, args UnbindProjectsFromCatalogArgs) (*mcp_golang.ToolResponse, error) {
+	if isDryRun(args.DryRun) {
+		if len(args.ProjectIDs) == 0 {
+			return createJSONResponse(ErrorResponse{Error: "At least one project ID is required", Code: errorCodeValidation}), nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would unbind %d project(s) from catalog %d", len(args.ProjectIDs), args.CatalogID),
+			nil,
+			catalogProjectsRequest(http.MethodPut, args.CatalogID, args.ProjectIDs),
+		), nil
+	}
 
 	response, err := client.Client.CatalogAPI.CatalogDeleteProject(ctx, args.CatalogID).
 		RequestBody(args.ProjectIDs).
@@ -271,9 +309,13 @@ func unbindProjectsFromCatalog(client *taikungoclient.Client, args UnbindProject
 	return createJSONResponse(successResp), nil
 }
 
-func addAppToCatalog(client *taikungoclient.Client, args AddAppToCatalogArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
+// catalogProjectsRequest is the request that binds projects to a catalog with a POST, or unbinds
+// them with a PUT to the same path
+func catalogProjectsRequest(method string, catalogID int32, projectIDs []int32) DryRunRequest {
+	return DryRunRequest{Method: method, Path: fmt.Sprintf("/api/v1/catalog/%d/projects", catalogID), Body: projectIDs}
+}
 
+func addAppToCatalog(ctx context.Context, client *taikungoclient.Client, args AddAppToCatalogArgs) (*mcp_golang.ToolResponse, error) {
 	createCmd := taikuncore.NewCreateCatalogAppCommand()
 	createCmd.SetCatalogId(args.CatalogID)
 	createCmd.SetRepoName(args.Repository)
@@ -300,9 +342,7 @@ func addAppToCatalog(client *taikungoclient.Client, args AddAppToCatalogArgs) (*
 	return createJSONResponse(successResp), nil
 }
 
-func addAppToCatalogWithParameters(client *taikungoclient.Client, args AddAppToCatalogWithParametersArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func addAppToCatalogWithParameters(ctx context.Context, client *taikungoclient.Client, args AddAppToCatalogWithParametersArgs) (*mcp_golang.ToolResponse, error) {
 	createCmd := taikuncore.NewCreateCatalogAppCommand()
 	createCmd.SetCatalogId(args.CatalogID)
 	createCmd.SetRepoName(args.Repository)
@@ -319,6 +359,18 @@ func addAppToCatalogWithParameters(client *taikungoclient.Client, args AddAppToC
 		createCmd.SetParameters(params)
 	}
 
+	if isDryRun(args.DryRun) {
+		catalog, errorResp := resolveCatalog(ctx, client, args.CatalogID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would add application '%s' from repository '%s' to catalog '%s'", args.PackageName, args.Repository, catalog.GetName()),
+			[]string{fmt.Sprintf("Catalog %d (%s) exists", args.CatalogID, catalog.GetName())},
+			dryRunPost("/api/v1/catalog-app/create", createCmd),
+		), nil
+	}
+
 	_, response, err := client.Client.CatalogAppAPI.CatalogAppCreate(ctx).
 		CreateCatalogAppCommand(*createCmd).
 		Execute()
@@ -339,9 +391,7 @@ func addAppToCatalogWithParameters(client *taikungoclient.Client, args AddAppToC
 	return createJSONResponse(successResp), nil
 }
 
-func removeAppFromCatalog(client *taikungoclient.Client, args RemoveAppFromCatalogArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func removeAppFromCatalog(ctx context.Context, client *taikungoclient.Client, args RemoveAppFromCatalogArgs) (*mcp_golang.ToolResponse, error) {
 	// Get the catalog apps to find the specific app to delete
 	req := client.Client.CatalogAppAPI.CatalogAppList(ctx).CatalogId(args.CatalogID)
 	if args.PackageName != "" {
@@ -392,6 +442,7 @@ func removeAppFromCatalog(client *taikungoclient.Client, args RemoveAppFromCatal
 		}
 		errorResp := ErrorResponse{
 			Error: errorMsg,
+			Code:  errorCodeNotFound,
 		}
 		return createJSONResponse(errorResp), nil
 	}
@@ -421,9 +472,7 @@ func removeAppFromCatalog(client *taikungoclient.Client, args RemoveAppFromCatal
 	return createJSONResponse(successResp), nil
 }
 
-func listCatalogApps(client *taikungoclient.Client, args ListCatalogAppsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listCatalogApps(ctx context.Context, client *taikungoclient.Client, args ListCatalogAppsArgs) (*mcp_golang.ToolResponse, error) {
 	req := client.Client.CatalogAppAPI.CatalogAppList(ctx)
 
 	// Add catalogId filter only if provided
@@ -505,13 +554,12 @@ func listCatalogApps(client *taikungoclient.Client, args ListCatalogAppsArgs) (*
 	return createJSONResponse(listResp), nil
 }
 
-func getCatalogAppParameters(client *taikungoclient.Client, args GetCatalogAppParamsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func getCatalogAppParameters(ctx context.Context, client *taikungoclient.Client, args GetCatalogAppParamsArgs) (*mcp_golang.ToolResponse, error) {
 	cmd := taikuncore.NewGetCatalogAppValueAutocompleteCommand()
 	if args.CatalogAppID == 0 && (args.PackageID == "" || args.Version == "") {
 		errorResp := ErrorResponse{
 			Error: "Provide catalogAppId or both packageId and version",
+			Code:  errorCodeValidation,
 		}
 		return createJSONResponse(errorResp), nil
 	}
@@ -542,6 +590,7 @@ func getCatalogAppParameters(client *taikungoclient.Client, args GetCatalogAppPa
 	if args.PackageID == "" {
 		errorResp := ErrorResponse{
 			Error: "Package ID is required when catalogAppId is not provided",
+			Code:  errorCodeValidation,
 		}
 		return createJSONResponse(errorResp), nil
 	}
@@ -550,6 +599,7 @@ func getCatalogAppParameters(client *taikungoclient.Client, args GetCatalogAppPa
 	if args.Version == "" {
 		errorResp := ErrorResponse{
 			Error: "Version is required when catalogAppId is not provided",
+			Code:  errorCodeValidation,
 		}
 		return createJSONResponse(errorResp), nil
 	}
@@ -704,9 +754,7 @@ func getCatalogAppParameters(client *taikungoclient.Client, args GetCatalogAppPa
 	return createJSONResponse(listResp), nil
 }
 
-func updateCatalogAppParameters(client *taikungoclient.Client, args SetCatalogAppDefaultParamsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func updateCatalogAppParameters(ctx context.Context, client *taikungoclient.Client, args SetCatalogAppDefaultParamsArgs) (*mcp_golang.ToolResponse, error) {
 	updateCmd := taikuncore.NewEditCatalogAppParamCommand()
 	updateCmd.SetCatalogAppId(args.CatalogAppID)
 
@@ -753,6 +801,17 @@ func updateCatalogAppParameters(client *taikungoclient.Client, args SetCatalogAp
 	}
 	updateCmd.SetParameters(params)
 
+	if isDryRun(args.DryRun) {
+		if _, errorResp := resolveCatalogApp(ctx, client, args.CatalogAppID); errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would set %d default parameter(s) on catalog app %d", len(params), args.CatalogAppID),
+			[]string{fmt.Sprintf("Catalog app %d exists", args.CatalogAppID)},
+			DryRunRequest{Method: http.MethodPut, Path: "/api/v1/catalog-app/edit/params", Body: updateCmd},
+		), nil
+	}
+
 	response, err := client.Client.CatalogAppAPI.CatalogAppEditParams(ctx).
 		EditCatalogAppParamCommand(*updateCmd).
 		Execute()
@@ -773,9 +832,7 @@ func updateCatalogAppParameters(client *taikungoclient.Client, args SetCatalogAp
 	return createJSONResponse(successResp), nil
 }
 
-func listRepositories(client *taikungoclient.Client, args ListRepositoriesArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listRepositories(ctx context.Context, client *taikungoclient.Client, args ListRepositoriesArgs) (*mcp_golang.ToolResponse, error) {
 	// Get all catalogs first
 	catalogReq := client.Client.CatalogAPI.CatalogList(ctx)
 	catalogList, response, err := catalogReq.Execute()
@@ -854,9 +911,7 @@ func listRepositories(client *taikungoclient.Client, args ListRepositoriesArgs)
 	return createJSONResponse(listResp), nil
 }
 
-func listAvailablePackages(client *taikungoclient.Client, args ListAvailablePackagesArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listAvailablePackages(ctx context.Context, client *taikungoclient.Client, args ListAvailablePackagesArgs) (*mcp_golang.ToolResponse, error) {
 	// Use the PackageAPI to list all available packages
 	req := client.Client.PackageAPI.PackageList(ctx)
 
@@ -956,9 +1011,7 @@ func listAvailablePackages(client *taikungoclient.Client, args ListAvailablePack
 	return createJSONResponse(listResp), nil
 }
 
-func listAvailableApps(client *taikungoclient.Client, args ListAvailableAppsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listAvailableApps(ctx context.Context, client *taikungoclient.Client, args ListAvailableAppsArgs) (*mcp_golang.ToolResponse, error) {
 	req := client.Client.PackageAPI.PackageList(ctx)
 
 	if args.Limit > 0 {
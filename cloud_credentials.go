@@ -8,9 +8,7 @@ import (
 	mcp_golang "github.com/metoro-io/mcp-golang"
 )
 
-func listCloudCredentials(client *taikungoclient.Client, args ListCloudCredentialsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listCloudCredentials(ctx context.Context, client *taikungoclient.Client, args ListCloudCredentialsArgs) (*mcp_golang.ToolResponse, error) {
 	// Switch to CloudcredentialsOrgList which is more standard and reliable
 	req := client.Client.CloudCredentialAPI.CloudcredentialsOrgList(ctx).
 		IsAdmin(args.IsAdmin)
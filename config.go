@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serverConfig holds the runtime options taken from command line flags and environment
+type serverConfig struct {
+	ShowVersion  bool
+	Transport    string
+	ListenAddr   string
+	ReadOnly     bool
+	ToolsConfig  string
+	BudgetPolicy string
+	DryRun       bool
+	AuditLog     string
+
+	LogLevel      string
+	LogFormat     string
+	LogFile       string
+	LogMaxSizeMB  int
+	LogMaxBackups int
+
+	RetryMaxAttempts   int
+	RetryBaseDelay     time.Duration
+	RetryMaxDelay      time.Duration
+	RetryMutatingTools []string
+
+	ToolTimeout time.Duration
+	JobsFile    string
+
+	RequireClientCredentials bool
+	ClientCacheTTL           time.Duration
+	ClientCacheSize          int
+}
+
+// parseServerConfig parses the command line, falling back to TAIKUN_MCP_* environment variables
+func parseServerConfig(args []string) (serverConfig, error) {
+	var cfg serverConfig
+
+	readOnly, err := envBool("TAIKUN_MCP_READ_ONLY", false)
+	if err != nil {
+		return cfg, err
+	}
+	dryRun, err := envBool("TAIKUN_MCP_DRY_RUN", false)
+	if err != nil {
+		return cfg, err
+	}
+	requireCredentials, err := envBool("TAIKUN_MCP_REQUIRE_CLIENT_CREDENTIALS", false)
+	if err != nil {
+		return cfg, err
+	}
+	cacheTTL, err := envDuration("TAIKUN_MCP_CLIENT_CACHE_TTL", 30*time.Minute)
+	if err != nil {
+		return cfg, err
+	}
+	cacheSize, err := envInt("TAIKUN_MCP_CLIENT_CACHE_SIZE", 100)
+	if err != nil {
+		return cfg, err
+	}
+	logMaxSize, err := envInt("TAIKUN_MCP_LOG_MAX_SIZE_MB", 10)
+	if err != nil {
+		return cfg, err
+	}
+	logMaxBackups, err := envInt("TAIKUN_MCP_LOG_MAX_BACKUPS", 3)
+	if err != nil {
+		return cfg, err
+	}
+	retryAttempts, err := envInt("TAIKUN_MCP_RETRY_MAX_ATTEMPTS", taikunRetryPolicy.MaxAttempts)
+	if err != nil {
+		return cfg, err
+	}
+	retryBaseDelay, err := envDuration("TAIKUN_MCP_RETRY_BASE_DELAY", taikunRetryPolicy.BaseDelay)
+	if err != nil {
+		return cfg, err
+	}
+	retryMaxDelay, err := envDuration("TAIKUN_MCP_RETRY_MAX_DELAY", taikunRetryPolicy.MaxDelay)
+	if err != nil {
+		return cfg, err
+	}
+	var retryMutatingTools string
+	toolTimeout, err := envDuration("TAIKUN_MCP_TOOL_TIMEOUT", defaultToolTimeout)
+	if err != nil {
+		return cfg, err
+	}
+
+	fs := flag.NewFlagSet("cloudera-cloud-factory-mcp", flag.ContinueOnError)
+	fs.BoolVar(&cfg.ShowVersion, "version", false, "Print version information and exit")
+	fs.BoolVar(&cfg.ShowVersion, "v", false, "Print version information and exit (shorthand)")
+	fs.StringVar(&cfg.Transport, "transport", envOrDefault("TAIKUN_MCP_TRANSPORT", transportStdio), "MCP transport to serve: stdio, http or sse")
+	fs.StringVar(&cfg.ListenAddr, "listen", envOrDefault("TAIKUN_MCP_LISTEN_ADDR", ":8080"), "Listen address for the http and sse transports")
+	fs.BoolVar(&cfg.ReadOnly, "read-only", readOnly, "Only expose list, get, describe and wait tools; refuse anything that changes state")
+	fs.StringVar(&cfg.ToolsConfig, "tools-config", os.Getenv("TAIKUN_MCP_TOOLS_CONFIG"), "YAML or JSON file with tool allow/deny lists and description overrides")
+	fs.StringVar(&cfg.BudgetPolicy, "budget-policy", os.Getenv("TAIKUN_MCP_BUDGET_POLICY"), "YAML or JSON file with hourly cost limits checked before projects and servers are created")
+	fs.BoolVar(&cfg.DryRun, "dry-run", dryRun, "Run every mutating tool as a dry run: validate and return the API request without sending it")
+	fs.StringVar(&cfg.AuditLog, "audit-log", os.Getenv("TAIKUN_MCP_AUDIT_LOG"), "Comma-separated audit sinks: file:<path>, stdout, udp://<host:port>")
+	fs.StringVar(&cfg.LogLevel, "log-level", envOrDefault("TAIKUN_MCP_LOG_LEVEL", "info"), "Minimum log level: debug, info, warn or error")
+	fs.StringVar(&cfg.LogFormat, "log-format", envOrDefault("TAIKUN_MCP_LOG_FORMAT", logFormatText), "Log format: text or json")
+	fs.StringVar(&cfg.LogFile, "log-file", os.Getenv("TAIKUN_MCP_LOG_FILE"), "Write logs to this file instead of stderr")
+	fs.IntVar(&cfg.LogMaxSizeMB, "log-max-size", logMaxSize, "Rotate the log file once it grows beyond this many megabytes (0 disables rotation)")
+	fs.IntVar(&cfg.LogMaxBackups, "log-max-backups", logMaxBackups, "Number of rotated log files to keep")
+	fs.StringVar(&cfg.JobsFile, "jobs-file", os.Getenv("TAIKUN_MCP_JOBS_FILE"), "Persist the state of asynchronous jobs to this file (default: in memory only)")
+	fs.DurationVar(&cfg.ToolTimeout, "tool-timeout", toolTimeout, "Maximum duration of a tool call, including its waits (0 disables); the tools config can override it per tool")
+	fs.IntVar(&cfg.RetryMaxAttempts, "retry-max-attempts", retryAttempts, "Attempts per Taikun API request, including the first (1 disables retries)")
+	fs.DurationVar(&cfg.RetryBaseDelay, "retry-base-delay", retryBaseDelay, "Delay before the first retry; doubled on every further attempt")
+	fs.DurationVar(&cfg.RetryMaxDelay, "retry-max-delay", retryMaxDelay, "Upper bound for a single retry delay, including Retry-After")
+	fs.StringVar(&retryMutatingTools, "retry-mutating-tools", os.Getenv("TAIKUN_MCP_RETRY_MUTATING_TOOLS"), "Comma-separated tool name patterns whose non-GET Taikun calls may be retried too")
+	fs.BoolVar(&cfg.RequireClientCredentials, "require-client-credentials", requireCredentials, "Reject tool calls that do not carry per-session Taikun credentials")
+	fs.DurationVar(&cfg.ClientCacheTTL, "client-cache-ttl", cacheTTL, "Evict per-session Taikun clients after this long without use (0 disables)")
+	fs.IntVar(&cfg.ClientCacheSize, "client-cache-size", cacheSize, "Maximum number of per-session Taikun clients kept in memory (0 is unlimited)")
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	cfg.Transport = strings.ToLower(strings.TrimSpace(cfg.Transport))
+	switch cfg.Transport {
+	case transportStdio, transportHTTP, transportSSE:
+	default:
+		return cfg, fmt.Errorf("unsupported transport %q (expected stdio, http or sse)", cfg.Transport)
+	}
+
+	if _, err := parseLogLevel(cfg.LogLevel); err != nil {
+		return cfg, err
+	}
+	cfg.LogFormat = strings.ToLower(strings.TrimSpace(cfg.LogFormat))
+	switch cfg.LogFormat {
+	case logFormatText, logFormatJSON:
+	default:
+		return cfg, fmt.Errorf("unsupported log format %q (expected text or json)", cfg.LogFormat)
+	}
+	if cfg.LogMaxSizeMB < 0 || cfg.LogMaxBackups < 0 {
+		return cfg, fmt.Errorf("log rotation limits must not be negative")
+	}
+
+	if cfg.ToolTimeout < 0 {
+		return cfg, fmt.Errorf("tool timeout must not be negative")
+	}
+	if cfg.RetryMaxAttempts < 1 {
+		return cfg, fmt.Errorf("retry max attempts must be at least 1")
+	}
+	if cfg.RetryBaseDelay <= 0 || cfg.RetryMaxDelay < cfg.RetryBaseDelay {
+		return cfg, fmt.Errorf("retry delays must be positive and the max delay at least the base delay")
+	}
+	for _, pattern := range strings.Split(retryMutatingTools, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return cfg, fmt.Errorf("invalid retry tool pattern %q: %v", pattern, err)
+		}
+		cfg.RetryMutatingTools = append(cfg.RetryMutatingTools, pattern)
+	}
+
+	if cfg.ClientCacheSize < 0 {
+		return cfg, fmt.Errorf("client cache size must not be negative")
+	}
+
+	return cfg, nil
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envBool(key string, fallback bool) (bool, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid %s value %q: %v", key, value, err)
+	}
+	return parsed, nil
+}
+
+func envDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid %s value %q: %v", key, value, err)
+	}
+	return parsed, nil
+}
+
+func envInt(key string, fallback int) (int, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid %s value %q: %v", key, value, err)
+	}
+	return parsed, nil
+}
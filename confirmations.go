@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// confirmationTTL is how long a destructive action can be confirmed after it was summarized
+const confirmationTTL = 5 * time.Minute
+
+// DestructionSummary describes what a destructive tool call is about to remove and the
+// project it affects
+type DestructionSummary struct {
+	Removes       string   `json:"removes"`
+	ProjectID     int32    `json:"projectId"`
+	ProjectName   string   `json:"projectName"`
+	ServerCount   int32    `json:"serverCount"`
+	Servers       []string `json:"servers,omitempty"`
+	HourlyCost    float64  `json:"hourlyCost"`
+	InstalledApps []string `json:"installedApps"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// ConfirmationRequiredResponse is returned by the first call of a destructive tool
+type ConfirmationRequiredResponse struct {
+	ConfirmationRequired bool               `json:"confirmationRequired"`
+	Message              string             `json:"message"`
+	Summary              DestructionSummary `json:"summary"`
+	ConfirmationToken    string             `json:"confirmationToken"`
+	ExpiresAt            string             `json:"expiresAt"`
+}
+
+type pendingConfirmation struct {
+	action    string
+	expiresAt time.Time
+}
+
+// confirmationStore holds single-use tokens, each bound to one exact action
+type confirmationStore struct {
+	mu     sync.Mutex
+	tokens map[string]pendingConfirmation
+	now    func() time.Time
+}
+
+var confirmations = &confirmationStore{
+	tokens: make(map[string]pendingConfirmation),
+	now:    time.Now,
+}
+
+// issue creates a token that confirms the given action
+func (s *confirmationStore) issue(action string) (string, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for token, pending := range s.tokens {
+		if now.After(pending.expiresAt) {
+			delete(s.tokens, token)
+		}
+	}
+
+	token := newSessionID()
+	expiresAt := now.Add(confirmationTTL)
+	s.tokens[token] = pendingConfirmation{action: action, expiresAt: expiresAt}
+	return token, expiresAt
+}
+
+// consume validates and invalidates a token; it only succeeds for the action it was issued for
+func (s *confirmationStore) consume(token, action string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, ok := s.tokens[token]
+	if !ok || pending.action != action {
+		return false
+	}
+	delete(s.tokens, token)
+	return !s.now().After(pending.expiresAt)
+}
+
+// confirmDestructive implements the two-phase protocol for destructive tools. Without a token
+// it summarizes the action and returns a token; with a valid token it returns nil so the caller
+// proceeds. action must identify the exact target so a token cannot be reused for another one.
+func confirmDestructive(ctx context.Context, action, token, tool string, summarize func() (*DestructionSummary, *mcp_golang.ToolResponse)) *mcp_golang.ToolResponse {
+	if token != "" {
+		if confirmations.consume(token, action) {
+			logger.InfoContext(ctx, "Confirmed destructive action", "action", action)
+			return nil
+		}
+		return createJSONResponse(ErrorResponse{
+			Error:   "Invalid or expired confirmation token",
+			Code:    errorCodeValidation,
+			Details: fmt.Sprintf("Call %s again without confirmationToken to review the action and get a new token", tool),
+		})
+	}
+
+	summary, errorResp := summarize()
+	if errorResp != nil {
+		return errorResp
+	}
+
+	token, expiresAt := confirmations.issue(action)
+	logger.InfoContext(ctx, "Issued confirmation token", "action", action)
+	return createJSONResponse(ConfirmationRequiredResponse{
+		ConfirmationRequired: true,
+		Message: fmt.Sprintf("Nothing was deleted yet. Review the summary, then call %s again with the same arguments and confirmationToken within %s to proceed.",
+			tool, confirmationTTL),
+		Summary:           *summary,
+		ConfirmationToken: token,
+		ExpiresAt:         expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// summarizeProject collects the name, server count, hourly cost and installed apps of a project
+func summarizeProject(ctx context.Context, client *taikungoclient.Client, projectID int32) (*DestructionSummary, *mcp_golang.ToolResponse) {
+	project, errorResp := resolveProject(ctx, client, projectID)
+	if errorResp != nil {
+		return nil, errorResp
+	}
+
+	apps, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappList(ctx).ProjectId(projectID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list applications"); errorResp != nil {
+		return nil, errorResp
+	}
+
+	installedApps := []string{}
+	if apps != nil {
+		for _, app := range apps.Data {
+			installedApps = append(installedApps, fmt.Sprintf("%s (namespace %s)", app.GetName(), app.GetNamespace()))
+		}
+	}
+
+	return &DestructionSummary{
+		ProjectID:     projectID,
+		ProjectName:   project.GetName(),
+		ServerCount:   project.GetTotalServersCount(),
+		HourlyCost:    project.GetTotalHourlyCost(),
+		InstalledApps: installedApps,
+	}, nil
+}
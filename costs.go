@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	taikungoclient "github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// unallocatedCost groups the cost that cannot be attributed to a server, such as that of projects
+// without servers or whose servers could not be loaded
+const unallocatedCost = "unallocated"
+
+type CostReportArgs struct {
+	OrganizationID int32  `json:"organizationId,omitempty" jsonschema:"description=Only report the projects of this organization (default: every project you can see)"`
+	Format         string `json:"format,omitempty" jsonschema:"description=Output format: json or csv (default: json)"`
+	Async          bool   `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
+}
+
+func (a CostReportArgs) runAsync() bool { return a.Async }
+
+type CostGroup struct {
+	Name        string  `json:"name"`
+	Projects    int     `json:"projects"`
+	Servers     int     `json:"servers"`
+	HourlyCost  float64 `json:"hourlyCost"`
+	MonthlyCost float64 `json:"monthlyCost"`
+}
+
+type ProjectCostLine struct {
+	ID           int32    `json:"id"`
+	Name         string   `json:"name"`
+	Organization string   `json:"organization"`
+	CloudType    string   `json:"cloudType"`
+	Status       string   `json:"status"`
+	Servers      int      `json:"servers"`
+	Apps         int      `json:"apps"`
+	HourlyCost   float64  `json:"hourlyCost"`
+	MonthlyCost  float64  `json:"monthlyCost"`
+	Idle         bool     `json:"idle"`
+	IdleReasons  []string `json:"idleReasons,omitempty"`
+}
+
+type CostReportResponse struct {
+	GeneratedAt      string            `json:"generatedAt"`
+	HoursPerMonth    int               `json:"hoursPerMonth"`
+	TotalHourlyCost  float64           `json:"totalHourlyCost"`
+	TotalMonthlyCost float64           `json:"totalMonthlyCost"`
+	IdleMonthlyCost  float64           `json:"idleMonthlyCost"`
+	Organizations    []CostGroup       `json:"organizations"`
+	CloudTypes       []CostGroup       `json:"cloudTypes"`
+	Roles            []CostGroup       `json:"roles"`
+	Flavors          []CostGroup       `json:"flavors"`
+	Projects         []ProjectCostLine `json:"projects"`
+	Warnings         []string          `json:"warnings,omitempty"`
+	Message          string            `json:"message"`
+}
+
+// projectUsage is what the report loads for one project besides the project list
+type projectUsage struct {
+	project taikuncore.ProjectListDetailDto
+	// servers is nil when they could not be loaded
+	servers []taikuncore.ServerListDto
+	// serverCosts holds the hourly cost of each server, in the order of servers
+	serverCosts []float64
+	idleReasons []string
+	apps        int
+}
+
+// costAccumulator sums costs per group name, counting each project once per group
+type costAccumulator struct {
+	groups   map[string]*CostGroup
+	projects map[string]map[int32]bool
+}
+
+func newCostAccumulator() *costAccumulator {
+	return &costAccumulator{groups: map[string]*CostGroup{}, projects: map[string]map[int32]bool{}}
+}
+
+func (a *costAccumulator) add(name string, projectID int32, servers int, hourly float64) {
+	if name == "" {
+		name = "unknown"
+	}
+	group, ok := a.groups[name]
+	if !ok {
+		group = &CostGroup{Name: name}
+		a.groups[name] = group
+		a.projects[name] = map[int32]bool{}
+	}
+	if !a.projects[name][projectID] {
+		a.projects[name][projectID] = true
+		group.Projects++
+	}
+	group.Servers += servers
+	group.HourlyCost += hourly
+}
+
+// sorted returns the groups with the most expensive first
+func (a *costAccumulator) sorted() []CostGroup {
+	result := []CostGroup{}
+	for _, group := range a.groups {
+		group.HourlyCost = roundCost(group.HourlyCost)
+		group.MonthlyCost = roundCost(group.HourlyCost * hoursPerMonth)
+		result = append(result, *group)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].HourlyCost != result[j].HourlyCost {
+			return result[i].HourlyCost > result[j].HourlyCost
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// roundCost drops floating point noise from sums of prices
+func roundCost(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// flavorPrices maps the flavors bound to a project to their hourly Linux price, where the cloud publishes one
+func flavorPrices(flavors []taikuncore.BoundFlavorsForProjectsListDto) map[string]float64 {
+	prices := map[string]float64{}
+	for _, flavor := range flavors {
+		price, err := strconv.ParseFloat(flavor.GetLinuxPrice(), 64)
+		if err == nil && price > 0 {
+			prices[flavor.GetName()] = price
+		}
+	}
+	return prices
+}
+
+// allocateServerCosts splits the hourly cost of a project across its servers. Taikun only reports the
+// total, so servers are weighted by the price of their flavor (or their spot price) when every server
+// has one, and by their vCPUs otherwise.
+func allocateServerCosts(hourly float64, servers []taikuncore.ServerListDto, prices map[string]float64) []float64 {
+	weights := make([]float64, len(servers))
+	priced := true
+	for i, server := range servers {
+		switch {
+		case server.GetSpotInstance() && server.GetSpotPrice() > 0:
+			weights[i] = server.GetSpotPrice()
+		case prices[server.GetFlavor()] > 0:
+			weights[i] = prices[server.GetFlavor()]
+		default:
+			priced = false
+		}
+	}
+	if !priced {
+		for i, server := range servers {
+			weights[i] = float64(server.GetCpu())
+		}
+	}
+
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+	costs := make([]float64, len(servers))
+	for i := range servers {
+		if total > 0 {
+			costs[i] = hourly * weights[i] / total
+		} else {
+			costs[i] = hourly / float64(len(servers))
+		}
+	}
+	return costs
+}
+
+// systemNamespace reports whether pods in a namespace belong to the cluster itself rather than to a workload
+func systemNamespace(namespace string) bool {
+	return strings.HasPrefix(namespace, "kube-") || strings.HasPrefix(namespace, "calico-") || namespace == "tigera-operator"
+}
+
+// idleWorkers returns the names of the workers that run no pods outside the system namespaces
+func idleWorkers(workers []taikuncore.ServerListDto, pods []NodePod) []string {
+	busy := map[string]bool{}
+	for _, pod := range pods {
+		if !systemNamespace(pod.Namespace) {
+			busy[pod.Server] = true
+		}
+	}
+	var idle []string
+	for _, worker := range workers {
+		if !busy[worker.GetName()] {
+			idle = append(idle, worker.GetName())
+		}
+	}
+	return idle
+}
+
+// loadProjectUsage reads the servers, flavor prices, apps and pods of a project. Every part is best
+// effort; what cannot be loaded is returned as a warning and leaves the project out of the idle check
+// it would have served.
+func loadProjectUsage(ctx context.Context, client *taikungoclient.Client, project taikuncore.ProjectListDetailDto) (projectUsage, []string) {
+	usage := projectUsage{project: project}
+	var warnings []string
+	warn := func(section string, err error) {
+		logger.WarnContext(ctx, "Failed to load cost report section", "projectId", project.GetId(), "section", section, "error", err)
+		warnings = append(warnings, fmt.Sprintf("Could not load the %s of project '%s' (ID %d): %v", section, project.GetName(), project.GetId(), err))
+	}
+
+	servers, httpResponse, err := client.Client.ServersAPI.ServersDetails(ctx, project.GetId()).Execute()
+	if err := sectionError(httpResponse, err); err != nil {
+		warn("servers", err)
+	} else {
+		usage.servers = []taikuncore.ServerListDto{}
+		if servers != nil {
+			usage.servers = servers.Data
+		}
+	}
+
+	if len(usage.servers) > 0 {
+		prices := map[string]float64{}
+		flavors, httpResponse, err := client.Client.FlavorsAPI.FlavorsSelectedFlavorsForProject(ctx).ProjectId(project.GetId()).Execute()
+		if err := sectionError(httpResponse, err); err != nil {
+			warn("flavor prices", err)
+		} else if flavors != nil {
+			prices = flavorPrices(flavors.Data)
+		}
+		usage.serverCosts = allocateServerCosts(project.GetTotalHourlyCost(), usage.servers, prices)
+	}
+
+	apps, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappList(ctx).ProjectId(project.GetId()).Execute()
+	if err := sectionError(httpResponse, err); err != nil {
+		warn("applications", err)
+	} else {
+		if apps != nil {
+			usage.apps = len(apps.Data)
+		}
+		if usage.apps == 0 {
+			usage.idleReasons = append(usage.idleReasons, "no apps installed")
+		}
+	}
+
+	// Pods can only be listed on a running cluster
+	var workers []taikuncore.ServerListDto
+	for _, server := range usage.servers {
+		if server.GetRole() == taikuncore.CLOUDROLE_KUBEWORKER {
+			workers = append(workers, server)
+		}
+	}
+	if len(workers) > 0 && project.GetStatus() == taikuncore.PROJECTSTATUS_READY {
+		nodes, _, err := fetchKubernetesListItems[nodeListItem](ctx, client, project.GetId(), "nodes", 0, 0, "")
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to list nodes for the cost report", "projectId", project.GetId(), "error", err)
+		}
+		pods, _, err := fetchKubernetesListItems[podListItem](ctx, client, project.GetId(), "pods", 0, 0, "")
+		if err != nil {
+			warn("pods", err)
+		} else if idle := idleWorkers(workers, podsOnServers(workers, nodes, pods)); len(idle) > 0 {
+			usage.idleReasons = append(usage.idleReasons, fmt.Sprintf("%d of %d worker(s) run no workload pods: %s", len(idle), len(workers), strings.Join(idle, ", ")))
+		}
+	}
+
+	return usage, warnings
+}
+
+// buildCostReport aggregates the usage of every project into the report
+func buildCostReport(usages []projectUsage, now time.Time) CostReportResponse {
+	organizations, cloudTypes, roles, flavors := newCostAccumulator(), newCostAccumulator(), newCostAccumulator(), newCostAccumulator()
+	report := CostReportResponse{
+		GeneratedAt:   now.UTC().Format(time.RFC3339),
+		HoursPerMonth: hoursPerMonth,
+		Projects:      []ProjectCostLine{},
+	}
+
+	var total, idle float64
+	for _, usage := range usages {
+		project := usage.project
+		hourly := project.GetTotalHourlyCost()
+		servers := len(usage.servers)
+		if usage.servers == nil {
+			servers = int(project.GetTotalServersCount())
+		}
+		total += hourly
+
+		organizations.add(project.GetOrganizationName(), project.GetId(), servers, hourly)
+		cloudTypes.add(string(project.GetCloudType()), project.GetId(), servers, hourly)
+		if len(usage.serverCosts) == 0 {
+			if hourly > 0 {
+				roles.add(unallocatedCost, project.GetId(), 0, hourly)
+				flavors.add(unallocatedCost, project.GetId(), 0, hourly)
+			}
+		} else {
+			for i, server := range usage.servers {
+				roles.add(string(server.GetRole()), project.GetId(), 1, usage.serverCosts[i])
+				flavors.add(server.GetFlavor(), project.GetId(), 1, usage.serverCosts[i])
+			}
+		}
+
+		line := ProjectCostLine{
+			ID:           project.GetId(),
+			Name:         project.GetName(),
+			Organization: project.GetOrganizationName(),
+			CloudType:    string(project.GetCloudType()),
+			Status:       string(project.GetStatus()),
+			Servers:      servers,
+			Apps:         usage.apps,
+			HourlyCost:   roundCost(hourly),
+			MonthlyCost:  roundCost(hourly * hoursPerMonth),
+			Idle:         len(usage.idleReasons) > 0,
+			IdleReasons:  usage.idleReasons,
+		}
+		if line.Idle {
+			idle += hourly
+		}
+		report.Projects = append(report.Projects, line)
+	}
+	sort.SliceStable(report.Projects, func(i, j int) bool { return report.Projects[i].HourlyCost > report.Projects[j].HourlyCost })
+
+	report.TotalHourlyCost = roundCost(total)
+	report.TotalMonthlyCost = roundCost(total * hoursPerMonth)
+	report.IdleMonthlyCost = roundCost(idle * hoursPerMonth)
+	report.Organizations = organizations.sorted()
+	report.CloudTypes = cloudTypes.sorted()
+	report.Roles = roles.sorted()
+	report.Flavors = flavors.sorted()
+	return report
+}
+
+// costReportCSV renders the report as one table, with a row per group and dimension so it can be pivoted
+func costReportCSV(report CostReportResponse) (string, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	formatCost := func(value float64) string { return strconv.FormatFloat(value, 'f', 4, 64) }
+
+	rows := [][]string{
+		{"dimension", "name", "organization", "projects", "servers", "hourlyCost", "monthlyCost", "idle", "notes"},
+		{"total", "all", "", strconv.Itoa(len(report.Projects)), "", formatCost(report.TotalHourlyCost), formatCost(report.TotalMonthlyCost), "", fmt.Sprintf("generated %s", report.GeneratedAt)},
+	}
+	for _, dimension := range []struct {
+		name   string
+		groups []CostGroup
+	}{
+		{"organization", report.Organizations},
+		{"cloudType", report.CloudTypes},
+		{"role", report.Roles},
+		{"flavor", report.Flavors},
+	} {
+		for _, group := range dimension.groups {
+			rows = append(rows, []string{dimension.name, group.Name, "", strconv.Itoa(group.Projects), strconv.Itoa(group.Servers), formatCost(group.HourlyCost), formatCost(group.MonthlyCost), "", ""})
+		}
+	}
+	for _, project := range report.Projects {
+		rows = append(rows, []string{"project", project.Name, project.Organization, "1", strconv.Itoa(project.Servers), formatCost(project.HourlyCost), formatCost(project.MonthlyCost), strconv.FormatBool(project.Idle), strings.Join(project.IdleReasons, "; ")})
+	}
+	for _, warning := range report.Warnings {
+		rows = append(rows, []string{"warning", "", "", "", "", "", "", "", warning})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func costReport(ctx context.Context, client *taikungoclient.Client, args CostReportArgs) (*mcp_golang.ToolResponse, error) {
+	format := strings.ToLower(args.Format)
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Invalid format %q; use json or csv", args.Format),
+			Code:  errorCodeValidation,
+		}), nil
+	}
+
+	projects, errorResp := listAllProjects(ctx, client, args.OrganizationID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	var usages []projectUsage
+	var warnings []string
+	for i, project := range projects {
+		if ctx.Err() != nil {
+			return createJSONResponse(errorResponseFromError(ctx.Err(),
+				fmt.Sprintf("Stopped the cost report after %d of %d project(s): %v", i, len(projects), ctx.Err()))), nil
+		}
+		reportProgress(ctx, float64(i), float64(len(projects)), fmt.Sprintf("Checking project %s", project.GetName()))
+		usage, projectWarnings := loadProjectUsage(ctx, client, project)
+		usages = append(usages, usage)
+		warnings = append(warnings, projectWarnings...)
+	}
+
+	report := buildCostReport(usages, time.Now())
+	report.Warnings = warnings
+	idle := 0
+	for _, project := range report.Projects {
+		if project.Idle {
+			idle++
+		}
+	}
+	report.Message = fmt.Sprintf("%d project(s) cost %.2f per hour (%.2f per month); %d look idle", len(report.Projects), report.TotalHourlyCost, report.TotalMonthlyCost, idle)
+
+	if format == "csv" {
+		data, err := costReportCSV(report)
+		if err != nil {
+			return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Failed to render CSV: %v", err), Code: errorCodeInternal}), nil
+		}
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(data)), nil
+	}
+	return createJSONResponse(report), nil
+}
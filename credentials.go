@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// Request headers that carry per-session Taikun credentials on the network transports
+const (
+	headerAccessKey = "X-Taikun-Access-Key"
+	headerSecretKey = "X-Taikun-Secret-Key"
+	headerAuthMode  = "X-Taikun-Auth-Mode"
+)
+
+const credentialsContextKey contextKey = "taikun-credentials"
+
+// taikunCredentials identifies the Taikun user a request acts as
+type taikunCredentials struct {
+	AccessKey string
+	SecretKey string
+	AuthMode  string
+	Token     string
+}
+
+func (c taikunCredentials) isEmpty() bool {
+	return c.Token == "" && (c.AccessKey == "" || c.SecretKey == "")
+}
+
+// cacheKey hashes the full credential set so distinct identities never share a client
+func (c taikunCredentials) cacheKey() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.AccessKey, c.SecretKey, c.AuthMode, c.Token}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprint is a short, non-reversible identifier that is safe to log
+func (c taikunCredentials) fingerprint() string {
+	return c.cacheKey()[:12]
+}
+
+// credentialsFromHeaders reads access/secret key headers or an Authorization bearer token
+func credentialsFromHeaders(header http.Header) taikunCredentials {
+	creds := taikunCredentials{
+		AccessKey: strings.TrimSpace(header.Get(headerAccessKey)),
+		SecretKey: strings.TrimSpace(header.Get(headerSecretKey)),
+		AuthMode:  strings.TrimSpace(header.Get(headerAuthMode)),
+	}
+	if auth := strings.TrimSpace(header.Get("Authorization")); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		creds.Token = strings.TrimSpace(auth[7:])
+	}
+	if creds.AccessKey != "" && creds.AuthMode == "" {
+		creds.AuthMode = "token"
+	}
+	return creds
+}
+
+func withCredentials(ctx context.Context, creds taikunCredentials) context.Context {
+	if creds.isEmpty() {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialsContextKey, creds)
+}
+
+func credentialsFromContext(ctx context.Context) (taikunCredentials, bool) {
+	if ctx == nil {
+		return taikunCredentials{}, false
+	}
+	creds, ok := ctx.Value(credentialsContextKey).(taikunCredentials)
+	return creds, ok
+}
+
+type cachedClient struct {
+	client   *taikungoclient.Client
+	lastUsed time.Time
+}
+
+// sharedClient is the client built from the server's environment credentials. refresh-client
+// replaces it while other tool calls are reading it.
+type sharedClient struct {
+	mu       sync.RWMutex
+	client   *taikungoclient.Client
+	identity string
+}
+
+// get returns the client, nil when there is none, and the identity that names it in audit records
+func (s *sharedClient) get() (*taikungoclient.Client, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client, s.identity
+}
+
+func (s *sharedClient) set(client *taikungoclient.Client, identity string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = client
+	s.identity = identity
+}
+
+// clientCache keeps one authenticated Taikun client per credential set, evicting idle entries
+type clientCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	entries map[string]*cachedClient
+	now     func() time.Time
+}
+
+func newClientCache(ttl time.Duration, maxSize int) *clientCache {
+	return &clientCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[string]*cachedClient),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached client for the credentials, creating it on first use
+func (c *clientCache) get(ctx context.Context, creds taikunCredentials, apiHost string) *taikungoclient.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.evictExpired(now)
+
+	key := creds.cacheKey()
+	if entry, ok := c.entries[key]; ok {
+		entry.lastUsed = now
+		return entry.client
+	}
+
+	if c.maxSize > 0 && len(c.entries) >= c.maxSize {
+		c.evictOldest()
+	}
+
+	var client *taikungoclient.Client
+	if creds.Token != "" {
+		client = taikungoclient.NewClientFromToken(creds.Token, apiHost)
+	} else {
+		client = taikungoclient.NewClientFromCredentials("", "", creds.AccessKey, creds.SecretKey, creds.AuthMode, apiHost)
+	}
+	client = withRetries(client)
+	c.entries[key] = &cachedClient{client: client, lastUsed: now}
+	logger.InfoContext(ctx, "Created Cloudera Cloud Factory client for session credentials", "fingerprint", creds.fingerprint(), "cached", len(c.entries))
+	return client
+}
+
+// evict drops the client for the given credentials so the next call authenticates again
+func (c *clientCache) evict(creds taikunCredentials) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, creds.cacheKey())
+}
+
+func (c *clientCache) evictExpired(now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	for key, entry := range c.entries {
+		if now.Sub(entry.lastUsed) > c.ttl {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *clientCache) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.lastUsed.Before(oldest) {
+			oldestKey = key
+			oldest = entry.lastUsed
+		}
+	}
+	if oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// clientForContext resolves the Taikun client a tool call should use: the caller's own
+// credentials when the transport supplied them, otherwise the server's default client
+func clientForContext(ctx context.Context) (*taikungoclient.Client, error) {
+	if creds, ok := credentialsFromContext(ctx); ok {
+		return sessionClients.get(ctx, creds, taikunAPIHost()), nil
+	}
+	if requireClientCredentials {
+		return nil, fmt.Errorf("this server requires per-session credentials: send %s and %s headers or an Authorization bearer token", headerAccessKey, headerSecretKey)
+	}
+	client, _ := defaultClient.get()
+	if client == nil {
+		return nil, fmt.Errorf("no Cloudera Cloud Factory credentials configured: set server environment credentials or send %s and %s headers", headerAccessKey, headerSecretKey)
+	}
+	return client, nil
+}
+
+// withTaikunClient adapts a tool handler to receive the Taikun client for the calling session
+func withTaikunClient[T any](handler func(context.Context, *taikungoclient.Client, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		client, err := clientForContext(ctx)
+		if err != nil {
+			logger.WarnContext(ctx, "No Cloudera Cloud Factory client for tool call", "error", err)
+			return createJSONResponse(ErrorResponse{Error: err.Error(), Code: errorCodeUnauthorized}), nil
+		}
+		return handler(ctx, client, args)
+	}
+}
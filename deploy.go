@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -29,13 +30,26 @@ func getProjectServerAddLock(projectId int32) *sync.Mutex {
 	return lock
 }
 
-func bindFlavorsToProject(client *taikungoclient.Client, args BindFlavorsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func bindFlavorsToProject(ctx context.Context, client *taikungoclient.Client, args BindFlavorsArgs) (*mcp_golang.ToolResponse, error) {
 	command := taikuncore.NewBindFlavorToProjectCommand()
 	command.SetProjectId(args.ProjectId)
 	command.SetFlavors(args.Flavors)
 
+	if isDryRun(args.DryRun) {
+		if len(args.Flavors) == 0 {
+			return createJSONResponse(ErrorResponse{Error: "At least one flavor is required", Code: errorCodeValidation}), nil
+		}
+		project, errorResp := resolveProject(ctx, client, args.ProjectId)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would bind %d flavor(s) to project '%s'", len(args.Flavors), project.GetName()),
+			[]string{fmt.Sprintf("Project %d (%s) exists", args.ProjectId, project.GetName())},
+			dryRunPost("/api/v1/flavors/bind", command),
+		), nil
+	}
+
 	request := client.Client.FlavorsAPI.FlavorsBindToProject(ctx).
 		BindFlavorToProjectCommand(*command)
 
@@ -53,13 +67,16 @@ func bindFlavorsToProject(client *taikungoclient.Client, args BindFlavorsArgs) (
 	}), nil
 }
 
-func addServerToProject(client *taikungoclient.Client, args AddServerArgs) (*mcp_golang.ToolResponse, error) {
+func addServerToProject(ctx context.Context, client *taikungoclient.Client, args AddServerArgs) (*mcp_golang.ToolResponse, error) {
 	lock := getProjectServerAddLock(args.ProjectId)
 	lock.Lock()
 	defer lock.Unlock()
 
-	ctx := context.Background()
+	return addServersLocked(ctx, client, args)
+}
 
+// addServersLocked creates and verifies servers; the caller holds the project's server add lock
+func addServersLocked(ctx context.Context, client *taikungoclient.Client, args AddServerArgs) (*mcp_golang.ToolResponse, error) {
 	serverDto := taikuncore.NewServerForCreateDto()
 	serverDto.SetName(args.Name)
 
@@ -67,6 +84,7 @@ func addServerToProject(client *taikungoclient.Client, args AddServerArgs) (*mcp
 	if err != nil {
 		return createJSONResponse(ErrorResponse{
 			Error: fmt.Sprintf("Invalid role: %v", err),
+			Code:  errorCodeValidation,
 		}), nil
 	}
 	serverDto.SetRole(*role)
@@ -84,6 +102,37 @@ func addServerToProject(client *taikungoclient.Client, args AddServerArgs) (*mcp
 	}
 	serverDto.SetCount(count)
 
+	budgetCheck, errorResp := checkBudget(ctx, client, budgetRequest{
+		action:    fmt.Sprintf("add %d %s server(s) with flavor %s", count, args.Role, args.Flavor),
+		projectID: args.ProjectId,
+		servers:   map[string]int32{args.Flavor: count},
+	})
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectId)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		if errorResp := resolveBoundFlavor(ctx, client, args.ProjectId, args.Flavor); errorResp != nil {
+			return errorResp, nil
+		}
+		checks := []string{
+			fmt.Sprintf("Project %d (%s) exists", args.ProjectId, project.GetName()),
+			fmt.Sprintf("Flavor '%s' is bound to the project", args.Flavor),
+		}
+		if budgetCheck != "" {
+			checks = append(checks, budgetCheck)
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would add %d %s server(s) with flavor %s to project '%s'", count, args.Role, args.Flavor, project.GetName()),
+			checks,
+			dryRunPost("/api/v1/servers/create", serverDto),
+		), nil
+	}
+
 	request := client.Client.ServersAPI.ServersCreate(ctx).
 		ServerForCreateDto(*serverDto)
 
@@ -103,6 +152,10 @@ func addServerToProject(client *taikungoclient.Client, args AddServerArgs) (*mcp
 		Expected int32           `json:"expected"`
 		Found    int32           `json:"found"`
 		Servers  []ServerSummary `json:"servers,omitempty"`
+		// Error and Code make an unverified creation an error envelope, as for every other failure
+		Error     string `json:"error,omitempty"`
+		Code      string `json:"code,omitempty"`
+		Retryable bool   `json:"retryable,omitempty"`
 	}
 
 	verifyTimeout := args.VerifyTimeoutSeconds
@@ -119,6 +172,7 @@ func addServerToProject(client *taikungoclient.Client, args AddServerArgs) (*mcp
 		if listHTTPResponse == nil {
 			return createJSONResponse(ErrorResponse{
 				Error: "Failed to verify server creation: no response received",
+				Code:  errorCodeUpstream5xx,
 			}), nil
 		}
 		if listHTTPResponse.StatusCode < 200 || listHTTPResponse.StatusCode >= 300 {
@@ -167,26 +221,54 @@ func addServerToProject(client *taikungoclient.Client, args AddServerArgs) (*mcp
 		}
 
 		if time.Now().After(verifyDeadline) {
+			message := fmt.Sprintf("Server creation request accepted but not verified within timeout (expected %d)", count)
 			return createJSONResponse(AddServerResponse{
-				Message:  fmt.Sprintf("Server creation request accepted but not verified within timeout (expected %d)", count),
-				Success:  false,
-				Verified: false,
-				Expected: count,
-				Found:    int32(len(matched)),
-				Servers:  matched,
+				Message:   message,
+				Success:   false,
+				Verified:  false,
+				Expected:  count,
+				Found:     int32(len(matched)),
+				Servers:   matched,
+				Error:     message,
+				Code:      errorCodeTimeout,
+				Retryable: true,
 			}), nil
 		}
 
-		time.Sleep(5 * time.Second)
+		if err := sleepContext(ctx, 5*time.Second); err != nil {
+			message := fmt.Sprintf("Server creation request accepted but verification stopped: %v", err)
+			stopped := errorResponseFromError(err, message)
+			return createJSONResponse(AddServerResponse{
+				Message:   message,
+				Success:   false,
+				Verified:  false,
+				Expected:  count,
+				Found:     int32(len(matched)),
+				Servers:   matched,
+				Error:     message,
+				Code:      stopped.Code,
+				Retryable: stopped.Retryable,
+			}), nil
+		}
 	}
 }
 
-func commitProject(client *taikungoclient.Client, args CommitProjectArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func commitProject(ctx context.Context, client *taikungoclient.Client, args CommitProjectArgs) (*mcp_golang.ToolResponse, error) {
 	command := taikuncore.NewProjectDeploymentCommitCommand()
 	command.SetProjectId(args.ProjectId)
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectId)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would commit the deployment of project '%s'", project.GetName()),
+			[]string{fmt.Sprintf("Project %d (%s) exists", args.ProjectId, project.GetName())},
+			dryRunPost("/api/v1/project-deployment/commit", command),
+		), nil
+	}
+
 	request := client.Client.ProjectDeploymentAPI.ProjectDeploymentCommit(ctx).
 		ProjectDeploymentCommitCommand(*command)
 
@@ -199,46 +281,22 @@ func commitProject(client *taikungoclient.Client, args CommitProjectArgs) (*mcp_
 		return errorResp, nil
 	}
 
+	if args.WaitForReady {
+		timeout := args.Timeout
+		if timeout <= 0 {
+			timeout = 1800 // Deployments take up to 30 minutes
+		}
+		logger.InfoContext(ctx, "Project deployment committed, waiting for it to finish", "projectId", args.ProjectId)
+		reportProgress(ctx, 0, 0, fmt.Sprintf("Project %d: deployment committed", args.ProjectId))
+		return waitForProject(ctx, client, WaitForProjectArgs{ProjectId: args.ProjectId, Timeout: timeout})
+	}
+
 	return createJSONResponse(map[string]string{
 		"message": fmt.Sprintf("Successfully committed project %d deployment. Note: Deploying a full Kubernetes cluster typically takes 10 to 30 minutes.", args.ProjectId),
 	}), nil
 }
 
-func getProjectDetails(client *taikungoclient.Client, args GetProjectDetailsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
-	// Using ProjectsList because it contains status and health info
-	request := client.Client.ProjectsAPI.ProjectsList(ctx).
-		Id(args.ProjectId)
-
-	result, httpResponse, err := request.Execute()
-	if err != nil {
-		return createError(httpResponse, err), nil
-	}
-
-	if errorResp := checkResponse(httpResponse, "get project details"); errorResp != nil {
-		return errorResp, nil
-	}
-
-	if len(result.Data) == 0 {
-		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Project with ID %d not found", args.ProjectId))), nil
-	}
-
-	project := result.Data[0]
-	response := ProjectStatusResponse{
-		ID:        project.GetId(),
-		Name:      project.GetName(),
-		Status:    string(project.GetStatus()),
-		Health:    string(project.GetHealth()),
-		CloudType: string(project.GetCloudType()),
-	}
-
-	return createJSONResponse(response), nil
-}
-
-func listFlavors(client *taikungoclient.Client, args ListFlavorsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listFlavors(ctx context.Context, client *taikungoclient.Client, args ListFlavorsArgs) (*mcp_golang.ToolResponse, error) {
 	request := client.Client.CloudCredentialAPI.CloudcredentialsAllFlavors(ctx, args.CloudCredentialId)
 	if args.Limit > 0 {
 		request = request.Limit(args.Limit)
@@ -279,9 +337,7 @@ func listFlavors(client *taikungoclient.Client, args ListFlavorsArgs) (*mcp_gola
 	return createJSONResponse(response), nil
 }
 
-func listServers(client *taikungoclient.Client, args ListServersArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listServers(ctx context.Context, client *taikungoclient.Client, args ListServersArgs) (*mcp_golang.ToolResponse, error) {
 	request := client.Client.ServersAPI.ServersDetails(ctx, args.ProjectId)
 
 	result, httpResponse, err := request.Execute()
@@ -316,15 +372,57 @@ func listServers(client *taikungoclient.Client, args ListServersArgs) (*mcp_gola
 	return createJSONResponse(response), nil
 }
 
-func deleteServersFromProject(client *taikungoclient.Client, args DeleteServersArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func deleteServersFromProject(ctx context.Context, client *taikungoclient.Client, args DeleteServersArgs) (*mcp_golang.ToolResponse, error) {
 	command := taikuncore.NewProjectDeploymentDeleteServersCommand()
 	command.SetProjectId(args.ProjectId)
 	command.SetServerIds(args.ServerIds)
 	command.SetForceDeleteVClusters(args.ForceDeleteVClusters)
 	command.SetDeleteAutoscalingServers(args.DeleteAutoscalingServers)
 
+	if isDryRun(args.DryRun) {
+		servers, httpResponse, err := client.Client.ServersAPI.ServersDetails(ctx, args.ProjectId).Execute()
+		if err != nil {
+			return createError(httpResponse, err), nil
+		}
+		if errorResp := checkResponse(httpResponse, "list servers"); errorResp != nil {
+			return errorResp, nil
+		}
+		known := map[int32]string{}
+		if servers != nil {
+			for _, server := range servers.Data {
+				known[server.GetId()] = server.GetName()
+			}
+		}
+		checks := make([]string, 0, len(args.ServerIds))
+		for _, id := range args.ServerIds {
+			name, ok := known[id]
+			if !ok {
+				return createJSONResponse(ErrorResponse{
+					Error: fmt.Sprintf("Server %d does not belong to project %d", id, args.ProjectId),
+					Code:  errorCodeValidation,
+				}), nil
+			}
+			checks = append(checks, fmt.Sprintf("Server %d (%s) belongs to project %d", id, name, args.ProjectId))
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would delete %d server(s) from project %d", len(args.ServerIds), args.ProjectId),
+			checks,
+			dryRunPost("/api/v1/project-deployment/delete", command),
+		), nil
+	}
+
+	serverIDs := make([]string, 0, len(args.ServerIds))
+	for _, id := range args.ServerIds {
+		serverIDs = append(serverIDs, fmt.Sprint(id))
+	}
+	sort.Strings(serverIDs)
+	action := fmt.Sprintf("delete-servers-from-project:%d:%s:%t:%t", args.ProjectId, strings.Join(serverIDs, ","), args.ForceDeleteVClusters, args.DeleteAutoscalingServers)
+	if confirmation := confirmDestructive(ctx, action, args.ConfirmationToken, "delete-servers-from-project", func() (*DestructionSummary, *mcp_golang.ToolResponse) {
+		return summarizeServerDeletion(ctx, client, args)
+	}); confirmation != nil {
+		return confirmation, nil
+	}
+
 	request := client.Client.ProjectDeploymentAPI.ProjectDeploymentDelete(ctx).
 		ProjectDeploymentDeleteServersCommand(*command)
 
@@ -343,3 +441,40 @@ func deleteServersFromProject(client *taikungoclient.Client, args DeleteServersA
 		"success":   true,
 	}), nil
 }
+
+// summarizeServerDeletion lists the servers a delete-servers-from-project call would remove
+func summarizeServerDeletion(ctx context.Context, client *taikungoclient.Client, args DeleteServersArgs) (*DestructionSummary, *mcp_golang.ToolResponse) {
+	summary, errorResp := summarizeProject(ctx, client, args.ProjectId)
+	if errorResp != nil {
+		return nil, errorResp
+	}
+
+	servers, httpResponse, err := client.Client.ServersAPI.ServersDetails(ctx, args.ProjectId).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list servers"); errorResp != nil {
+		return nil, errorResp
+	}
+
+	known := map[int32]string{}
+	if servers != nil {
+		for _, server := range servers.Data {
+			known[server.GetId()] = describeServer(server)
+		}
+	}
+	for _, id := range args.ServerIds {
+		description, ok := known[id]
+		if !ok {
+			return nil, createJSONResponse(ErrorResponse{
+				Error: fmt.Sprintf("Server %d does not belong to project %d", id, args.ProjectId),
+				Code:  errorCodeValidation,
+			})
+		}
+		summary.Servers = append(summary.Servers, description)
+	}
+
+	summary.Removes = fmt.Sprintf("%d of %d server(s) in project '%s'", len(args.ServerIds), summary.ServerCount, summary.ProjectName)
+	summary.ServerCount = int32(len(args.ServerIds))
+	return summary, nil
+}
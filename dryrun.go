@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// globalDryRun turns every mutating tool call into a dry run, regardless of its dryRun argument
+var globalDryRun bool
+
+// DryRunRequest is a Taikun API call that a mutating tool would send
+type DryRunRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// DryRunResponse is returned instead of executing a mutating tool
+type DryRunResponse struct {
+	DryRun   bool            `json:"dryRun"`
+	Message  string          `json:"message"`
+	Checks   []string        `json:"checks,omitempty"`
+	Requests []DryRunRequest `json:"requests"`
+}
+
+func isDryRun(requested bool) bool {
+	return requested || globalDryRun
+}
+
+func createDryRunResponse(message string, checks []string, requests ...DryRunRequest) *mcp_golang.ToolResponse {
+	return createJSONResponse(DryRunResponse{
+		DryRun:   true,
+		Message:  "Dry run: " + message + ". No changes were made.",
+		Checks:   checks,
+		Requests: requests,
+	})
+}
+
+// resolveProject looks up a project by ID, returning an error response when it does not exist
+func resolveProject(ctx context.Context, client *taikungoclient.Client, projectID int32) (*taikuncore.ProjectListDetailDto, *mcp_golang.ToolResponse) {
+	result, httpResponse, err := client.Client.ProjectsAPI.ProjectsList(ctx).Id(projectID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "get project"); errorResp != nil {
+		return nil, errorResp
+	}
+	if result == nil || len(result.Data) == 0 {
+		return nil, createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Project with ID %d not found", projectID),
+			Code:  errorCodeNotFound,
+		})
+	}
+	return &result.Data[0], nil
+}
+
+// resolveCatalog looks up a catalog by ID, returning an error response when it does not exist
+func resolveCatalog(ctx context.Context, client *taikungoclient.Client, catalogID int32) (*taikuncore.CatalogListDto, *mcp_golang.ToolResponse) {
+	result, httpResponse, err := client.Client.CatalogAPI.CatalogList(ctx).Id(catalogID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "get catalog"); errorResp != nil {
+		return nil, errorResp
+	}
+	if result == nil || len(result.Data) == 0 {
+		return nil, createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Catalog with ID %d not found", catalogID),
+			Code:  errorCodeNotFound,
+		})
+	}
+	return &result.Data[0], nil
+}
+
+// resolveCatalogApp checks that a catalog application exists
+func resolveCatalogApp(ctx context.Context, client *taikungoclient.Client, catalogAppID int32) (*taikuncore.CatalogAppDetailsDto, *mcp_golang.ToolResponse) {
+	details, httpResponse, err := client.Client.CatalogAppAPI.CatalogAppDetails(ctx, catalogAppID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "get catalog app"); errorResp != nil {
+		return nil, errorResp
+	}
+	if details == nil {
+		return nil, createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Catalog app with ID %d not found", catalogAppID),
+			Code:  errorCodeNotFound,
+		})
+	}
+	return details, nil
+}
+
+// resolveProjectApp checks that an installed application exists
+func resolveProjectApp(ctx context.Context, client *taikungoclient.Client, projectAppID int32) (*taikuncore.ProjectAppDetailsDto, *mcp_golang.ToolResponse) {
+	details, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappDetails(ctx, projectAppID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "get application details"); errorResp != nil {
+		return nil, errorResp
+	}
+	if details == nil {
+		return nil, createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Application with ID %d not found", projectAppID),
+			Code:  errorCodeNotFound,
+		})
+	}
+	return details, nil
+}
+
+// resolveCloudCredential checks that a cloud credential is visible to the caller
+func resolveCloudCredential(ctx context.Context, client *taikungoclient.Client, cloudCredentialID int32) (*taikuncore.CloudCredentialsForOrganizationEntity, *mcp_golang.ToolResponse) {
+	// The client refuses to send the request without isAdmin
+	result, httpResponse, err := client.Client.CloudCredentialAPI.CloudcredentialsOrgList(ctx).IsAdmin(false).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list cloud credentials"); errorResp != nil {
+		return nil, errorResp
+	}
+	for i := range result {
+		if result[i].GetId() == cloudCredentialID {
+			return &result[i], nil
+		}
+	}
+	return nil, createJSONResponse(ErrorResponse{
+		Error: fmt.Sprintf("Cloud credential with ID %d not found", cloudCredentialID),
+		Code:  errorCodeNotFound,
+	})
+}
+
+// resolveBoundFlavor checks that a flavor is bound to the project before servers use it
+func resolveBoundFlavor(ctx context.Context, client *taikungoclient.Client, projectID int32, flavor string) *mcp_golang.ToolResponse {
+	result, httpResponse, err := client.Client.FlavorsAPI.FlavorsSelectedFlavorsForProject(ctx).
+		ProjectId(projectID).
+		FlavorName(flavor).
+		Execute()
+	if err != nil {
+		return createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list project flavors"); errorResp != nil {
+		return errorResp
+	}
+	if result != nil {
+		for _, bound := range result.Data {
+			if bound.GetName() == flavor {
+				return nil
+			}
+		}
+	}
+	return createJSONResponse(ErrorResponse{
+		Error:   fmt.Sprintf("Flavor '%s' is not bound to project %d", flavor, projectID),
+		Code:    errorCodeValidation,
+		Details: "Bind it first with bind-flavors-to-project",
+	})
+}
+
+func dryRunPost(path string, body interface{}) DryRunRequest {
+	return DryRunRequest{Method: http.MethodPost, Path: path, Body: body}
+}
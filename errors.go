@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// Error codes of the error envelope. They are stable so agents can branch on them instead of
+// on messages.
+const (
+	errorCodeNotFound       = "NOT_FOUND"
+	errorCodeUnauthorized   = "UNAUTHORIZED"
+	errorCodeValidation     = "VALIDATION"
+	errorCodeConflict       = "CONFLICT"
+	errorCodeRateLimited    = "RATE_LIMITED"
+	errorCodeUpstream5xx    = "UPSTREAM_5XX"
+	errorCodeTimeout        = "TIMEOUT"
+	errorCodeResourceFailed = "RESOURCE_FAILED"
+	errorCodeCancelled      = "CANCELLED"
+	errorCodeBudgetExceeded = "BUDGET_EXCEEDED"
+	errorCodeInternal       = "INTERNAL"
+)
+
+// errorCodeForStatus maps a Taikun API status code to an error code
+func errorCodeForStatus(status int) string {
+	switch {
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return errorCodeNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return errorCodeUnauthorized
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed || status == http.StatusLocked:
+		return errorCodeConflict
+	case status == http.StatusTooManyRequests:
+		return errorCodeRateLimited
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return errorCodeTimeout
+	case status >= 500:
+		return errorCodeUpstream5xx
+	case status >= 400:
+		return errorCodeValidation
+	}
+	return errorCodeInternal
+}
+
+// isRetryableErrorCode reports whether the same call may succeed when repeated later
+func isRetryableErrorCode(code string) bool {
+	switch code {
+	case errorCodeRateLimited, errorCodeUpstream5xx, errorCodeTimeout:
+		return true
+	}
+	return false
+}
+
+// codedError is returned by helpers that already know how their failure is classified
+type codedError struct {
+	code       string
+	httpStatus int
+	upstream   json.RawMessage
+	message    string
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func newCodedError(code, format string, args ...interface{}) error {
+	return &codedError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// upstreamError classifies a failed Taikun API call for helpers that return plain errors
+func upstreamError(response *http.Response, err error) error {
+	errorResp := apiErrorResponse(response, err)
+	return &codedError{
+		code:       errorResp.Code,
+		httpStatus: errorResp.HTTPStatus,
+		upstream:   errorResp.UpstreamBody,
+		message:    errorResp.Error,
+	}
+}
+
+// apiErrorResponse builds the error envelope for a failed Taikun API call, keeping the
+// body Taikun answered with
+func apiErrorResponse(response *http.Response, err error) ErrorResponse {
+	// Use taikungoclient's CreateError for detailed error messages
+	taikunErr := taikungoclient.CreateError(response, err)
+
+	errorResp := ErrorResponse{Error: "Unknown error occurred"}
+	if taikunErr != nil {
+		errorResp.Error = taikunErr.Error()
+	}
+
+	var apiErr *taikuncore.GenericOpenAPIError
+	if errors.As(err, &apiErr) && len(apiErr.Body()) > 0 {
+		errorResp.UpstreamBody = upstreamBody(apiErr.Body())
+	}
+
+	switch {
+	case response != nil:
+		errorResp.HTTPStatus = response.StatusCode
+		errorResp.Code = errorCodeForStatus(response.StatusCode)
+	case isTimeout(err):
+		errorResp.Code = errorCodeTimeout
+	case errors.Is(err, context.Canceled):
+		errorResp.Code = errorCodeCancelled
+	default:
+		// The request never got an answer, so treat Taikun as unavailable
+		errorResp.Code = errorCodeUpstream5xx
+	}
+	errorResp.Retryable = isRetryableErrorCode(errorResp.Code)
+	return errorResp
+}
+
+// failureFromEnvelope decodes the error envelope of a tool response; text that is not an
+// envelope becomes an internal error
+func failureFromEnvelope(envelope string) ErrorResponse {
+	var failure ErrorResponse
+	if err := json.Unmarshal([]byte(envelope), &failure); err != nil {
+		return ErrorResponse{Error: envelope, Code: errorCodeInternal}
+	}
+	return failure
+}
+
+// errorResponseFromError builds the error envelope for an error returned by a helper
+func errorResponseFromError(err error, message string) ErrorResponse {
+	errorResp := ErrorResponse{Error: message, Code: errorCodeInternal}
+	var coded *codedError
+	switch {
+	case errors.As(err, &coded):
+		errorResp.Code = coded.code
+		errorResp.HTTPStatus = coded.httpStatus
+		errorResp.UpstreamBody = coded.upstream
+	case isTimeout(err):
+		errorResp.Code = errorCodeTimeout
+	case errors.Is(err, context.Canceled):
+		errorResp.Code = errorCodeCancelled
+	}
+	errorResp.Retryable = isRetryableErrorCode(errorResp.Code)
+	return errorResp
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+func upstreamBody(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// toolError hands an error envelope back to the MCP server so the result is flagged with isError
+type toolError struct {
+	envelope string
+}
+
+func (e *toolError) Error() string {
+	return e.envelope
+}
+
+// withErrorFlag sets the MCP isError flag on every response that carries an error envelope
+func withErrorFlag[T any](handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		response, err := handler(ctx, args)
+		if err != nil {
+			return response, err
+		}
+		if envelope, ok := errorEnvelope(response); ok {
+			return nil, &toolError{envelope: envelope}
+		}
+		return response, nil
+	}
+}
+
+// errorEnvelope returns the text of a response when it is an ErrorResponse
+func errorEnvelope(response *mcp_golang.ToolResponse) (string, bool) {
+	if response == nil || len(response.Content) == 0 || response.Content[0].TextContent == nil {
+		return "", false
+	}
+	text := response.Content[0].TextContent.Text
+	var fields struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		return "", false
+	}
+	return text, fields.Error != "" && fields.Code != ""
+}
+
+// handlerErrorPrefix is what mcp-golang puts in front of the text of an error returned by a tool
+const handlerErrorPrefix = "handler returned an error: "
+
+// errorResultTransport strips the mcp-golang prefix from tool error results, so their content
+// stays a parseable error envelope on every transport
+type errorResultTransport struct {
+	transport.Transport
+}
+
+func (t errorResultTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	if message != nil && message.Type == transport.BaseMessageTypeJSONRPCResponseType && message.JsonRpcResponse != nil {
+		message.JsonRpcResponse.Result = stripHandlerErrorPrefix(message.JsonRpcResponse.Result)
+	}
+	return t.Transport.Send(ctx, message)
+}
+
+func stripHandlerErrorPrefix(result json.RawMessage) json.RawMessage {
+	if !bytes.Contains(result, []byte(handlerErrorPrefix)) {
+		return result
+	}
+	var toolResult struct {
+		Content []map[string]interface{} `json:"content"`
+		IsError bool                     `json:"isError"`
+	}
+	if err := json.Unmarshal(result, &toolResult); err != nil || !toolResult.IsError {
+		return result
+	}
+	for _, content := range toolResult.Content {
+		text, _ := content["text"].(string)
+		if envelope, ok := strings.CutPrefix(text, handlerErrorPrefix); ok && json.Valid([]byte(envelope)) {
+			content["text"] = envelope
+		}
+	}
+	stripped, err := json.Marshal(toolResult)
+	if err != nil {
+		return result
+	}
+	return stripped
+}
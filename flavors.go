@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	taikungoclient "github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	flavorListPageSize = 100
+
+	// The kubelet, the container runtime and the system pods keep this much of every node
+	nodeReservedCPU      = 0.5
+	nodeReservedMemoryGB = 1.0
+
+	// maxPodsPerNode is the default pod limit of the kubelet
+	maxPodsPerNode = 110
+)
+
+// flavorMinimum is the smallest flavor a server role works with
+type flavorMinimum struct {
+	cpu      float64
+	memoryGB float64
+}
+
+// roleMinimums holds the sizing Cloudera Cloud Factory needs per server role
+var roleMinimums = map[string]flavorMinimum{
+	"Bastion":    {cpu: 2, memoryGB: 2},
+	"Kubemaster": {cpu: 4, memoryGB: 4},
+	"Kubeworker": {cpu: 4, memoryGB: 4},
+}
+
+type RecommendFlavorsArgs struct {
+	CloudCredentialID int32  `json:"cloudCredentialId" jsonschema:"required,description=ID of the cloud credential whose flavors are ranked"`
+	Role              string `json:"role,omitempty" jsonschema:"description=Server role: Bastion or Kubemaster or Kubeworker (default: Kubeworker)"`
+	Pods              int32  `json:"pods,omitempty" jsonschema:"description=Number of pods the workers will run"`
+	CPURequest        string `json:"cpuRequest,omitempty" jsonschema:"description=CPU request of each pod such as 500m or 2"`
+	MemoryRequest     string `json:"memoryRequest,omitempty" jsonschema:"description=Memory request of each pod such as 512Mi or 2Gi"`
+	Count             int32  `json:"count,omitempty" jsonschema:"description=Number of workers the pods are spread over (default: 1)"`
+	ProjectID         int32  `json:"projectId,omitempty" jsonschema:"description=Project whose bound flavors supply the prices the cloud publishes"`
+	Limit             int32  `json:"limit,omitempty" jsonschema:"description=Maximum number of flavors to return (default: 5)"`
+}
+
+type FlavorRequirement struct {
+	CPU      float64 `json:"cpu"`
+	MemoryGB float64 `json:"memoryGB"`
+	Basis    string  `json:"basis"`
+}
+
+type FlavorRecommendation struct {
+	Rank        int      `json:"rank"`
+	Name        string   `json:"name"`
+	CPU         int32    `json:"cpu"`
+	MemoryGB    float64  `json:"memoryGB"`
+	HourlyCost  *float64 `json:"hourlyCost,omitempty"`
+	PriceSource string   `json:"priceSource,omitempty"`
+	Reason      string   `json:"reason"`
+}
+
+type FlavorRecommendationResponse struct {
+	CloudCredentialID int32                  `json:"cloudCredentialId"`
+	Role              string                 `json:"role"`
+	Required          FlavorRequirement      `json:"required"`
+	Recommendations   []FlavorRecommendation `json:"recommendations"`
+	Considered        int                    `json:"considered"`
+	Excluded          int                    `json:"excluded"`
+	Warnings          []string               `json:"warnings,omitempty"`
+	Message           string                 `json:"message"`
+}
+
+// flavorRequirement works out the vCPUs and memory one server of the role needs. Workload hints
+// only size workers; masters and bastions do not run pods.
+func flavorRequirement(args RecommendFlavorsArgs, role string) (FlavorRequirement, []string, error) {
+	minimum := roleMinimums[role]
+	requirement := FlavorRequirement{
+		CPU:      minimum.cpu,
+		MemoryGB: minimum.memoryGB,
+		Basis:    fmt.Sprintf("the %s minimum of %g vCPU and %g GB", role, minimum.cpu, minimum.memoryGB),
+	}
+
+	hinted := args.Pods > 0 || args.CPURequest != "" || args.MemoryRequest != ""
+	if !hinted {
+		return requirement, nil, nil
+	}
+	if role != "Kubeworker" {
+		return requirement, []string{fmt.Sprintf("Workload hints only size Kubeworker servers; %s servers are sized by their minimum", role)}, nil
+	}
+	if args.Pods < 0 || args.Count < 0 {
+		return requirement, nil, fmt.Errorf("pods and count must not be negative")
+	}
+
+	var cpuPerPod, memoryPerPodGB float64
+	if args.CPURequest != "" {
+		quantity, err := resource.ParseQuantity(args.CPURequest)
+		if err != nil {
+			return requirement, nil, fmt.Errorf("invalid cpuRequest %q: %v", args.CPURequest, err)
+		}
+		cpuPerPod = quantity.AsApproximateFloat64()
+	}
+	if args.MemoryRequest != "" {
+		quantity, err := resource.ParseQuantity(args.MemoryRequest)
+		if err != nil {
+			return requirement, nil, fmt.Errorf("invalid memoryRequest %q: %v", args.MemoryRequest, err)
+		}
+		memoryPerPodGB = quantity.AsApproximateFloat64() / (1024 * 1024 * 1024)
+	}
+
+	pods := max(args.Pods, 1)
+	nodes := max(args.Count, 1)
+	podsPerNode := int32(math.Ceil(float64(pods) / float64(nodes)))
+	cpu := float64(podsPerNode)*cpuPerPod + nodeReservedCPU
+	memory := float64(podsPerNode)*memoryPerPodGB + nodeReservedMemoryGB
+
+	var warnings []string
+	if podsPerNode > maxPodsPerNode {
+		warnings = append(warnings, fmt.Sprintf("%d pods per worker exceeds the kubelet default of %d; use more workers", podsPerNode, maxPodsPerNode))
+	}
+	if cpu > requirement.CPU || memory > requirement.MemoryGB {
+		requirement.CPU = max(requirement.CPU, math.Round(cpu*100)/100)
+		requirement.MemoryGB = max(requirement.MemoryGB, math.Round(memory*100)/100)
+		requirement.Basis = fmt.Sprintf("%d pod(s) per worker plus %g vCPU and %g GB reserved for the node, at least the %s minimum",
+			podsPerNode, nodeReservedCPU, nodeReservedMemoryGB, role)
+	}
+	return requirement, warnings, nil
+}
+
+// flavorCandidate is a flavor that meets the requirement, with its price when one is known
+type flavorCandidate struct {
+	flavor   taikuncore.FlavorsListDto
+	price    flavorPrice
+	priced   bool
+	headroom float64
+}
+
+// rankFlavors drops the flavors below the requirement and orders the rest. Priced flavors come
+// first, cheapest first; flavors of equal or unknown price are ordered by how closely they fit.
+func rankFlavors(flavors []taikuncore.FlavorsListDto, requirement FlavorRequirement, priceOf func(taikuncore.FlavorsListDto) (flavorPrice, bool)) ([]flavorCandidate, int) {
+	var candidates []flavorCandidate
+	excluded := 0
+	for _, flavor := range flavors {
+		cpu, memory := float64(flavor.GetCpu()), flavorMemoryGB(flavor)
+		if cpu < requirement.CPU || memory < requirement.MemoryGB {
+			excluded++
+			continue
+		}
+		price, priced := priceOf(flavor)
+		// The larger of the two ratios tells how much of the flavor would sit unused
+		headroom := max(cpu/requirement.CPU, memory/requirement.MemoryGB) - 1
+		candidates = append(candidates, flavorCandidate{flavor: flavor, price: price, priced: priced, headroom: headroom})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.priced != b.priced {
+			return a.priced
+		}
+		if a.priced && a.price.price != b.price.price {
+			return a.price.price < b.price.price
+		}
+		if a.headroom != b.headroom {
+			return a.headroom < b.headroom
+		}
+		return a.flavor.GetName() < b.flavor.GetName()
+	})
+	return candidates, excluded
+}
+
+// explainFlavor says why a flavor was ranked where it is
+func explainFlavor(candidate flavorCandidate, requirement FlavorRequirement, rank int) string {
+	cpu, memory := float64(candidate.flavor.GetCpu()), flavorMemoryGB(candidate.flavor)
+	fit := fmt.Sprintf("%g vCPU and %g GB cover the %g vCPU and %g GB needed with %.0f%% CPU and %.0f%% memory to spare",
+		cpu, memory, requirement.CPU, requirement.MemoryGB, (cpu/requirement.CPU-1)*100, (memory/requirement.MemoryGB-1)*100)
+	switch {
+	case candidate.priced && rank == 1:
+		return fmt.Sprintf("Cheapest flavor that fits at %.4f/h (%s); %s", candidate.price.price, candidate.price.source, fit)
+	case candidate.priced:
+		return fmt.Sprintf("Costs %.4f/h (%s); %s", candidate.price.price, candidate.price.source, fit)
+	case rank == 1:
+		return "Closest fit with no known price; " + fit
+	default:
+		return "No known price; " + fit
+	}
+}
+
+// listAllFlavors reads every flavor of a cloud credential page by page
+func listAllFlavors(ctx context.Context, client *taikungoclient.Client, cloudCredentialID int32) ([]taikuncore.FlavorsListDto, *mcp_golang.ToolResponse) {
+	var flavors []taikuncore.FlavorsListDto
+	for offset := int32(0); ; offset += flavorListPageSize {
+		page, httpResponse, err := client.Client.CloudCredentialAPI.CloudcredentialsAllFlavors(ctx, cloudCredentialID).
+			Limit(flavorListPageSize).
+			Offset(offset).
+			Execute()
+		if err != nil {
+			return nil, createError(httpResponse, err)
+		}
+		if errorResp := checkResponse(httpResponse, "list flavors"); errorResp != nil {
+			return nil, errorResp
+		}
+		if page == nil {
+			return flavors, nil
+		}
+		flavors = append(flavors, page.Data...)
+		if len(page.Data) < flavorListPageSize || int32(len(flavors)) >= page.GetTotalCount() {
+			return flavors, nil
+		}
+	}
+}
+
+func recommendFlavors(ctx context.Context, client *taikungoclient.Client, args RecommendFlavorsArgs) (*mcp_golang.ToolResponse, error) {
+	role := args.Role
+	if role == "" {
+		role = "Kubeworker"
+	}
+	if _, ok := roleMinimums[role]; !ok {
+		return createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Invalid role %q; use Bastion, Kubemaster or Kubeworker", args.Role),
+			Code:  errorCodeValidation,
+		}), nil
+	}
+	requirement, warnings, err := flavorRequirement(args, role)
+	if err != nil {
+		return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid workload hints: %v", err), Code: errorCodeValidation}), nil
+	}
+	limit := int(args.Limit)
+	if limit <= 0 {
+		limit = 5
+	}
+
+	flavors, errorResp := listAllFlavors(ctx, client, args.CloudCredentialID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	// Clouds only publish prices for the flavors bound to a project
+	published := map[string]float64{}
+	if args.ProjectID != 0 {
+		bound, httpResponse, err := client.Client.FlavorsAPI.FlavorsSelectedFlavorsForProject(ctx).ProjectId(args.ProjectID).Execute()
+		if err := sectionError(httpResponse, err); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Could not load the flavor prices of project %d: %v", args.ProjectID, err))
+		} else if bound != nil {
+			published = flavorPrices(bound.Data)
+		}
+	}
+	priceOf := func(flavor taikuncore.FlavorsListDto) (flavorPrice, bool) {
+		if budget != nil {
+			return budget.priceFlavor(flavor.GetName(), published, &flavor)
+		}
+		price, ok := published[flavor.GetName()]
+		return flavorPrice{price: price, source: "cloud price"}, ok
+	}
+
+	candidates, excluded := rankFlavors(flavors, requirement, priceOf)
+	response := FlavorRecommendationResponse{
+		CloudCredentialID: args.CloudCredentialID,
+		Role:              role,
+		Required:          requirement,
+		Recommendations:   []FlavorRecommendation{},
+		Considered:        len(flavors),
+		Excluded:          excluded,
+		Warnings:          warnings,
+	}
+	for i, candidate := range candidates[:min(len(candidates), limit)] {
+		recommendation := FlavorRecommendation{
+			Rank:     i + 1,
+			Name:     candidate.flavor.GetName(),
+			CPU:      candidate.flavor.GetCpu(),
+			MemoryGB: flavorMemoryGB(candidate.flavor),
+			Reason:   explainFlavor(candidate, requirement, i+1),
+		}
+		if candidate.priced {
+			price := candidate.price.price
+			recommendation.HourlyCost = &price
+			recommendation.PriceSource = candidate.price.source
+		}
+		response.Recommendations = append(response.Recommendations, recommendation)
+	}
+
+	if len(candidates) == 0 {
+		response.Message = fmt.Sprintf("None of the %d flavor(s) has the %g vCPU and %g GB a %s server needs", len(flavors), requirement.CPU, requirement.MemoryGB, role)
+	} else {
+		names := make([]string, 0, len(response.Recommendations))
+		for _, recommendation := range response.Recommendations {
+			names = append(names, recommendation.Name)
+		}
+		response.Message = fmt.Sprintf("%d of %d flavor(s) fit a %s server; %d below %g vCPU and %g GB were left out. Best: %s",
+			len(candidates), len(flavors), role, excluded, requirement.CPU, requirement.MemoryGB, strings.Join(names, ", "))
+	}
+	return createJSONResponse(response), nil
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// Job statuses
+const (
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+	// jobInterrupted marks jobs that were still running when the server stopped
+	jobInterrupted = "interrupted"
+)
+
+const (
+	// jobRetention is how long finished jobs stay available to job-status and job-list
+	jobRetention = 24 * time.Hour
+	// maxJobEvents caps the events kept per job; the oldest are dropped first
+	maxJobEvents = 100
+)
+
+// jobs runs the tool calls made with async set; main replaces it when jobs are persisted
+var jobs = newJobManager("")
+
+// JobEvent is an intermediate update of a running job, such as a status poll of its wait
+type JobEvent struct {
+	Time     time.Time `json:"time"`
+	Message  string    `json:"message"`
+	Progress float64   `json:"progress,omitempty"`
+	Total    float64   `json:"total,omitempty"`
+}
+
+// Job is the state of a tool call running in the background
+type Job struct {
+	ID         string                 `json:"id"`
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	Owner      string                 `json:"owner"`
+	Status     string                 `json:"status"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	UpdatedAt  time.Time              `json:"updatedAt"`
+	FinishedAt *time.Time             `json:"finishedAt,omitempty"`
+	Events     []JobEvent             `json:"events,omitempty"`
+	// Result is the response of the tool call, an error envelope when the job failed
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+type JobAcceptedResponse struct {
+	JobID   string `json:"jobId"`
+	Tool    string `json:"tool"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type JobListResponse struct {
+	Jobs    []Job  `json:"jobs"`
+	Total   int    `json:"total"`
+	Message string `json:"message"`
+}
+
+type JobStatusArgs struct {
+	JobID string `json:"jobId" jsonschema:"required,description=The job ID returned by a call made with async"`
+}
+
+type JobListArgs struct {
+	Status string `json:"status,omitempty" jsonschema:"description=Only list jobs with this status: running, succeeded, failed, cancelled or interrupted (optional)"`
+}
+
+type JobCancelArgs struct {
+	JobID string `json:"jobId" jsonschema:"required,description=The job ID to cancel"`
+}
+
+// asyncRequest is implemented by the arguments of tools that can run as a job
+type asyncRequest interface {
+	runAsync() bool
+}
+
+// withAsync runs a tool call as a background job when its arguments ask for it, answering
+// right away with the job ID
+func withAsync[T any](name string, handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		request, ok := any(args).(asyncRequest)
+		if !ok || !request.runAsync() {
+			return handler(ctx, args)
+		}
+
+		job := jobs.start(ctx, name, sanitizeArguments(args), func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+			return handler(ctx, args)
+		})
+		return createJSONResponse(JobAcceptedResponse{
+			JobID:   job.ID,
+			Tool:    name,
+			Status:  job.Status,
+			Message: fmt.Sprintf("Started %s as job %s. Poll job-status for its progress and result.", name, job.ID),
+		}), nil
+	}
+}
+
+// runningJob holds what is needed to stop a job that is still running
+type runningJob struct {
+	cancel          context.CancelFunc
+	cancelRequested bool
+}
+
+// jobManager keeps the jobs of all clients, optionally persisting them to a file so finished
+// results outlive a restart. The file is written when a job starts or finishes, not on every
+// progress event, and outside mu so the write never blocks other jobs.
+type jobManager struct {
+	mu      sync.Mutex
+	path    string
+	jobs    map[string]*Job
+	running map[string]*runningJob
+	// snapshots counts the snapshots taken under mu, so an older one never overwrites a newer
+	snapshots int64
+
+	writeMu sync.Mutex
+	written int64
+}
+
+func newJobManager(path string) *jobManager {
+	return &jobManager{
+		path:    path,
+		jobs:    make(map[string]*Job),
+		running: make(map[string]*runningJob),
+	}
+}
+
+// loadJobManager restores the jobs persisted in path. Jobs that were running when the server
+// stopped cannot be resumed and are marked as interrupted.
+func loadJobManager(path string) (*jobManager, error) {
+	m := newJobManager(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs file: %w", err)
+	}
+
+	var saved []*Job
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, fmt.Errorf("failed to parse jobs file %s: %w", path, err)
+		}
+	}
+	now := time.Now().UTC()
+	for _, job := range saved {
+		if job.Status == jobRunning {
+			job.Status = jobInterrupted
+			job.FinishedAt = &now
+			job.UpdatedAt = now
+			job.Result, _ = json.Marshal(ErrorResponse{
+				Error: fmt.Sprintf("The server stopped before job %s finished; run %s again", job.ID, job.Tool),
+				Code:  errorCodeInternal,
+			})
+		}
+		m.jobs[job.ID] = job
+	}
+
+	m.mu.Lock()
+	m.prune()
+	seq, snapshot := m.snapshot()
+	m.mu.Unlock()
+	m.write(seq, snapshot)
+	return m, nil
+}
+
+// start runs fn in the background. The job keeps the values of ctx, such as the caller's
+// credentials, but not its cancellation, so it survives the client disconnecting.
+func (m *jobManager) start(ctx context.Context, tool string, arguments map[string]interface{}, fn func(context.Context) (*mcp_golang.ToolResponse, error)) Job {
+	now := time.Now().UTC()
+	job := &Job{
+		ID:        "job-" + newSessionID(),
+		Tool:      tool,
+		Arguments: arguments,
+		Owner:     jobOwner(ctx),
+		Status:    jobRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	jobCtx = context.WithValue(jobCtx, progressContextKey, jobProgress{manager: m, id: job.ID})
+
+	m.mu.Lock()
+	m.prune()
+	m.jobs[job.ID] = job
+	m.running[job.ID] = &runningJob{cancel: cancel}
+	seq, saved := m.snapshot()
+	snapshot := *job
+	m.mu.Unlock()
+	m.write(seq, saved)
+
+	logger.InfoContext(ctx, "Job started", "jobId", job.ID, "tool", tool)
+	go func() {
+		defer cancel()
+		response, err := fn(jobCtx)
+		if err != nil {
+			response = createJSONResponse(errorResponseFromError(err, err.Error()))
+		}
+		m.finish(jobCtx, job.ID, response)
+	}()
+	return snapshot
+}
+
+func (m *jobManager) finish(ctx context.Context, id string, response *mcp_golang.ToolResponse) {
+	status := jobSucceeded
+	var result json.RawMessage
+	if envelope, ok := errorEnvelope(response); ok {
+		status = jobFailed
+		result = json.RawMessage(envelope)
+	} else if response != nil && len(response.Content) > 0 && response.Content[0].TextContent != nil {
+		result = upstreamBody([]byte(response.Content[0].TextContent.Text))
+	}
+
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	if running := m.running[id]; running != nil && running.cancelRequested && status == jobFailed {
+		status = jobCancelled
+	}
+	delete(m.running, id)
+
+	now := time.Now().UTC()
+	job.Status = status
+	job.Result = result
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+	tool, duration := job.Tool, now.Sub(job.CreatedAt)
+	seq, snapshot := m.snapshot()
+	m.mu.Unlock()
+
+	m.write(seq, snapshot)
+	logger.InfoContext(ctx, "Job finished", "jobId", id, "tool", tool, "status", status, "duration", duration)
+}
+
+func (m *jobManager) addEvent(id string, event JobEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok || job.Status != jobRunning {
+		return
+	}
+	job.Events = append(job.Events, event)
+	if len(job.Events) > maxJobEvents {
+		job.Events = job.Events[len(job.Events)-maxJobEvents:]
+	}
+	job.UpdatedAt = event.Time
+}
+
+// get returns a job of the given owner; jobs of other callers are reported as missing
+func (m *jobManager) get(owner, id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok || job.Owner != owner {
+		return Job{}, false
+	}
+	return copyJob(job), true
+}
+
+// list returns the jobs of the given owner, newest first
+func (m *jobManager) list(owner, status string) []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	var result []Job
+	for _, job := range m.jobs {
+		if job.Owner != owner || (status != "" && job.Status != status) {
+			continue
+		}
+		result = append(result, copyJob(job))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// cancel stops a running job of the given owner. The job reports cancelled once its call returns.
+func (m *jobManager) cancel(owner, id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok || job.Owner != owner {
+		return Job{}, newCodedError(errorCodeNotFound, "job %s not found", id)
+	}
+	running, ok := m.running[id]
+	if !ok {
+		return Job{}, newCodedError(errorCodeConflict, "job %s is not running (status: %s)", id, job.Status)
+	}
+	running.cancelRequested = true
+	running.cancel()
+	return copyJob(job), nil
+}
+
+// prune drops finished jobs past their retention. Must be called with m.mu held.
+func (m *jobManager) prune() {
+	cutoff := time.Now().Add(-jobRetention)
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// snapshot encodes every job for the jobs file, if one is configured. Must be called with m.mu held.
+func (m *jobManager) snapshot() (int64, []byte) {
+	if m.path == "" {
+		return 0, nil
+	}
+	saved := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		saved = append(saved, job)
+	}
+	sort.Slice(saved, func(i, j int) bool {
+		return saved[i].CreatedAt.Before(saved[j].CreatedAt)
+	})
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		logger.Error("Failed to encode jobs", "error", err)
+		return 0, nil
+	}
+	m.snapshots++
+	return m.snapshots, data
+}
+
+// write replaces the jobs file with a snapshot unless a newer one was already written. It must
+// be called without m.mu held.
+func (m *jobManager) write(seq int64, data []byte) {
+	if data == nil {
+		return
+	}
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	if seq <= m.written {
+		return
+	}
+	m.written = seq
+
+	// Write to a temporary file first so a crash never leaves a truncated jobs file behind
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		logger.Error("Failed to save jobs", "file", m.path, "error", err)
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		os.Remove(tmp.Name())
+		logger.Error("Failed to save jobs", "file", m.path, "error", err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		os.Remove(tmp.Name())
+		logger.Error("Failed to save jobs", "file", m.path, "error", err)
+	}
+}
+
+func copyJob(job *Job) Job {
+	snapshot := *job
+	snapshot.Events = append([]JobEvent(nil), job.Events...)
+	return snapshot
+}
+
+// jobOwner identifies the caller a job belongs to. It is derived from the credentials rather
+// than the session, so a client that reconnects still sees its jobs.
+func jobOwner(ctx context.Context) string {
+	caller := callerFromContext(ctx)
+	if caller.Credential != "" {
+		return caller.Credential
+	}
+	return caller.Identity
+}
+
+// jobProgress records the progress reports of a job as its events
+type jobProgress struct {
+	manager *jobManager
+	id      string
+}
+
+func (p jobProgress) report(ctx context.Context, progress, total float64, message string) {
+	p.manager.addEvent(p.id, JobEvent{Time: time.Now().UTC(), Message: message, Progress: progress, Total: total})
+}
+
+func jobStatus(ctx context.Context, args JobStatusArgs) (*mcp_golang.ToolResponse, error) {
+	job, ok := jobs.get(jobOwner(ctx), args.JobID)
+	if !ok {
+		return createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Job %s not found", args.JobID),
+			Code:  errorCodeNotFound,
+		}), nil
+	}
+	return createJSONResponse(job), nil
+}
+
+func jobList(ctx context.Context, args JobListArgs) (*mcp_golang.ToolResponse, error) {
+	switch args.Status {
+	case "", jobRunning, jobSucceeded, jobFailed, jobCancelled, jobInterrupted:
+	default:
+		return createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Invalid status %q: expected running, succeeded, failed, cancelled or interrupted", args.Status),
+			Code:  errorCodeValidation,
+		}), nil
+	}
+
+	list := jobs.list(jobOwner(ctx), args.Status)
+	for i := range list {
+		// Keep the list short: the latest event and no results, job-status has the rest
+		if n := len(list[i].Events); n > 1 {
+			list[i].Events = list[i].Events[n-1:]
+		}
+		list[i].Arguments = nil
+		list[i].Result = nil
+	}
+
+	message := fmt.Sprintf("Found %d jobs", len(list))
+	if len(list) == 0 {
+		message = "No jobs found"
+		list = []Job{}
+	}
+	return createJSONResponse(JobListResponse{Jobs: list, Total: len(list), Message: message}), nil
+}
+
+func jobCancel(ctx context.Context, args JobCancelArgs) (*mcp_golang.ToolResponse, error) {
+	job, err := jobs.cancel(jobOwner(ctx), args.JobID)
+	if err != nil {
+		return createJSONResponse(errorResponseFromError(err, err.Error())), nil
+	}
+	logger.InfoContext(ctx, "Job cancellation requested", "jobId", job.ID, "tool", job.Tool)
+	return createJSONResponse(SuccessResponse{
+		Message: fmt.Sprintf("Cancellation of job %s (%s) requested. Changes already sent to Cloudera Cloud Factory are not rolled back.", job.ID, job.Tool),
+		Success: true,
+	}), nil
+}
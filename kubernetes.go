@@ -426,11 +426,13 @@ type DeleteKubernetesResourceArgs struct {
 	Kind      string `json:"kind" jsonschema:"required,description=The kind of the resource (e.g., Pod, Deployment, Service)"`
 	Name      string `json:"name" jsonschema:"required,description=The name of the resource to delete"`
 	Namespace string `json:"namespace,omitempty" jsonschema:"description=The namespace of the resource (optional, defaults to 'default')"`
+	DryRun    bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type DeployKubernetesResourcesArgs struct {
 	ProjectID int32  `json:"projectId" jsonschema:"required,description=The project ID to deploy the resources to"`
 	YAML      string `json:"yaml" jsonschema:"required,description=The Kubernetes resources in YAML format (raw or base64-encoded)"`
+	DryRun    bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type CreateKubeConfigArgs struct {
@@ -442,6 +444,7 @@ type CreateKubeConfigArgs struct {
 	UserId                 string `json:"userId,omitempty" jsonschema:"description=The user ID for the kubeconfig (optional)"`
 	Namespace              string `json:"namespace,omitempty" jsonschema:"description=The namespace for the kubeconfig (optional)"`
 	TTL                    int32  `json:"ttl,omitempty" jsonschema:"description=The TTL for the kubeconfig in minutes (optional)"`
+	DryRun                 bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type GetKubeConfigArgs struct {
@@ -469,24 +472,48 @@ type PatchKubernetesResourceArgs struct {
 	Name      string `json:"name" jsonschema:"required,description=The name of the resource to patch"`
 	Yaml      string `json:"yaml" jsonschema:"required,description=The YAML patch to apply to the resource (raw or base64-encoded)"`
 	Namespace string `json:"namespace,omitempty" jsonschema:"description=The namespace of the resource (optional, defaults to 'default')"`
+	DryRun    bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type ListKubeConfigRolesArgs struct{}
 
-func deployKubernetesResources(client *taikungoclient.Client, args DeployKubernetesResourcesArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func deployKubernetesResources(ctx context.Context, client *taikungoclient.Client, args DeployKubernetesResourcesArgs) (*mcp_golang.ToolResponse, error) {
 	normalizedYaml, err := normalizeYamlInput(args.YAML)
 	if err != nil {
-		return createJSONResponse(ErrorResponse{Error: err.Error()}), nil
+		return createJSONResponse(ErrorResponse{Error: err.Error(), Code: errorCodeValidation}), nil
 	}
 	docs, err := splitKubernetesYaml(normalizedYaml)
 	if err != nil {
-		return createJSONResponse(ErrorResponse{Error: err.Error()}), nil
+		return createJSONResponse(ErrorResponse{Error: err.Error(), Code: errorCodeValidation}), nil
+	}
+
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		requests := make([]DryRunRequest, 0, len(docs))
+		for _, doc := range docs {
+			if err := validateKubernetesYaml(doc); err != nil {
+				return createJSONResponse(ErrorResponse{Error: err.Error(), Code: errorCodeValidation}), nil
+			}
+			encodedYaml := base64.StdEncoding.EncodeToString([]byte(doc))
+			createCmd := taikuncore.NewCreateKubernetesResourceCommand(args.ProjectID, *taikuncore.NewNullableString(&encodedYaml))
+			requests = append(requests, dryRunPost("/api/v1/kubernetes/create-resource", createCmd))
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would deploy %d resource(s) to project '%s'", len(docs), project.GetName()),
+			[]string{
+				fmt.Sprintf("Project %d (%s) exists", args.ProjectID, project.GetName()),
+				fmt.Sprintf("%d YAML document(s) validated", len(docs)),
+			},
+			requests...,
+		), nil
 	}
+
 	for _, doc := range docs {
 		if err := validateKubernetesYaml(doc); err != nil {
-			return createJSONResponse(ErrorResponse{Error: err.Error()}), nil
+			return createJSONResponse(ErrorResponse{Error: err.Error(), Code: errorCodeValidation}), nil
 		}
 		encodedYaml := base64.StdEncoding.EncodeToString([]byte(doc))
 		createCmd := taikuncore.NewCreateKubernetesResourceCommand(args.ProjectID, *taikuncore.NewNullableString(&encodedYaml))
@@ -509,9 +536,7 @@ func deployKubernetesResources(client *taikungoclient.Client, args DeployKuberne
 	return createJSONResponse(successResp), nil
 }
 
-func createKubeConfig(client *taikungoclient.Client, args CreateKubeConfigArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func createKubeConfig(ctx context.Context, client *taikungoclient.Client, args CreateKubeConfigArgs) (*mcp_golang.ToolResponse, error) {
 	createCmd := taikuncore.NewCreateKubeConfigCommand()
 	createCmd.SetProjectId(args.ProjectID)
 
@@ -534,6 +559,18 @@ func createKubeConfig(client *taikungoclient.Client, args CreateKubeConfigArgs)
 		createCmd.SetTtl(args.TTL)
 	}
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would create a kubeconfig for project '%s'", project.GetName()),
+			[]string{fmt.Sprintf("Project %d (%s) exists", args.ProjectID, project.GetName())},
+			dryRunPost("/api/v1/kubeconfig", createCmd),
+		), nil
+	}
+
 	_, httpResponse, err := client.Client.KubeConfigAPI.KubeconfigCreate(ctx).
 		CreateKubeConfigCommand(*createCmd).
 		Execute()
@@ -554,9 +591,7 @@ func createKubeConfig(client *taikungoclient.Client, args CreateKubeConfigArgs)
 	return createJSONResponse(successResp), nil
 }
 
-func getKubeConfig(client *taikungoclient.Client, args GetKubeConfigArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func getKubeConfig(ctx context.Context, client *taikungoclient.Client, args GetKubeConfigArgs) (*mcp_golang.ToolResponse, error) {
 	listRequest := client.Client.KubeConfigAPI.KubeconfigList(ctx).
 		ProjectId(args.ProjectID).
 		Limit(100)
@@ -593,6 +628,7 @@ func getKubeConfig(client *taikungoclient.Client, args GetKubeConfigArgs) (*mcp_
 	if kubeconfigId == 0 {
 		errorResp := ErrorResponse{
 			Error: fmt.Sprintf("No downloadable kubeconfig found for project %d", args.ProjectID),
+			Code:  errorCodeNotFound,
 		}
 		return createJSONResponse(errorResp), nil
 	}
@@ -614,6 +650,7 @@ func getKubeConfig(client *taikungoclient.Client, args GetKubeConfigArgs) (*mcp_
 	if kubeconfig == "" {
 		errorResp := ErrorResponse{
 			Error: fmt.Sprintf("Kubeconfig for project %d not found", args.ProjectID),
+			Code:  errorCodeNotFound,
 		}
 		return createJSONResponse(errorResp), nil
 	}
@@ -633,12 +670,14 @@ func getKubeConfig(client *taikungoclient.Client, args GetKubeConfigArgs) (*mcp_
 			if err := os.MkdirAll(dir, 0o750); err != nil {
 				return createJSONResponse(ErrorResponse{
 					Error: fmt.Sprintf("Failed to create directory for kubeconfig: %v", err),
+					Code:  errorCodeInternal,
 				}), nil
 			}
 		}
 		if err := os.WriteFile(args.SavePath, []byte(normalizedKubeconfig), 0o600); err != nil {
 			return createJSONResponse(ErrorResponse{
 				Error: fmt.Sprintf("Failed to write kubeconfig file: %v", err),
+				Code:  errorCodeInternal,
 			}), nil
 		}
 		savedPath = args.SavePath
@@ -653,9 +692,7 @@ func getKubeConfig(client *taikungoclient.Client, args GetKubeConfigArgs) (*mcp_
 	return createJSONResponse(resp), nil
 }
 
-func listKubeConfigRoles(client *taikungoclient.Client, _ ListKubeConfigRolesArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listKubeConfigRoles(ctx context.Context, client *taikungoclient.Client, _ ListKubeConfigRolesArgs) (*mcp_golang.ToolResponse, error) {
 	roles, httpResponse, err := client.Client.KubeConfigRoleAPI.KubeconfigroleList(ctx).Execute()
 	if err != nil {
 		return createError(httpResponse, err), nil
@@ -797,14 +834,10 @@ func listKubernetesError(kind string, response *http.Response, err error) *mcp_g
 	if response != nil && (response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices) {
 		return createError(response, err)
 	}
-	errorResp := ErrorResponse{
-		Error: fmt.Sprintf("Failed to list %s: %v", kind, err),
-	}
-	return createJSONResponse(errorResp)
+	return createJSONResponse(errorResponseFromError(err, fmt.Sprintf("Failed to list %s: %v", kind, err)))
 }
 
-func listKubernetesResources(client *taikungoclient.Client, args ListKubernetesResourcesArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
+func listKubernetesResources(ctx context.Context, client *taikungoclient.Client, args ListKubernetesResourcesArgs) (*mcp_golang.ToolResponse, error) {
 	var result interface{}
 
 	switch args.Kind {
@@ -943,6 +976,7 @@ func listKubernetesResources(client *taikungoclient.Client, args ListKubernetesR
 	case "CronJobs":
 		return createJSONResponse(ErrorResponse{
 			Error: "CronJobs listing is not available through the Cloudera Cloud Factory Kubernetes list API",
+			Code:  errorCodeValidation,
 		}), nil
 	case "DaemonSets":
 		daemonSets, response, err := fetchKubernetesListItems[daemonSetListItem](ctx, client, args.ProjectID, "daemonset", args.Limit, args.Offset, args.SearchTerm)
@@ -965,6 +999,7 @@ func listKubernetesResources(client *taikungoclient.Client, args ListKubernetesR
 	case "Jobs":
 		return createJSONResponse(ErrorResponse{
 			Error: "Jobs listing is not available through the Cloudera Cloud Factory Kubernetes list API",
+			Code:  errorCodeValidation,
 		}), nil
 	case "Nodes":
 		nodes, response, err := fetchKubernetesListItems[nodeListItem](ctx, client, args.ProjectID, "nodes", args.Limit, args.Offset, args.SearchTerm)
@@ -1007,6 +1042,7 @@ func listKubernetesResources(client *taikungoclient.Client, args ListKubernetesR
 	case "StorageClasses":
 		return createJSONResponse(ErrorResponse{
 			Error: "StorageClasses listing is not available through the Cloudera Cloud Factory Kubernetes list API",
+			Code:  errorCodeValidation,
 		}), nil
 	case "Sts":
 		statefulSets, response, err := fetchKubernetesListItems[statefulSetListItem](ctx, client, args.ProjectID, "sts", args.Limit, args.Offset, args.SearchTerm)
@@ -1028,18 +1064,17 @@ func listKubernetesResources(client *taikungoclient.Client, args ListKubernetesR
 	default:
 		return createJSONResponse(ErrorResponse{
 			Error: fmt.Sprintf("Unsupported resource kind: %s", args.Kind),
+			Code:  errorCodeValidation,
 		}), nil
 	}
 
 	return createJSONResponse(result), nil
 }
 
-func describeKubernetesResource(client *taikungoclient.Client, args DescribeKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func describeKubernetesResource(ctx context.Context, client *taikungoclient.Client, args DescribeKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
 	kind, err := taikuncore.NewEKubernetesResourceFromValue(args.Kind)
 	if err != nil {
-		return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid resource kind: %s", args.Kind)}), nil
+		return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid resource kind: %s", args.Kind), Code: errorCodeValidation}), nil
 	}
 
 	describeCmd := taikuncore.NewDescribeKubernetesResourceCommand(args.ProjectID, args.Name, *kind)
@@ -1070,12 +1105,13 @@ func describeKubernetesResource(client *taikungoclient.Client, args DescribeKube
 	return createJSONResponse(resp), nil
 }
 
-func deleteKubernetesResource(client *taikungoclient.Client, args DeleteKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func deleteKubernetesResource(ctx context.Context, client *taikungoclient.Client, args DeleteKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
 	kind, err := taikuncore.NewEKubernetesResourceFromValue(args.Kind)
 	if err != nil {
-		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Invalid resource kind: %s", args.Kind))), nil
+		return createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Invalid resource kind: %s", args.Kind),
+			Code:  errorCodeValidation,
+		}), nil
 	}
 
 	// Create the action request with name and namespace
@@ -1087,6 +1123,18 @@ func deleteKubernetesResource(client *taikungoclient.Client, args DeleteKubernet
 	// Create the delete command
 	deleteCmd := taikuncore.NewDeleteKubernetesResourceCommand(args.ProjectID, *kind, []taikuncore.KubernetesActionRequest{*actionRequest})
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would delete %s '%s' from project '%s'", args.Kind, args.Name, project.GetName()),
+			[]string{fmt.Sprintf("Project %d (%s) exists", args.ProjectID, project.GetName())},
+			dryRunPost("/api/v1/kubernetes/delete-resource", deleteCmd),
+		), nil
+	}
+
 	_, httpResponse, err := client.Client.KubernetesAPI.KubernetesDeleteResource(ctx).
 		DeleteKubernetesResourceCommand(*deleteCmd).
 		Execute()
@@ -1112,15 +1160,13 @@ func deleteKubernetesResource(client *taikungoclient.Client, args DeleteKubernet
 	return createJSONResponse(successResp), nil
 }
 
-func patchKubernetesResource(client *taikungoclient.Client, args PatchKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func patchKubernetesResource(ctx context.Context, client *taikungoclient.Client, args PatchKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
 	normalizedYaml, err := normalizeYamlInput(args.Yaml)
 	if err != nil {
-		return createJSONResponse(ErrorResponse{Error: err.Error()}), nil
+		return createJSONResponse(ErrorResponse{Error: err.Error(), Code: errorCodeValidation}), nil
 	}
 	if err := validateKubernetesYaml(normalizedYaml); err != nil {
-		return createJSONResponse(ErrorResponse{Error: err.Error()}), nil
+		return createJSONResponse(ErrorResponse{Error: err.Error(), Code: errorCodeValidation}), nil
 	}
 
 	encodedYaml := base64.StdEncoding.EncodeToString([]byte(normalizedYaml))
@@ -1129,6 +1175,21 @@ func patchKubernetesResource(client *taikungoclient.Client, args PatchKubernetes
 		patchCmd.SetNamespace(args.Namespace)
 	}
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would patch resource '%s' in project '%s'", args.Name, project.GetName()),
+			[]string{
+				fmt.Sprintf("Project %d (%s) exists", args.ProjectID, project.GetName()),
+				"Patch YAML validated",
+			},
+			dryRunPost("/api/v1/kubernetes/patch-resource", patchCmd),
+		), nil
+	}
+
 	httpResponse, err := client.Client.KubernetesAPI.KubernetesPatchResource(ctx).
 		PatchKubernetesResourceCommand(*patchCmd).
 		Execute()
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// projectListPageSize is the page size used when every project has to be read
+const projectListPageSize = 100
+
+type ProjectLockArgs struct {
+	ProjectID int32 `json:"projectId" jsonschema:"required,description=ID of the project"`
+	DryRun    bool  `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+}
+
+type SetProjectExpirationArgs struct {
+	ProjectID          int32  `json:"projectId" jsonschema:"required,description=ID of the project"`
+	ExpiresAt          string `json:"expiresAt,omitempty" jsonschema:"description=Expiration date in RFC3339 format"`
+	ExpiresIn          string `json:"expiresIn,omitempty" jsonschema:"description=Expiration relative to now instead of expiresAt such as 72h or 7d"`
+	DeleteOnExpiration bool   `json:"deleteOnExpiration,omitempty" jsonschema:"description=Delete the project when it expires instead of only flagging it (default: false)"`
+	Clear              bool   `json:"clear,omitempty" jsonschema:"description=Remove the expiration so the project never expires (default: false)"`
+	DryRun             bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+}
+
+type ListExpiringProjectsArgs struct {
+	Within         string `json:"within,omitempty" jsonschema:"description=Window to look ahead such as 48h or 7d (default: 7d)"`
+	IncludeExpired bool   `json:"includeExpired,omitempty" jsonschema:"description=Also list projects whose expiration has already passed (default: false)"`
+}
+
+type ExpiringProject struct {
+	ID                 int32   `json:"id"`
+	Name               string  `json:"name"`
+	Status             string  `json:"status"`
+	ExpiresAt          string  `json:"expiresAt"`
+	ExpiresIn          string  `json:"expiresIn"`
+	Expired            bool    `json:"expired"`
+	DeleteOnExpiration bool    `json:"deleteOnExpiration"`
+	IsLocked           bool    `json:"isLocked"`
+	HourlyCost         float64 `json:"hourlyCost"`
+}
+
+type ExpiringProjectListResponse struct {
+	Projects []ExpiringProject `json:"projects"`
+	Total    int               `json:"total"`
+	Within   string            `json:"within"`
+	Message  string            `json:"message"`
+}
+
+// parseLifetime parses a Go duration, also accepting whole days such as "7d"
+func parseLifetime(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %q; use a value such as 48h or 7d", value)
+	}
+	return duration, nil
+}
+
+// parseExpiration parses the expiration dates Taikun returns, with or without a time zone
+func parseExpiration(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05", "02/01/2006 15:04", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatRemaining renders the time until an expiration the way ages are shown elsewhere
+func formatRemaining(remaining time.Duration) string {
+	if remaining < 0 {
+		return "expired " + formatRemaining(-remaining) + " ago"
+	}
+	if remaining >= 48*time.Hour {
+		return fmt.Sprintf("%dd", int(remaining.Hours()/24))
+	}
+	return remaining.Round(time.Minute).String()
+}
+
+func lockProject(ctx context.Context, client *taikungoclient.Client, args ProjectLockArgs) (*mcp_golang.ToolResponse, error) {
+	return setProjectLock(ctx, client, args, true)
+}
+
+func unlockProject(ctx context.Context, client *taikungoclient.Client, args ProjectLockArgs) (*mcp_golang.ToolResponse, error) {
+	return setProjectLock(ctx, client, args, false)
+}
+
+func setProjectLock(ctx context.Context, client *taikungoclient.Client, args ProjectLockArgs, lock bool) (*mcp_golang.ToolResponse, error) {
+	action := "unlock"
+	if lock {
+		action = "lock"
+	}
+
+	project, errorResp := resolveProject(ctx, client, args.ProjectID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+	if project.GetIsLocked() == lock {
+		return createJSONResponse(SuccessResponse{
+			Message: fmt.Sprintf("Project '%s' (ID %d) is already %sed", project.GetName(), args.ProjectID, action),
+			Success: true,
+		}), nil
+	}
+
+	command := taikuncore.NewProjectLockManagerCommand()
+	command.SetId(args.ProjectID)
+	command.SetMode(action)
+
+	if isDryRun(args.DryRun) {
+		return createDryRunResponse(
+			fmt.Sprintf("would %s project '%s'", action, project.GetName()),
+			[]string{fmt.Sprintf("Project %d (%s) exists and is not %sed", args.ProjectID, project.GetName(), action)},
+			dryRunPost("/api/v1/projects/lockmanager", command),
+		), nil
+	}
+
+	httpResponse, err := client.Client.ProjectsAPI.ProjectsLockManager(ctx).
+		ProjectLockManagerCommand(*command).
+		Execute()
+	if err != nil {
+		return createError(httpResponse, err), nil
+	}
+	if errorResp := checkResponse(httpResponse, action+" project"); errorResp != nil {
+		return errorResp, nil
+	}
+
+	return createJSONResponse(SuccessResponse{
+		Message: fmt.Sprintf("Successfully %sed project '%s' (ID %d)", action, project.GetName(), args.ProjectID),
+		Success: true,
+	}), nil
+}
+
+// expirationFromArgs returns the requested expiration, or nil when it is cleared
+func expirationFromArgs(args SetProjectExpirationArgs, now time.Time) (*time.Time, error) {
+	set := 0
+	for _, given := range []bool{args.ExpiresAt != "", args.ExpiresIn != "", args.Clear} {
+		if given {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of expiresAt, expiresIn and clear is required")
+	}
+
+	switch {
+	case args.Clear:
+		if args.DeleteOnExpiration {
+			return nil, fmt.Errorf("deleteOnExpiration needs an expiration")
+		}
+		return nil, nil
+	case args.ExpiresIn != "":
+		lifetime, err := parseLifetime(args.ExpiresIn)
+		if err != nil {
+			return nil, err
+		}
+		if lifetime <= 0 {
+			return nil, fmt.Errorf("expiresIn must be positive")
+		}
+		expiresAt := now.Add(lifetime).UTC()
+		return &expiresAt, nil
+	default:
+		expiresAt, err := time.Parse(time.RFC3339, args.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing expiration date: %v", err)
+		}
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("expiration date %s is in the past", args.ExpiresAt)
+		}
+		return &expiresAt, nil
+	}
+}
+
+func setProjectExpiration(ctx context.Context, client *taikungoclient.Client, args SetProjectExpirationArgs) (*mcp_golang.ToolResponse, error) {
+	expiresAt, err := expirationFromArgs(args, time.Now())
+	if err != nil {
+		return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid expiration: %v", err), Code: errorCodeValidation}), nil
+	}
+
+	command := taikuncore.NewProjectExtendLifeTimeCommand()
+	command.SetProjectId(args.ProjectID)
+	command.SetDeleteOnExpiration(args.DeleteOnExpiration)
+	if expiresAt != nil {
+		command.SetExpireAt(*expiresAt)
+	} else {
+		command.SetExpireAtNil()
+	}
+
+	change := "never expire"
+	if expiresAt != nil {
+		change = fmt.Sprintf("expire on %s (delete on expiration: %t)", expiresAt.Format(time.RFC3339), args.DeleteOnExpiration)
+	}
+
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would set project '%s' to %s", project.GetName(), change),
+			[]string{fmt.Sprintf("Project %d (%s) exists", args.ProjectID, project.GetName())},
+			dryRunPost("/api/v1/projects/extend/lifetime", command),
+		), nil
+	}
+
+	httpResponse, err := client.Client.ProjectsAPI.ProjectsExtendLifetime(ctx).
+		ProjectExtendLifeTimeCommand(*command).
+		Execute()
+	if err != nil {
+		return createError(httpResponse, err), nil
+	}
+	if errorResp := checkResponse(httpResponse, "set project expiration"); errorResp != nil {
+		return errorResp, nil
+	}
+
+	return createJSONResponse(SuccessResponse{
+		Message: fmt.Sprintf("Successfully set project %d to %s", args.ProjectID, change),
+		Success: true,
+	}), nil
+}
+
+// listAllProjects reads every project page by page, limited to one organization unless organizationID is 0
+func listAllProjects(ctx context.Context, client *taikungoclient.Client, organizationID int32) ([]taikuncore.ProjectListDetailDto, *mcp_golang.ToolResponse) {
+	var projects []taikuncore.ProjectListDetailDto
+	for offset := int32(0); ; offset += projectListPageSize {
+		request := client.Client.ProjectsAPI.ProjectsList(ctx).
+			Limit(projectListPageSize).
+			Offset(offset)
+		if organizationID != 0 {
+			request = request.OrganizationId(organizationID)
+		}
+		page, httpResponse, err := request.Execute()
+		if err != nil {
+			return nil, createError(httpResponse, err)
+		}
+		if errorResp := checkResponse(httpResponse, "list projects"); errorResp != nil {
+			return nil, errorResp
+		}
+		if page == nil {
+			return projects, nil
+		}
+		projects = append(projects, page.Data...)
+		if len(page.Data) < projectListPageSize || int32(len(projects)) >= page.GetTotalCount() {
+			return projects, nil
+		}
+	}
+}
+
+// expiringProjects returns the projects that expire before now+within, soonest first
+func expiringProjects(projects []taikuncore.ProjectListDetailDto, now time.Time, within time.Duration, includeExpired bool) []ExpiringProject {
+	result := []ExpiringProject{}
+	for _, project := range projects {
+		if project.ExpiredAt.Get() == nil {
+			continue
+		}
+		expiresAt, ok := parseExpiration(project.GetExpiredAt())
+		if !ok {
+			continue
+		}
+		remaining := expiresAt.Sub(now)
+		if remaining > within || (remaining < 0 && !includeExpired) {
+			continue
+		}
+		result = append(result, ExpiringProject{
+			ID:                 project.GetId(),
+			Name:               project.GetName(),
+			Status:             string(project.GetStatus()),
+			ExpiresAt:          expiresAt.UTC().Format(time.RFC3339),
+			ExpiresIn:          formatRemaining(remaining),
+			Expired:            remaining < 0,
+			DeleteOnExpiration: project.GetDeleteOnExpiration(),
+			IsLocked:           project.GetIsLocked(),
+			HourlyCost:         project.GetTotalHourlyCost(),
+		})
+	}
+	// Timestamps in UTC RFC3339 sort chronologically as strings
+	sort.SliceStable(result, func(i, j int) bool { return result[i].ExpiresAt < result[j].ExpiresAt })
+	return result
+}
+
+func listExpiringProjects(ctx context.Context, client *taikungoclient.Client, args ListExpiringProjectsArgs) (*mcp_golang.ToolResponse, error) {
+	window := args.Within
+	if window == "" {
+		window = "7d"
+	}
+	within, err := parseLifetime(window)
+	if err != nil {
+		return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid window: %v", err), Code: errorCodeValidation}), nil
+	}
+
+	projects, errorResp := listAllProjects(ctx, client, 0)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	expiring := expiringProjects(projects, time.Now(), within, args.IncludeExpired)
+	message := fmt.Sprintf("Found %d project(s) expiring within %s", len(expiring), window)
+	if len(expiring) == 0 {
+		message = fmt.Sprintf("No projects expire within %s", window)
+	}
+	return createJSONResponse(ExpiringProjectListResponse{
+		Projects: expiring,
+		Total:    len(expiring),
+		Within:   window,
+		Message:  message,
+	}), nil
+}
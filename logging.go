@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// Supported log formats
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+const requestIDContextKey contextKey = "request-id"
+
+// logger writes to stderr until initLogger applies the configured level, format and destination
+var logger = slog.New(contextHandler{slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{ReplaceAttr: redactLogAttr})})
+
+// initLogger builds the process logger from the server configuration. The returned closer
+// releases the log file and is nil when logging to stderr.
+func initLogger(cfg serverConfig) (io.Closer, error) {
+	level, err := parseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var out io.Writer = os.Stderr
+	var closer io.Closer
+	if cfg.LogFile != "" {
+		file, err := newRotatingFile(cfg.LogFile, int64(cfg.LogMaxSizeMB)<<20, cfg.LogMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out, closer = file, file
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		AddSource:   level <= slog.LevelDebug,
+		ReplaceAttr: redactLogAttr,
+	}
+	var handler slog.Handler
+	if cfg.LogFormat == logFormatJSON {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
+
+	logger = slog.New(contextHandler{handler})
+	slog.SetDefault(logger)
+	return closer, nil
+}
+
+func parseLogLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unsupported log level %q (expected debug, info, warn or error)", value)
+}
+
+// fatal logs an error and exits the process
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// contextHandler adds the correlation ID of the tool call in the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("requestId", requestID))
+	}
+	if sessionID := sessionIDFromContext(ctx); sessionID != "" {
+		record.AddAttrs(slog.String("sessionId", sessionID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+var bearerTokenPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]{16,}=*`)
+
+// redactLogAttr hides attributes whose key looks secret-bearing and bearer tokens inside strings
+func redactLogAttr(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindGroup {
+		return attr
+	}
+	if sensitiveArgumentPattern.MatchString(attr.Key) {
+		return slog.String(attr.Key, redactedValue)
+	}
+	if attr.Value.Kind() == slog.KindString {
+		value := attr.Value.String()
+		if bearerTokenPattern.MatchString(value) {
+			return slog.String(attr.Key, bearerTokenPattern.ReplaceAllString(value, "${1}"+redactedValue))
+		}
+	}
+	return attr
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to generate request id: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// requestIDFromContext returns the correlation ID of the tool call, if any
+func requestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// withRequestLogging gives every call of a tool handler its own correlation ID and logs how it ended
+func withRequestLogging[T any](name string, handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		ctx = context.WithValue(ctx, requestIDContextKey, newRequestID())
+		start := time.Now()
+		logger.DebugContext(ctx, "Tool call started", "tool", name)
+
+		response, err := handler(ctx, args)
+
+		duration := time.Since(start).Milliseconds()
+		if err != nil {
+			logger.ErrorContext(ctx, "Tool call failed", "tool", name, "durationMs", duration, "error", err)
+			return response, err
+		}
+		outcome, status, message := classifyToolResponse(response)
+		if outcome == auditOutcomeError {
+			logger.WarnContext(ctx, "Tool call returned an error", "tool", name, "durationMs", duration, "httpStatus", status, "error", message)
+		} else {
+			logger.InfoContext(ctx, "Tool call finished", "tool", name, "durationMs", duration, "outcome", outcome)
+		}
+		return response, nil
+	}
+}
+
+// rotatingFile is a log file that is renamed to <path>.1, <path>.2, ... once it exceeds maxSize
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file %s: %w", f.path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if f.maxBackups > 0 {
+		for i := f.maxBackups - 1; i > 0; i-- {
+			_ = os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		}
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
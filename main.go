@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/itera-io/taikungoclient"
 	mcp_golang "github.com/metoro-io/mcp-golang"
-	"github.com/metoro-io/mcp-golang/transport/stdio"
 )
 
 // Build-time variables (set by GoReleaser)
@@ -21,15 +24,23 @@ var (
 )
 
 var (
-	logger       *log.Logger
-	logFilePath  = "/tmp/cloudera_cloud_factory_mcp_server.log"
-	taikunClient *taikungoclient.Client
+	// defaultClient is built from the environment credentials for callers without their own
+	defaultClient = &sharedClient{identity: "env"}
+
+	// Per-session clients for callers that bring their own credentials over HTTP/SSE
+	sessionClients           = newClientCache(30*time.Minute, 100)
+	requireClientCredentials bool
 )
 
 // Response structs for JSON formatting
+// ErrorResponse is the error envelope returned by every tool; see errors.go for the codes
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
+	Error        string          `json:"error"`
+	Code         string          `json:"code"`
+	Details      string          `json:"details,omitempty"`
+	HTTPStatus   int             `json:"httpStatus,omitempty"`
+	UpstreamBody json.RawMessage `json:"upstreamBody,omitempty"`
+	Retryable    bool            `json:"retryable"`
 }
 
 type SuccessResponse struct {
@@ -130,6 +141,7 @@ type AddAppToCatalogWithParametersArgs struct {
 	Repository  string         `json:"repository" jsonschema:"required,description=Repository name (3-30 chars, lowercase/numeric)"`
 	PackageName string         `json:"packageName" jsonschema:"required,description=Package name (3-30 chars, lowercase/numeric)"`
 	Parameters  []AppParameter `json:"parameters,omitempty" jsonschema:"description=Default application parameters to set in the catalog (optional)"`
+	DryRun      bool           `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type ListAvailableAppsArgs struct {
@@ -150,6 +162,7 @@ type SetCatalogAppDefaultParamsArgs struct {
 	CatalogAppID      int32          `json:"catalogAppId" jsonschema:"required,description=The catalog application ID to update parameters for"`
 	Parameters        []AppParameter `json:"parameters" jsonschema:"required,description=Catalog app parameters to set as defaults"`
 	MergeWithExisting *bool          `json:"mergeWithExisting,omitempty" jsonschema:"description=Merge with existing defaults before updating (default: true)"`
+	DryRun            bool           `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type ListRepositoriesArgs struct {
@@ -172,10 +185,15 @@ type CreateProjectArgs struct {
 	AlertingProfileID   int32  `json:"alertingProfileId,omitempty" jsonschema:"description=ID of the alerting profile to use (optional)"`
 	Monitoring          bool   `json:"monitoring,omitempty" jsonschema:"description=Enable monitoring for this project (default: false)"`
 	KubernetesVersion   string `json:"kubernetesVersion,omitempty" jsonschema:"description=Kubernetes version to install (optional)"`
+	ExpiredAt           string `json:"expiredAt,omitempty" jsonschema:"description=Expiration date in RFC3339 format (optional)"`
+	DeleteOnExpiration  bool   `json:"deleteOnExpiration,omitempty" jsonschema:"description=Whether to delete the project on expiration (default: false)"`
+	DryRun              bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type DeleteProjectArgs struct {
-	ProjectID int32 `json:"projectId" jsonschema:"required,description=ID of the project to delete"`
+	ProjectID         int32  `json:"projectId" jsonschema:"required,description=ID of the project to delete"`
+	DryRun            bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	ConfirmationToken string `json:"confirmationToken,omitempty" jsonschema:"description=Token returned by the first call after reviewing its summary; the deletion only runs when this is provided"`
 }
 
 type RemoveAppFromCatalogArgs struct {
@@ -229,6 +247,7 @@ type ListCloudCredentialsArgs struct {
 type BindFlavorsArgs struct {
 	ProjectId int32    `json:"projectId" jsonschema:"description=The ID of the project to bind flavors to"`
 	Flavors   []string `json:"flavors" jsonschema:"description=List of flavor names to bind"`
+	DryRun    bool     `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
 }
 
 type AddServerArgs struct {
@@ -239,12 +258,22 @@ type AddServerArgs struct {
 	DiskSize             int64  `json:"diskSize,omitempty" jsonschema:"description=The disk size in GB (optional)"`
 	Count                int32  `json:"count,omitempty" jsonschema:"description=Number of servers to add (default: 1)"`
 	VerifyTimeoutSeconds int32  `json:"verifyTimeoutSeconds,omitempty" jsonschema:"description=Seconds to wait for server verification (default: 300)"`
+	DryRun               bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	Async                bool   `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
 }
 
+func (a AddServerArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
 type CommitProjectArgs struct {
-	ProjectId int32 `json:"projectId" jsonschema:"description=The ID of the project to commit"`
+	ProjectId    int32 `json:"projectId" jsonschema:"description=The ID of the project to commit"`
+	WaitForReady bool  `json:"waitForReady,omitempty" jsonschema:"description=Wait for the deployment to finish with the project ready and healthy, reporting progress along the way (default: false)"`
+	Timeout      int32 `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds when waiting for the deployment (default: 1800)"`
+	DryRun       bool  `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	Async        bool  `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
 }
 
+func (a CommitProjectArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
 type GetProjectDetailsArgs struct {
 	ProjectId int32 `json:"projectId" jsonschema:"description=The ID of the project to get details for"`
 }
@@ -253,19 +282,27 @@ type WaitForProjectArgs struct {
 	ProjectId   int32 `json:"projectId" jsonschema:"required,description=The ID of the project to wait for"`
 	Timeout     int32 `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds (default: 600 for creation, 300 for deletion)"`
 	WaitDeleted bool  `json:"waitDeleted,omitempty" jsonschema:"description=Wait for the project to be deleted (default: false)"`
+	Async       bool  `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
 }
 
+func (a WaitForProjectArgs) runAsync() bool { return a.Async }
+
 type WaitForAppArgs struct {
 	ProjectAppId int32 `json:"projectAppId" jsonschema:"required,description=The ID of the project application to wait for"`
 	Timeout      int32 `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds (default: 60 for creation, 30 for deletion)"`
 	WaitDeleted  bool  `json:"waitDeleted,omitempty" jsonschema:"description=Wait for the application to be deleted (default: false)"`
+	Async        bool  `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
 }
 
+func (a WaitForAppArgs) runAsync() bool { return a.Async }
+
 type DeleteServersArgs struct {
 	ProjectId                int32   `json:"projectId" jsonschema:"required,description=The ID of the project"`
 	ServerIds                []int32 `json:"serverIds" jsonschema:"required,description=List of server IDs to delete"`
 	ForceDeleteVClusters     bool    `json:"forceDeleteVClusters,omitempty" jsonschema:"description=Force delete virtual clusters on these servers (default: false)"`
 	DeleteAutoscalingServers bool    `json:"deleteAutoscalingServers,omitempty" jsonschema:"description=Delete autoscaling servers (default: false)"`
+	DryRun                   bool    `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	ConfirmationToken        string  `json:"confirmationToken,omitempty" jsonschema:"description=Token returned by the first call after reviewing its summary; the deletion only runs when this is provided"`
 }
 
 type ListFlavorsArgs struct {
@@ -318,8 +355,8 @@ type ProjectStatusResponse struct {
 func createJSONResponse(data interface{}) *mcp_golang.ToolResponse {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		logger.Printf("Error marshaling JSON: %v", err)
-		errorResp := ErrorResponse{Error: "Failed to serialize response data"}
+		logger.Error("Error marshaling JSON", "error", err)
+		errorResp := ErrorResponse{Error: "Failed to serialize response data", Code: errorCodeInternal}
 		jsonData, _ = json.Marshal(errorResp)
 	}
 	return mcp_golang.NewToolResponse(
@@ -329,56 +366,45 @@ func createJSONResponse(data interface{}) *mcp_golang.ToolResponse {
 
 // createError creates a formatted error response for MCP tools
 func createError(response *http.Response, err error) *mcp_golang.ToolResponse {
-	// Use taikungoclient's CreateError for detailed error messages
-	taikunErr := taikungoclient.CreateError(response, err)
-
-	var errorResp ErrorResponse
-	if taikunErr != nil {
-		errorResp.Error = taikunErr.Error()
-	} else {
-		errorResp.Error = "Unknown error occurred"
-	}
-
-	logger.Printf("Error occurred: %s", errorResp.Error)
-	return createJSONResponse(errorResp)
+	return createJSONResponse(apiErrorResponse(response, err))
 }
 
 // checkResponse validates HTTP response status codes
 func checkResponse(response *http.Response, operation string) *mcp_golang.ToolResponse {
 	if response == nil {
-		errorMsg := fmt.Sprintf("No response received for %s", operation)
-		logger.Printf("Error: %s", errorMsg)
-		return mcp_golang.NewToolResponse(
-			mcp_golang.NewTextContent(errorMsg),
-		)
+		return createJSONResponse(ErrorResponse{
+			Error:     fmt.Sprintf("No response received for %s", operation),
+			Code:      errorCodeUpstream5xx,
+			Retryable: true,
+		})
 	}
 
 	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		errorMsg := fmt.Sprintf("Failed to %s. HTTP Status: %d", operation, response.StatusCode)
-		logger.Printf("Error: %s", errorMsg)
-		return mcp_golang.NewToolResponse(
-			mcp_golang.NewTextContent(errorMsg),
-		)
+		code := errorCodeForStatus(response.StatusCode)
+		return createJSONResponse(ErrorResponse{
+			Error:      fmt.Sprintf("Failed to %s. HTTP Status: %d", operation, response.StatusCode),
+			Code:       code,
+			HTTPStatus: response.StatusCode,
+			Retryable:  isRetryableErrorCode(code),
+		})
 	}
 
 	return nil
 }
 
-func initLogger() {
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		os.Exit(1)
-	}
-	logger = log.New(logFile, "[cloudera-cloud-factory-mcp] ", log.LstdFlags|log.Lshortfile)
-	logger.Println("Logger initialized")
-}
-
-func createTaikunClient() *taikungoclient.Client {
+func taikunAPIHost() string {
 	apiHost := os.Getenv("TAIKUN_API_HOST")
 	if apiHost == "" {
 		apiHost = "api.taikun.cloud"
 	}
-	logger.Printf("Using API host: %s", apiHost)
+	return apiHost
+}
+
+// createTaikunClient builds a client from the environment credentials and returns it with the
+// identity that names those credentials in audit records
+func createTaikunClient() (*taikungoclient.Client, string, error) {
+	apiHost := taikunAPIHost()
+	logger.Info("Using API host", "host", apiHost)
 
 	authMode := os.Getenv("TAIKUN_AUTH_MODE")
 
@@ -390,8 +416,9 @@ func createTaikunClient() *taikungoclient.Client {
 		if authMode == "" {
 			authMode = "token"
 		}
-		logger.Printf("Using access key/secret key authentication with mode: %s", authMode)
-		return taikungoclient.NewClientFromCredentials("", "", accessKey, secretKey, authMode, apiHost)
+		logger.Info("Using access key/secret key authentication", "mode", authMode)
+		identity := "env:access-key:" + taikunCredentials{AccessKey: accessKey, SecretKey: secretKey, AuthMode: authMode}.fingerprint()
+		return withRetries(taikungoclient.NewClientFromCredentials("", "", accessKey, secretKey, authMode, apiHost)), identity, nil
 	}
 
 	// Check for email/password (standard taikungoclient env vars)
@@ -399,18 +426,34 @@ func createTaikunClient() *taikungoclient.Client {
 	password := os.Getenv("TAIKUN_PASSWORD")
 
 	if email != "" && password != "" {
-		logger.Printf("Using email/password authentication for user: %s", email)
-		return taikungoclient.NewClientFromCredentials(email, password, "", "", "", apiHost)
+		logger.Info("Using email/password authentication", "user", email)
+		return withRetries(taikungoclient.NewClientFromCredentials(email, password, "", "", "", apiHost)), "env:user:" + email, nil
 	}
 
-	logger.Fatal("No valid authentication credentials found. Please set either:\n" +
+	return nil, "", fmt.Errorf("no valid authentication credentials found. Please set either:\n" +
 		"  - TAIKUN_ACCESS_KEY + TAIKUN_SECRET_KEY + TAIKUN_AUTH_MODE (optional, defaults to 'token')\n" +
 		"  - TAIKUN_EMAIL + TAIKUN_PASSWORD")
-	return nil
 }
 
-func refreshTaikunClient() *mcp_golang.ToolResponse {
-	taikunClient = createTaikunClient()
+func refreshTaikunClient(ctx context.Context) *mcp_golang.ToolResponse {
+	// Callers with their own credentials only drop their cached session client
+	if creds, ok := credentialsFromContext(ctx); ok {
+		sessionClients.evict(creds)
+		return createJSONResponse(SuccessResponse{
+			Message: "Cloudera Cloud Factory session client refreshed successfully",
+			Success: true,
+		})
+	}
+
+	client, identity, err := createTaikunClient()
+	if err != nil {
+		return createJSONResponse(ErrorResponse{
+			Error:   "Failed to refresh Cloudera Cloud Factory client",
+			Code:    errorCodeUnauthorized,
+			Details: err.Error(),
+		})
+	}
+	defaultClient.set(client, identity)
 	successResp := SuccessResponse{
 		Message: "Cloudera Cloud Factory client refreshed successfully",
 		Success: true,
@@ -419,8 +462,14 @@ func refreshTaikunClient() *mcp_golang.ToolResponse {
 }
 
 func main() {
+	cfg, err := parseServerConfig(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	// Handle version command
-	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
+	if cfg.ShowVersion {
 		fmt.Printf("Cloudera Cloud Factory MCP Server %s\n", version)
 		fmt.Printf("  commit: %s\n", commit)
 		fmt.Printf("  built: %s\n", date)
@@ -428,332 +477,401 @@ func main() {
 		return
 	}
 
-	initLogger()
-	logger.Printf("Starting Cloudera Cloud Factory MCP server v%s", version)
+	logCloser, err := initLogger(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logging: %v\n", err)
+		os.Exit(1)
+	}
+	if logCloser != nil {
+		defer logCloser.Close()
+	}
+	logger.Info("Starting Cloudera Cloud Factory MCP server", "version", version)
+
+	serverTransport := newServerTransport(cfg)
+	server := mcp_golang.NewServer(errorResultTransport{resourceTransport{
+		Transport:     progressTransport{serverTransport},
+		notifications: cfg.Transport != transportHTTP,
+	}})
+	logger.Info("MCP server created", "transport", cfg.Transport)
+
+	sessionClients = newClientCache(cfg.ClientCacheTTL, cfg.ClientCacheSize)
+	requireClientCredentials = cfg.RequireClientCredentials
 
-	server := mcp_golang.NewServer(stdio.NewStdioServerTransport())
-	logger.Println("MCP server created")
+	readOnlyMode = cfg.ReadOnly
+	if readOnlyMode {
+		logger.Info("Read-only mode enabled: mutating tools are not registered")
+	}
+	globalDryRun = cfg.DryRun
+	if globalDryRun {
+		logger.Info("Dry-run mode enabled: mutating tools only validate and describe their API requests")
+	}
+	if cfg.AuditLog != "" {
+		if cfg.Transport == transportStdio && strings.Contains(cfg.AuditLog, "stdout") {
+			fatal("The stdout audit sink cannot be used with the stdio transport")
+		}
+		if auditLog, err = newAuditLogger(cfg.AuditLog); err != nil {
+			fatal("Failed to open audit log", "error", err)
+		}
+		defer auditLog.close()
+		logger.Info("Audit log enabled", "sinks", cfg.AuditLog)
+	}
+	defaultToolTimeout = cfg.ToolTimeout
+	if cfg.JobsFile != "" {
+		if jobs, err = loadJobManager(cfg.JobsFile); err != nil {
+			fatal("Failed to load jobs", "error", err)
+		}
+		logger.Info("Job persistence enabled", "file", cfg.JobsFile)
+	}
+	taikunRetryPolicy = retryPolicy{
+		MaxAttempts:   cfg.RetryMaxAttempts,
+		BaseDelay:     cfg.RetryBaseDelay,
+		MaxDelay:      cfg.RetryMaxDelay,
+		MutatingTools: cfg.RetryMutatingTools,
+	}
+	for _, pattern := range taikunRetryPolicy.MutatingTools {
+		if !matchesKnownTool(pattern) {
+			logger.Warn("Retry tool pattern matches no tool", "pattern", pattern)
+		}
+	}
+	if cfg.ToolsConfig != "" {
+		if toolSettings, err = loadToolConfig(cfg.ToolsConfig); err != nil {
+			fatal("Failed to load tools config", "error", err)
+		}
+		logger.Info("Loaded tools config", "file", cfg.ToolsConfig)
+	}
+	if cfg.BudgetPolicy != "" {
+		if budget, err = loadBudgetPolicy(cfg.BudgetPolicy); err != nil {
+			fatal("Failed to load budget policy", "error", err)
+		}
+		logger.Info("Budget policy enabled", "file", cfg.BudgetPolicy)
+	}
 
-	// Initialize the Cloudera Cloud Factory client once
-	taikunClient = createTaikunClient()
-	logger.Println("Cloudera Cloud Factory client initialized")
+	// Initialize the default Cloudera Cloud Factory client once. Network transports may run
+	// without one when every client sends its own credentials.
+	if requireClientCredentials {
+		logger.Info("Per-session credentials required; environment credentials are not used")
+	} else if client, identity, err := createTaikunClient(); err != nil {
+		if cfg.Transport == transportStdio {
+			fatal("No Cloudera Cloud Factory credentials", "error", err)
+		}
+		logger.Warn("No default Cloudera Cloud Factory client; clients must send their own credentials with each session", "error", err)
+	} else {
+		defaultClient.set(client, identity)
+		logger.Info("Cloudera Cloud Factory client initialized")
+		if cfg.Transport != transportStdio {
+			logger.Warn("Callers without their own credentials act as the environment identity; set --require-client-credentials to reject them",
+				"transport", cfg.Transport, "identity", identity)
+		}
+	}
 
-	logger.Println("Starting tool registration...")
+	logger.Debug("Starting tool registration")
 
 	// --- MCP Tool Registrations ---
 
-	err := server.RegisterTool("refresh-taikun-client", "Refresh the Cloudera Cloud Factory API client using current environment credentials", func(args RefreshTaikunClientArgs) (*mcp_golang.ToolResponse, error) {
-		return refreshTaikunClient(), nil
+	err = registerTool(server, "refresh-taikun-client", "Refresh the Cloudera Cloud Factory API client using current environment credentials", func(ctx context.Context, args RefreshTaikunClientArgs) (*mcp_golang.ToolResponse, error) {
+		return refreshTaikunClient(ctx), nil
 	})
 	if err != nil {
-		logger.Fatalf("Failed to register refresh-taikun-client tool: %v", err)
+		fatal("Failed to register tool", "tool", "refresh-taikun-client", "error", err)
 	}
-	logger.Println("Registered refresh-taikun-client tool")
 
-	err = server.RegisterTool("create-virtual-cluster", "Create a new virtual cluster (a project in Cloudera Cloud Factory) with optional wait for completion", func(args CreateVirtualClusterArgs) (*mcp_golang.ToolResponse, error) {
-		return createVirtualCluster(taikunClient, args)
-	})
+	err = registerTool(server, "create-virtual-cluster", "Create a new virtual cluster (a project in Cloudera Cloud Factory) with optional wait for completion", withTaikunClient(createVirtualCluster))
 	if err != nil {
-		logger.Fatalf("Failed to register create-virtual-cluster tool: %v", err)
+		fatal("Failed to register tool", "tool", "create-virtual-cluster", "error", err)
 	}
-	logger.Println("Registered create-virtual-cluster tool")
 
-	err = server.RegisterTool("delete-virtual-cluster", "Delete a virtual cluster (a project in Cloudera Cloud Factory)", func(args DeleteVirtualClusterArgs) (*mcp_golang.ToolResponse, error) {
-		return deleteVirtualCluster(taikunClient, args)
-	})
+	err = registerTool(server, "delete-virtual-cluster", "Delete a virtual cluster (a project in Cloudera Cloud Factory). Two-phase: the first call returns a summary and a confirmationToken, call again with the token to proceed", withTaikunClient(deleteVirtualCluster))
 	if err != nil {
-		logger.Fatalf("Failed to register delete-virtual-cluster tool: %v", err)
+		fatal("Failed to register tool", "tool", "delete-virtual-cluster", "error", err)
 	}
-	logger.Println("Registered delete-virtual-cluster tool")
 
-	err = server.RegisterTool("list-virtual-clusters", "List virtual clusters in a parent project (projects in Cloudera Cloud Factory)", func(args ListVirtualClustersArgs) (*mcp_golang.ToolResponse, error) {
-		return listVirtualClusters(taikunClient, args)
-	})
+	err = registerTool(server, "list-virtual-clusters", "List virtual clusters in a parent project (projects in Cloudera Cloud Factory)", withTaikunClient(listVirtualClusters))
 	if err != nil {
-		logger.Fatalf("Failed to register list-virtual-clusters tool: %v", err)
+		fatal("Failed to register tool", "tool", "list-virtual-clusters", "error", err)
 	}
-	logger.Println("Registered list-virtual-clusters tool")
 
-	err = server.RegisterTool("catalog-create", "Create a new catalog", func(args CreateCatalogArgs) (*mcp_golang.ToolResponse, error) {
-		return createCatalog(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-create", "Create a new catalog", withTaikunClient(createCatalog))
 	if err != nil {
-		logger.Fatalf("Failed to register catalog-create tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-create", "error", err)
 	}
-	logger.Println("Registered catalog-create tool")
 
-	err = server.RegisterTool("catalog-list", "List catalogs with optional filtering", func(args ListCatalogsArgs) (*mcp_golang.ToolResponse, error) {
-		return listCatalogs(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-list", "List catalogs with optional filtering", withTaikunClient(listCatalogs))
 	if err != nil {
-		logger.Fatalf("Failed to register catalog-list tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-list", "error", err)
 	}
-	logger.Println("Registered catalog-list tool")
 
-	err = server.RegisterTool("catalog-delete", "Delete a catalog", func(args DeleteCatalogArgs) (*mcp_golang.ToolResponse, error) {
-		return deleteCatalog(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-delete", "Delete a catalog", withTaikunClient(deleteCatalog))
 	if err != nil {
-		logger.Fatalf("Failed to register catalog-delete tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-delete", "error", err)
 	}
-	logger.Println("Registered catalog-delete tool")
 
-	err = server.RegisterTool("available-apps-list", "List available apps from the package repository", func(args ListAvailableAppsArgs) (*mcp_golang.ToolResponse, error) {
-		return listAvailableApps(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-bind-projects", "Bind projects to a catalog so its applications can be installed in them", withTaikunClient(bindProjectsToCatalog))
 	if err != nil {
-		logger.Fatalf("Failed to register available-apps-list tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-bind-projects", "error", err)
 	}
-	logger.Println("Registered available-apps-list tool")
 
-	err = server.RegisterTool("catalog-app-add", "Add an application to a catalog with optional default parameters", func(args AddAppToCatalogWithParametersArgs) (*mcp_golang.ToolResponse, error) {
-		return addAppToCatalogWithParameters(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-unbind-projects", "Unbind projects from a catalog", withTaikunClient(unbindProjectsFromCatalog))
 	if err != nil {
-		logger.Fatalf("Failed to register catalog-app-add tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-unbind-projects", "error", err)
 	}
-	logger.Println("Registered catalog-app-add tool")
 
-	err = server.RegisterTool("catalog-apps-list", "List applications in a specific catalog or all catalogs", func(args ListCatalogAppsArgs) (*mcp_golang.ToolResponse, error) {
-		return listCatalogApps(taikunClient, args)
-	})
+	err = registerTool(server, "available-apps-list", "List available apps from the package repository", withTaikunClient(listAvailableApps))
 	if err != nil {
-		logger.Fatalf("Failed to register catalog-apps-list tool: %v", err)
+		fatal("Failed to register tool", "tool", "available-apps-list", "error", err)
 	}
-	logger.Println("Registered catalog-apps-list tool")
 
-	err = server.RegisterTool("catalog-app-params", "Get available and added parameters for a catalog application", func(args GetCatalogAppParamsArgs) (*mcp_golang.ToolResponse, error) {
-		return getCatalogAppParameters(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-app-add", "Add an application to a catalog with optional default parameters", withTaikunClient(addAppToCatalogWithParameters))
 	if err != nil {
-		logger.Fatalf("Failed to register catalog-app-params tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-app-add", "error", err)
 	}
-	logger.Println("Registered catalog-app-params tool")
 
-	err = server.RegisterTool("catalog-app-defaults-set", "Update default parameters for a catalog application (merges with existing defaults by default)", func(args SetCatalogAppDefaultParamsArgs) (*mcp_golang.ToolResponse, error) {
-		return updateCatalogAppParameters(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-apps-list", "List applications in a specific catalog or all catalogs", withTaikunClient(listCatalogApps))
 	if err != nil {
-		logger.Fatalf("Failed to register catalog-app-defaults-set tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-apps-list", "error", err)
 	}
-	logger.Println("Registered catalog-app-defaults-set tool")
 
-	err = server.RegisterTool("app-install", "Install a new application instance with optional defaults and overrides", func(args InstallAppArgs) (*mcp_golang.ToolResponse, error) {
-		return installApp(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-app-params", "Get available and added parameters for a catalog application", withTaikunClient(getCatalogAppParameters))
 	if err != nil {
-		logger.Fatalf("Failed to register app-install tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-app-params", "error", err)
 	}
-	logger.Println("Registered app-install tool")
 
-	err = server.RegisterTool("list-apps", "List application instances in a project", func(args ListAppsArgs) (*mcp_golang.ToolResponse, error) {
-		return listApps(taikunClient, args)
-	})
+	err = registerTool(server, "catalog-app-defaults-set", "Update default parameters for a catalog application (merges with existing defaults by default)", withTaikunClient(updateCatalogAppParameters))
 	if err != nil {
-		logger.Fatalf("Failed to register list-apps tool: %v", err)
+		fatal("Failed to register tool", "tool", "catalog-app-defaults-set", "error", err)
 	}
-	logger.Println("Registered list-apps tool")
 
-	err = server.RegisterTool("get-app", "Get detailed application instance information", func(args GetAppArgs) (*mcp_golang.ToolResponse, error) {
-		return getApp(taikunClient, args)
-	})
+	err = registerTool(server, "app-install", "Install a new application instance with optional defaults and overrides", withTaikunClient(installApp))
 	if err != nil {
-		logger.Fatalf("Failed to register get-app tool: %v", err)
+		fatal("Failed to register tool", "tool", "app-install", "error", err)
 	}
-	logger.Println("Registered get-app tool")
 
-	err = server.RegisterTool("update-sync-app", "Update application values and sync", func(args UpdateSyncAppArgs) (*mcp_golang.ToolResponse, error) {
-		return updateSyncApp(taikunClient, args)
-	})
+	err = registerTool(server, "list-apps", "List application instances in a project", withTaikunClient(listApps))
 	if err != nil {
-		logger.Fatalf("Failed to register update-sync-app tool: %v", err)
+		fatal("Failed to register tool", "tool", "list-apps", "error", err)
 	}
-	logger.Println("Registered update-sync-app tool")
 
-	err = server.RegisterTool("uninstall-app", "Uninstall an application instance", func(args UninstallAppArgs) (*mcp_golang.ToolResponse, error) {
-		return uninstallApp(taikunClient, args)
-	})
+	err = registerTool(server, "get-app", "Get detailed application instance information", withTaikunClient(getApp))
 	if err != nil {
-		logger.Fatalf("Failed to register uninstall-app tool: %v", err)
+		fatal("Failed to register tool", "tool", "get-app", "error", err)
 	}
-	logger.Println("Registered uninstall-app tool")
 
-	err = server.RegisterTool("wait-for-app", "Wait for an application instance to be ready", func(args WaitForAppArgs) (*mcp_golang.ToolResponse, error) {
-		return waitForApp(taikunClient, args)
-	})
+	err = registerTool(server, "update-sync-app", "Update application values and sync", withTaikunClient(updateSyncApp))
 	if err != nil {
-		logger.Fatalf("Failed to register wait-for-app tool: %v", err)
+		fatal("Failed to register tool", "tool", "update-sync-app", "error", err)
 	}
-	logger.Println("Registered wait-for-app tool")
 
-	err = server.RegisterTool("list-projects", "List Kubernetes projects with optional virtual cluster filtering", func(args ListProjectsArgs) (*mcp_golang.ToolResponse, error) {
-		return listProjects(taikunClient, args)
-	})
+	err = registerTool(server, "uninstall-app", "Uninstall an application instance. Two-phase: the first call returns a summary and a confirmationToken, call again with the token to proceed", withTaikunClient(uninstallApp))
 	if err != nil {
-		logger.Fatalf("Failed to register list-projects tool: %v", err)
+		fatal("Failed to register tool", "tool", "uninstall-app", "error", err)
 	}
-	logger.Println("Registered list-projects tool")
 
-	err = server.RegisterTool("create-project", "Create a new Kubernetes project in Cloudera Cloud Factory", func(args CreateProjectArgs) (*mcp_golang.ToolResponse, error) {
-		return createProject(taikunClient, args)
-	})
+	err = registerTool(server, "wait-for-app", "Wait for an application instance to be ready", withTaikunClient(waitForApp))
 	if err != nil {
-		logger.Fatalf("Failed to register create-project tool: %v", err)
+		fatal("Failed to register tool", "tool", "wait-for-app", "error", err)
 	}
-	logger.Println("Registered create-project tool")
 
-	err = server.RegisterTool("delete-project", "Delete a project in Cloudera Cloud Factory", func(args DeleteProjectArgs) (*mcp_golang.ToolResponse, error) {
-		return deleteProject(taikunClient, args)
-	})
+	err = registerTool(server, "list-projects", "List Kubernetes projects with optional virtual cluster filtering", withTaikunClient(listProjects))
 	if err != nil {
-		logger.Fatalf("Failed to register delete-project tool: %v", err)
+		fatal("Failed to register tool", "tool", "list-projects", "error", err)
 	}
-	logger.Println("Registered delete-project tool")
 
-	err = server.RegisterTool("wait-for-project", "Wait for a project to be ready and healthy", func(args WaitForProjectArgs) (*mcp_golang.ToolResponse, error) {
-		return waitForProject(taikunClient, args)
-	})
+	err = registerTool(server, "create-project", "Create a new Kubernetes project in Cloudera Cloud Factory", withTaikunClient(createProject))
 	if err != nil {
-		logger.Fatalf("Failed to register wait-for-project tool: %v", err)
+		fatal("Failed to register tool", "tool", "create-project", "error", err)
 	}
-	logger.Println("Registered wait-for-project tool")
 
-	err = server.RegisterTool("deploy-kubernetes-resources", "Deploy Kubernetes resources via YAML in a project", func(args DeployKubernetesResourcesArgs) (*mcp_golang.ToolResponse, error) {
-		return deployKubernetesResources(taikunClient, args)
-	})
+	err = registerTool(server, "delete-project", "Delete a project in Cloudera Cloud Factory. Two-phase: the first call returns a summary and a confirmationToken, call again with the token to proceed", withTaikunClient(deleteProject))
 	if err != nil {
-		logger.Fatalf("Failed to register deploy-kubernetes-resources tool: %v", err)
+		fatal("Failed to register tool", "tool", "delete-project", "error", err)
 	}
-	logger.Println("Registered deploy-kubernetes-resources tool")
 
-	err = server.RegisterTool("create-kubeconfig", "Create a new kubeconfig for a project", func(args CreateKubeConfigArgs) (*mcp_golang.ToolResponse, error) {
-		return createKubeConfig(taikunClient, args)
-	})
+	err = registerTool(server, "wait-for-project", "Wait for a project to be ready and healthy", withTaikunClient(waitForProject))
 	if err != nil {
-		logger.Fatalf("Failed to register create-kubeconfig tool: %v", err)
+		fatal("Failed to register tool", "tool", "wait-for-project", "error", err)
 	}
-	logger.Println("Registered create-kubeconfig tool")
 
-	err = server.RegisterTool("get-kubeconfig", "Retrieve the kubeconfig content for a project (optionally save as YAML)", func(args GetKubeConfigArgs) (*mcp_golang.ToolResponse, error) {
-		return getKubeConfig(taikunClient, args)
-	})
+	err = registerTool(server, "lock-project", "Lock a project so it cannot be changed or deleted until it is unlocked", withTaikunClient(lockProject))
 	if err != nil {
-		logger.Fatalf("Failed to register get-kubeconfig tool: %v", err)
+		fatal("Failed to register tool", "tool", "lock-project", "error", err)
 	}
-	logger.Println("Registered get-kubeconfig tool")
 
-	err = server.RegisterTool("list-kubeconfig-roles", "List available roles for kubeconfigs", func(args ListKubeConfigRolesArgs) (*mcp_golang.ToolResponse, error) {
-		return listKubeConfigRoles(taikunClient, args)
-	})
+	err = registerTool(server, "unlock-project", "Unlock a locked project", withTaikunClient(unlockProject))
 	if err != nil {
-		logger.Fatalf("Failed to register list-kubeconfig-roles tool: %v", err)
+		fatal("Failed to register tool", "tool", "unlock-project", "error", err)
 	}
-	logger.Println("Registered list-kubeconfig-roles tool")
 
-	err = server.RegisterTool("list-kubernetes-resources", "List specialized Kubernetes resources in a project", func(args ListKubernetesResourcesArgs) (*mcp_golang.ToolResponse, error) {
-		return listKubernetesResources(taikunClient, args)
-	})
+	err = registerTool(server, "set-project-expiration", "Set or remove the expiration date of a project and whether it is deleted when it expires", withTaikunClient(setProjectExpiration))
 	if err != nil {
-		logger.Fatalf("Failed to register list-kubernetes-resources tool: %v", err)
+		fatal("Failed to register tool", "tool", "set-project-expiration", "error", err)
 	}
-	logger.Println("Registered list-kubernetes-resources tool")
 
-	err = server.RegisterTool("describe-kubernetes-resource", "Describe a specialized Kubernetes resource in a project", func(args DescribeKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
-		return describeKubernetesResource(taikunClient, args)
-	})
+	err = registerTool(server, "list-expiring-projects", "List the projects that expire within a time window such as 7d; soonest first", withTaikunClient(listExpiringProjects))
 	if err != nil {
-		logger.Fatalf("Failed to register describe-kubernetes-resource tool: %v", err)
+		fatal("Failed to register tool", "tool", "list-expiring-projects", "error", err)
 	}
-	logger.Println("Registered describe-kubernetes-resource tool")
 
-	err = server.RegisterTool("delete-kubernetes-resource", "Delete a Kubernetes resource", func(args DeleteKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
-		return deleteKubernetesResource(taikunClient, args)
-	})
+	err = registerTool(server, "cost-report", "Report hourly and monthly cost grouped by organization, cloud type, project, server role and flavor. Flags idle projects and exports JSON or CSV", withTaikunClient(costReport))
 	if err != nil {
-		logger.Fatalf("Failed to register delete-kubernetes-resource tool: %v", err)
+		fatal("Failed to register tool", "tool", "cost-report", "error", err)
 	}
-	logger.Println("Registered delete-kubernetes-resource tool")
 
-	err = server.RegisterTool("patch-kubernetes-resource", "Patch a Kubernetes resource using YAML", func(args PatchKubernetesResourceArgs) (*mcp_golang.ToolResponse, error) {
-		return patchKubernetesResource(taikunClient, args)
-	})
+	err = registerTool(server, "list-kubernetes-versions", "List the Kubernetes versions available to projects of a cloud credential", withTaikunClient(listKubernetesVersions))
 	if err != nil {
-		logger.Fatalf("Failed to register patch-kubernetes-resource tool: %v", err)
+		fatal("Failed to register tool", "tool", "list-kubernetes-versions", "error", err)
 	}
-	logger.Println("Registered patch-kubernetes-resource tool")
 
-	err = server.RegisterTool("list-cloud-credentials", "List cloud credentials", func(args ListCloudCredentialsArgs) (*mcp_golang.ToolResponse, error) {
-		return listCloudCredentials(taikunClient, args)
-	})
+	err = registerTool(server, "upgrade-project-kubernetes", "Upgrade the Kubernetes of a project to the next available version and wait for it to finish. The project must be Ready and Healthy and not locked; the result reports the node versions before and after", withTaikunClient(upgradeProjectKubernetes))
 	if err != nil {
-		logger.Fatalf("Failed to register list-cloud-credentials tool: %v", err)
+		fatal("Failed to register tool", "tool", "upgrade-project-kubernetes", "error", err)
 	}
-	logger.Println("Registered list-cloud-credentials tool")
 
-	err = server.RegisterTool("bind-flavors-to-project", "Bind flavors to a project", func(args BindFlavorsArgs) (*mcp_golang.ToolResponse, error) {
-		return bindFlavorsToProject(taikunClient, args)
-	})
+	err = registerTool(server, "deploy-kubernetes-resources", "Deploy Kubernetes resources via YAML in a project", withTaikunClient(deployKubernetesResources))
 	if err != nil {
-		logger.Fatalf("Failed to register bind-flavors-to-project tool: %v", err)
+		fatal("Failed to register tool", "tool", "deploy-kubernetes-resources", "error", err)
 	}
-	logger.Println("Registered bind-flavors-to-project tool")
 
-	err = server.RegisterTool("add-server-to-project", "Add a server to a project. Recommendation: Bastion needs min flavor (2 CPUs, 2GB RAM), Master and Worker need at least 4 CPUs and 4GB RAM.", func(args AddServerArgs) (*mcp_golang.ToolResponse, error) {
-		return addServerToProject(taikunClient, args)
-	})
+	err = registerTool(server, "create-kubeconfig", "Create a new kubeconfig for a project", withTaikunClient(createKubeConfig))
 	if err != nil {
-		logger.Fatalf("Failed to register add-server-to-project tool: %v", err)
+		fatal("Failed to register tool", "tool", "create-kubeconfig", "error", err)
 	}
-	logger.Println("Registered add-server-to-project tool")
 
-	err = server.RegisterTool("commit-project", "Commit and deploy a project. Note: Initial deployment takes 10-30 minutes.", func(args CommitProjectArgs) (*mcp_golang.ToolResponse, error) {
-		return commitProject(taikunClient, args)
-	})
+	err = registerTool(server, "get-kubeconfig", "Retrieve the kubeconfig content for a project (optionally save as YAML)", withTaikunClient(getKubeConfig))
 	if err != nil {
-		logger.Fatalf("Failed to register commit-project tool: %v", err)
+		fatal("Failed to register tool", "tool", "get-kubeconfig", "error", err)
 	}
-	logger.Println("Registered commit-project tool")
 
-	err = server.RegisterTool("get-project-details", "Get detailed status of a project", func(args GetProjectDetailsArgs) (*mcp_golang.ToolResponse, error) {
-		return getProjectDetails(taikunClient, args)
-	})
+	err = registerTool(server, "list-kubeconfig-roles", "List available roles for kubeconfigs", withTaikunClient(listKubeConfigRoles))
 	if err != nil {
-		logger.Fatalf("Failed to register get-project-details tool: %v", err)
+		fatal("Failed to register tool", "tool", "list-kubeconfig-roles", "error", err)
 	}
-	logger.Println("Registered get-project-details tool")
 
-	err = server.RegisterTool("list-flavors", "List available flavors for a cloud credential", func(args ListFlavorsArgs) (*mcp_golang.ToolResponse, error) {
-		return listFlavors(taikunClient, args)
-	})
+	err = registerTool(server, "list-kubernetes-resources", "List specialized Kubernetes resources in a project", withTaikunClient(listKubernetesResources))
 	if err != nil {
-		logger.Fatalf("Failed to register list-flavors tool: %v", err)
+		fatal("Failed to register tool", "tool", "list-kubernetes-resources", "error", err)
 	}
-	logger.Println("Registered list-flavors tool")
 
-	err = server.RegisterTool("list-servers", "List servers in a project", func(args ListServersArgs) (*mcp_golang.ToolResponse, error) {
-		return listServers(taikunClient, args)
-	})
+	err = registerTool(server, "describe-kubernetes-resource", "Describe a specialized Kubernetes resource in a project", withTaikunClient(describeKubernetesResource))
 	if err != nil {
-		logger.Fatalf("Failed to register list-servers tool: %v", err)
+		fatal("Failed to register tool", "tool", "describe-kubernetes-resource", "error", err)
 	}
-	logger.Println("Registered list-servers tool")
 
-	err = server.RegisterTool("delete-servers-from-project", "Delete servers from a project", func(args DeleteServersArgs) (*mcp_golang.ToolResponse, error) {
-		return deleteServersFromProject(taikunClient, args)
-	})
+	err = registerTool(server, "delete-kubernetes-resource", "Delete a Kubernetes resource", withTaikunClient(deleteKubernetesResource))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "delete-kubernetes-resource", "error", err)
+	}
+
+	err = registerTool(server, "patch-kubernetes-resource", "Patch a Kubernetes resource using YAML", withTaikunClient(patchKubernetesResource))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "patch-kubernetes-resource", "error", err)
+	}
+
+	err = registerTool(server, "list-cloud-credentials", "List cloud credentials", withTaikunClient(listCloudCredentials))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "list-cloud-credentials", "error", err)
+	}
+
+	err = registerTool(server, "bind-flavors-to-project", "Bind flavors to a project", withTaikunClient(bindFlavorsToProject))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "bind-flavors-to-project", "error", err)
+	}
+
+	err = registerTool(server, "add-server-to-project", "Add a server to a project. Use recommend-flavors to pick a flavor that fits the role.", withTaikunClient(addServerToProject))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "add-server-to-project", "error", err)
+	}
+
+	err = registerTool(server, "commit-project", "Commit and deploy a project. Note: Initial deployment takes 10-30 minutes; set waitForReady to wait for it with progress notifications.", withTaikunClient(commitProject))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "commit-project", "error", err)
+	}
+
+	err = registerTool(server, "provision-cluster", "Provision a Kubernetes cluster from a declarative spec: create the project, bind flavors, add the node pools, commit and wait until it is ready. Reports the result of every step and deletes the project if a step fails. Deployment takes 10-30 minutes; consider async.", withTaikunClient(provisionCluster))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "provision-cluster", "error", err)
+	}
+
+	err = registerTool(server, "export-project-spec", "Export a project as a YAML spec: cloud credential, profiles, monitoring, servers by role/flavor/disk, bound flavors, catalogs and installed apps with their non-default parameters", withTaikunClient(exportProjectSpec))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "export-project-spec", "error", err)
+	}
+
+	err = registerTool(server, "apply-project-spec", "Reconcile a project to a YAML spec from export-project-spec, creating the project if it does not exist. Removing servers or reinstalling apps is two-phase: the first call returns a summary and a confirmationToken", withTaikunClient(applyProjectSpec))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "apply-project-spec", "error", err)
+	}
+
+	err = registerTool(server, "plan-project-changes", "Compare a YAML project spec with the live project and show a Terraform-like plan of the servers, apps and catalog bindings apply-project-spec would add, change or remove, with parameter diffs. Nothing is changed", withTaikunClient(planProjectChanges))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "plan-project-changes", "error", err)
+	}
+
+	err = registerTool(server, "get-project-details", "Get a full view of a project in one call: status and health, Kubernetes version, cloud credential and profiles, bound flavors, servers grouped by role, installed apps, virtual clusters, bound catalogs, cost, lock state and expiration", withTaikunClient(getProjectDetails))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "get-project-details", "error", err)
+	}
+
+	err = registerTool(server, "list-flavors", "List available flavors for a cloud credential", withTaikunClient(listFlavors))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "list-flavors", "error", err)
+	}
+
+	err = registerTool(server, "recommend-flavors", "Rank the flavors of a cloud credential for a server role by fit and price. Leaves out flavors below the role minimum and sizes workers from optional pod count and CPU/memory requests", withTaikunClient(recommendFlavors))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "recommend-flavors", "error", err)
+	}
+
+	err = registerTool(server, "list-servers", "List servers in a project", withTaikunClient(listServers))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "list-servers", "error", err)
+	}
+
+	err = registerTool(server, "delete-servers-from-project", "Delete servers from a project. Two-phase: the first call returns a summary and a confirmationToken, call again with the token to proceed", withTaikunClient(deleteServersFromProject))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "delete-servers-from-project", "error", err)
+	}
+
+	err = registerTool(server, "scale-node-pool", "Scale the Kubeworker or Kubemaster servers of a project to a target count. Scaling up names and adds the servers and commits; scaling down removes the newest Kubeworker servers and warns about the pods running on them. Scaling down is two-phase: the first call returns a summary and a confirmationToken", withTaikunClient(scaleNodePool))
+	if err != nil {
+		fatal("Failed to register tool", "tool", "scale-node-pool", "error", err)
+	}
+
+	err = registerTool(server, "job-status", "Get the status, progress events and result of a job started with async", jobStatus)
+	if err != nil {
+		fatal("Failed to register tool", "tool", "job-status", "error", err)
+	}
+
+	err = registerTool(server, "job-list", "List your asynchronous jobs, newest first", jobList)
 	if err != nil {
-		logger.Fatalf("Failed to register delete-servers-from-project tool: %v", err)
+		fatal("Failed to register tool", "tool", "job-list", "error", err)
 	}
-	logger.Println("Registered delete-servers-from-project tool")
 
-	logger.Println("All tools registered successfully. Starting MCP server...")
-	logger.Println("About to call server.Serve()...")
+	err = registerTool(server, "job-cancel", "Cancel a running asynchronous job. Changes it already made in Cloudera Cloud Factory are not rolled back", jobCancel)
+	if err != nil {
+		fatal("Failed to register tool", "tool", "job-cancel", "error", err)
+	}
+
+	if err := registerResources(server); err != nil {
+		fatal("Failed to register resources", "error", err)
+	}
+	if err := registerPrompts(server); err != nil {
+		fatal("Failed to register prompts", "error", err)
+	}
+
+	logger.Info("All tools registered successfully. Starting MCP server")
 	err = server.Serve()
-	logger.Printf("server.Serve() returned with error: %v", err)
 	if err != nil {
-		logger.Fatalf("Server error: %v", err)
+		fatal("Server error", "error", err)
 	}
 
-	done := make(chan struct{})
-	<-done
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	logger.Info("Shutdown signal received, stopping MCP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := shutdownTransport(shutdownCtx, serverTransport); err != nil {
+		logger.Error("Error during shutdown", "error", err)
+	}
+	logger.Info("MCP server stopped")
 }
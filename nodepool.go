@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// maxPodsPerWarning caps the pods named in the warning about one server being removed
+const maxPodsPerWarning = 10
+
+type ScaleNodePoolArgs struct {
+	ProjectID         int32  `json:"projectId" jsonschema:"required,description=ID of the project"`
+	Role              string `json:"role,omitempty" jsonschema:"description=Role of the pool: Kubeworker or Kubemaster (default: Kubeworker); Kubemaster pools can only grow"`
+	Flavor            string `json:"flavor,omitempty" jsonschema:"description=Only count servers with this flavor and use it for new servers; required when the pool is empty or mixes flavors"`
+	NamePrefix        string `json:"namePrefix,omitempty" jsonschema:"description=Only count servers whose name starts with this prefix; new servers are named <prefix>-<n> (default: the prefix the pool's servers share)"`
+	Count             int32  `json:"count" jsonschema:"required,description=Target number of servers in the pool"`
+	DiskSize          int64  `json:"diskSize,omitempty" jsonschema:"description=Disk size in GB of new servers (default: that of the newest server in the pool)"`
+	WaitForReady      bool   `json:"waitForReady,omitempty" jsonschema:"description=After adding servers wait for the deployment to finish with the project ready and healthy (default: false)"`
+	Timeout           int32  `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds when waiting for the deployment (default: 1800)"`
+	DryRun            bool   `json:"dryRun,omitempty" jsonschema:"description=Compute the servers to add or remove and return the API requests that would be sent without executing them (default: false)"`
+	ConfirmationToken string `json:"confirmationToken,omitempty" jsonschema:"description=Token returned by the first scale-down call after reviewing its summary; servers are only removed when this is provided"`
+	Async             bool   `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
+}
+
+func (a ScaleNodePoolArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
+// NodePod is a pod running on a server that a scale-down removes
+type NodePod struct {
+	Server    string `json:"server"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+}
+
+// ScaleNodePoolResponse reports the servers added or removed and, when a step fails, its error
+type ScaleNodePoolResponse struct {
+	ProjectID int32           `json:"projectId"`
+	Role      string          `json:"role"`
+	Flavor    string          `json:"flavor,omitempty"`
+	From      int32           `json:"from"`
+	To        int32           `json:"to"`
+	Added     []ServerSummary `json:"added,omitempty"`
+	Removed   []string        `json:"removed,omitempty"`
+	Pods      []NodePod       `json:"pods,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+	Commit    json.RawMessage `json:"commit,omitempty"`
+	Success   bool            `json:"success"`
+	Message   string          `json:"message"`
+	Error     string          `json:"error,omitempty"`
+	Code      string          `json:"code,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"`
+}
+
+// fail records the error envelope of a failed step
+func (r *ScaleNodePoolResponse) fail(step, envelope string) {
+	failure := failureFromEnvelope(envelope)
+	r.Success = false
+	r.Error = fmt.Sprintf("%s failed: %s", step, failure.Error)
+	r.Code = failure.Code
+	r.Retryable = failure.Retryable
+}
+
+// nodePoolPlan is the difference between a node pool and its target size
+type nodePoolPlan struct {
+	role     string
+	prefix   string
+	flavor   string
+	diskSize int64
+	current  []taikuncore.ServerListDto
+	add      []string
+	remove   []taikuncore.ServerListDto
+}
+
+// planNodePool selects the servers of the pool, sorted from oldest to newest, and computes the
+// names of the servers to add or picks the newest servers to remove
+func planNodePool(servers []taikuncore.ServerListDto, args ScaleNodePoolArgs) (*nodePoolPlan, error) {
+	role := args.Role
+	if role == "" {
+		role = string(taikuncore.CLOUDROLE_KUBEWORKER)
+	}
+	if role != string(taikuncore.CLOUDROLE_KUBEWORKER) && role != string(taikuncore.CLOUDROLE_KUBEMASTER) {
+		return nil, fmt.Errorf("role %s cannot be scaled; use Kubeworker or Kubemaster", role)
+	}
+	if args.Count < 0 || args.DiskSize < 0 {
+		return nil, fmt.Errorf("count and diskSize cannot be negative")
+	}
+	if role == string(taikuncore.CLOUDROLE_KUBEMASTER) && args.Count < 1 {
+		return nil, fmt.Errorf("a cluster needs at least one Kubemaster server")
+	}
+
+	plan := &nodePoolPlan{role: role, flavor: args.Flavor, diskSize: args.DiskSize}
+	flavors := map[string]bool{}
+	var names []string
+	for _, server := range servers {
+		if string(server.GetRole()) != role || (args.Flavor != "" && server.GetFlavor() != args.Flavor) ||
+			!strings.HasPrefix(server.GetName(), args.NamePrefix) {
+			continue
+		}
+		plan.current = append(plan.current, server)
+		flavors[server.GetFlavor()] = true
+		names = append(names, server.GetName())
+	}
+	sort.Slice(plan.current, func(i, j int) bool { return plan.current[i].GetId() < plan.current[j].GetId() })
+
+	have := int32(len(plan.current))
+	if args.Count < have && role == string(taikuncore.CLOUDROLE_KUBEMASTER) {
+		// Removing masters without draining them can break etcd quorum
+		return nil, fmt.Errorf("the Kubemaster pool has %d servers and cannot be scaled down; only Kubeworker pools can shrink", have)
+	}
+	if args.Count < have {
+		for i := len(plan.current) - 1; i >= int(args.Count); i-- {
+			plan.remove = append(plan.remove, plan.current[i])
+		}
+		return plan, nil
+	}
+	if args.Count == have {
+		return plan, nil
+	}
+
+	if plan.flavor == "" {
+		switch len(flavors) {
+		case 0:
+			return nil, fmt.Errorf("the pool has no %s servers yet; flavor is required", role)
+		case 1:
+			for flavor := range flavors {
+				plan.flavor = flavor
+			}
+		default:
+			mixed := make([]string, 0, len(flavors))
+			for flavor := range flavors {
+				mixed = append(mixed, flavor)
+			}
+			sort.Strings(mixed)
+			return nil, fmt.Errorf("the %s servers use flavors %s; pass flavor to choose the pool", role, strings.Join(mixed, ", "))
+		}
+	}
+	if plan.diskSize == 0 && have > 0 {
+		plan.diskSize = serverDiskSize(plan.current[have-1])
+	}
+
+	plan.prefix = args.NamePrefix
+	if plan.prefix == "" && len(names) > 0 {
+		plan.prefix = strings.TrimRight(poolName(names), "-0123456789")
+	}
+	if plan.prefix == "" {
+		plan.prefix = provisionDefaultNames[taikuncore.CloudRole(role)]
+	}
+	plan.add = nextServerNames(plan.prefix, servers, int(args.Count-have))
+	return plan, nil
+}
+
+// nextServerNames returns n names <prefix>-<i> numbered after every server that already uses the prefix
+func nextServerNames(prefix string, servers []taikuncore.ServerListDto, n int) []string {
+	highest := 0
+	for _, server := range servers {
+		suffix, ok := strings.CutPrefix(server.GetName(), prefix)
+		if !ok {
+			continue
+		}
+		if i, err := strconv.Atoi(strings.TrimPrefix(suffix, "-")); err == nil && i > highest {
+			highest = i
+		}
+	}
+	names := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		names = append(names, fmt.Sprintf("%s-%d", prefix, highest+i))
+	}
+	return names
+}
+
+// describeServer formats a server the way the destruction summaries do
+func describeServer(server taikuncore.ServerListDto) string {
+	return fmt.Sprintf("%s (ID %d, %s, %s)", server.GetName(), server.GetId(), server.GetRole(), server.GetFlavor())
+}
+
+// podsOnServers returns the pods scheduled on the given servers. The Kubernetes node of a server
+// is found by its IP address, or else by its name.
+func podsOnServers(servers []taikuncore.ServerListDto, nodes []nodeListItem, pods []podListItem) []NodePod {
+	nodeServers := map[string]string{}
+	for _, server := range servers {
+		nodeServers[server.GetName()] = server.GetName()
+		for _, node := range nodes {
+			if (node.IP != "" && node.IP == server.GetIpAddress()) || strings.HasPrefix(node.Name, server.GetName()+".") {
+				nodeServers[node.Name] = server.GetName()
+			}
+		}
+	}
+
+	var result []NodePod
+	for _, pod := range pods {
+		server, ok := nodeServers[pod.Node]
+		if !ok || pod.State == "Succeeded" || pod.State == "Completed" {
+			continue
+		}
+		result = append(result, NodePod{Server: server, Namespace: pod.Namespace, Name: pod.Name, Status: pod.State})
+	}
+	return result
+}
+
+// podWarnings summarizes the pods that are evicted when their servers are removed
+func podWarnings(servers []taikuncore.ServerListDto, pods []NodePod) []string {
+	var warnings []string
+	for _, server := range servers {
+		var names []string
+		for _, pod := range pods {
+			if pod.Server == server.GetName() {
+				names = append(names, pod.Namespace+"/"+pod.Name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		listed := strings.Join(names[:min(len(names), maxPodsPerWarning)], ", ")
+		if len(names) > maxPodsPerWarning {
+			listed += fmt.Sprintf(" and %d more", len(names)-maxPodsPerWarning)
+		}
+		warnings = append(warnings, fmt.Sprintf("Server %s runs %d pod(s) that will be evicted: %s", server.GetName(), len(names), listed))
+	}
+	if len(warnings) > 0 {
+		warnings = append(warnings, "The servers are deleted without draining them first; make sure the remaining servers can run the evicted pods")
+	}
+	return warnings
+}
+
+// loadPodsOnServers lists the pods scheduled on the servers a scale-down removes. A cluster that
+// cannot be listed only adds a warning, so a broken cluster can still be scaled down.
+func loadPodsOnServers(ctx context.Context, client *taikungoclient.Client, projectID int32, servers []taikuncore.ServerListDto) ([]NodePod, []string) {
+	nodes, _, err := fetchKubernetesListItems[nodeListItem](ctx, client, projectID, "nodes", 0, 0, "")
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to list nodes before scaling down", "projectId", projectID, "error", err)
+	}
+	pods, _, err := fetchKubernetesListItems[podListItem](ctx, client, projectID, "pods", 0, 0, "")
+	if err != nil {
+		return nil, []string{fmt.Sprintf("Could not list the pods of the cluster to check the servers being removed: %v", err)}
+	}
+	result := podsOnServers(servers, nodes, pods)
+	return result, podWarnings(servers, result)
+}
+
+func scaleNodePool(ctx context.Context, client *taikungoclient.Client, args ScaleNodePoolArgs) (*mcp_golang.ToolResponse, error) {
+	project, errorResp := resolveProject(ctx, client, args.ProjectID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	// The lock covers choosing the new names and creating the servers, so two calls cannot pick the
+	// same names; the deployment is committed and awaited after it is released
+	lock := getProjectServerAddLock(args.ProjectID)
+	lock.Lock()
+	response, errorResp := resizeNodePool(ctx, client, args, project.GetName())
+	lock.Unlock()
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	if len(response.Added) > 0 && response.Error == "" {
+		commit, err := commitProject(ctx, client, CommitProjectArgs{ProjectId: args.ProjectID, WaitForReady: args.WaitForReady, Timeout: args.Timeout})
+		if err != nil {
+			commit = createJSONResponse(errorResponseFromError(err, fmt.Sprintf("Commit failed: %v", err)))
+		}
+		if len(commit.Content) > 0 && commit.Content[0].TextContent != nil {
+			response.Commit = upstreamBody([]byte(commit.Content[0].TextContent.Text))
+		}
+		if envelope, ok := errorEnvelope(commit); ok {
+			response.fail("commit-project", envelope)
+		}
+	}
+	if response.Success {
+		response.Message = fmt.Sprintf("Scaled the %s pool of project '%s' from %d to %d server(s)", response.Role, project.GetName(), response.From, response.To)
+		if len(response.Added) > 0 && !args.WaitForReady {
+			response.Message += ". The deployment was committed; follow it with wait-for-project"
+		}
+	}
+	return createJSONResponse(*response), nil
+}
+
+// resizeNodePool adds or removes the servers of the pool; the caller holds the project's server
+// add lock. It returns a tool response instead when it stops early: for a dry run, a scale-down
+// awaiting confirmation or an invalid request.
+func resizeNodePool(ctx context.Context, client *taikungoclient.Client, args ScaleNodePoolArgs, projectName string) (*ScaleNodePoolResponse, *mcp_golang.ToolResponse) {
+	servers, httpResponse, err := client.Client.ServersAPI.ServersDetails(ctx, args.ProjectID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list servers"); errorResp != nil {
+		return nil, errorResp
+	}
+	var live []taikuncore.ServerListDto
+	if servers != nil {
+		live = servers.Data
+	}
+
+	plan, err := planNodePool(live, args)
+	if err != nil {
+		return nil, createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid scaling request: %v", err), Code: errorCodeValidation})
+	}
+	response := &ScaleNodePoolResponse{
+		ProjectID: args.ProjectID,
+		Role:      plan.role,
+		Flavor:    plan.flavor,
+		From:      int32(len(plan.current)),
+		To:        args.Count,
+		Success:   true,
+	}
+	if plan.role == string(taikuncore.CLOUDROLE_KUBEMASTER) && args.Count%2 == 0 {
+		response.Warnings = append(response.Warnings, "An even number of Kubemaster servers does not tolerate more failures than one fewer")
+	}
+
+	switch {
+	case len(plan.add) > 0:
+		return addPoolServers(ctx, client, args, plan, projectName, response)
+	case len(plan.remove) > 0:
+		return removePoolServers(ctx, client, args, plan, projectName, response)
+	}
+
+	response.Message = fmt.Sprintf("The %s pool of project '%s' already has %d server(s)", plan.role, projectName, args.Count)
+	return nil, createJSONResponse(*response)
+}
+
+func addPoolServers(ctx context.Context, client *taikungoclient.Client, args ScaleNodePoolArgs, plan *nodePoolPlan, projectName string, response *ScaleNodePoolResponse) (*ScaleNodePoolResponse, *mcp_golang.ToolResponse) {
+	if errorResp := requireTools("scale-node-pool", "add-server-to-project", "commit-project"); errorResp != nil {
+		return nil, errorResp
+	}
+
+	// The budget policy sees the whole increase at once, so it cannot stop the pool halfway
+	budgetCheck, errorResp := checkBudget(ctx, client, budgetRequest{
+		action:    fmt.Sprintf("add %d %s server(s) with flavor %s", len(plan.add), plan.role, plan.flavor),
+		projectID: args.ProjectID,
+		servers:   map[string]int32{plan.flavor: int32(len(plan.add))},
+	})
+	if errorResp != nil {
+		return nil, errorResp
+	}
+
+	if isDryRun(args.DryRun) {
+		if errorResp := resolveBoundFlavor(ctx, client, args.ProjectID, plan.flavor); errorResp != nil {
+			return nil, errorResp
+		}
+		requests := make([]DryRunRequest, 0, len(plan.add)+1)
+		for _, name := range plan.add {
+			serverDto := taikuncore.NewServerForCreateDto()
+			serverDto.SetName(name)
+			serverDto.SetRole(taikuncore.CloudRole(plan.role))
+			serverDto.SetFlavor(plan.flavor)
+			serverDto.SetProjectId(args.ProjectID)
+			serverDto.SetCount(1)
+			if plan.diskSize > 0 {
+				serverDto.SetDiskSize(plan.diskSize * 1024 * 1024 * 1024)
+			}
+			requests = append(requests, dryRunPost("/api/v1/servers/create", serverDto))
+		}
+		command := taikuncore.NewProjectDeploymentCommitCommand()
+		command.SetProjectId(args.ProjectID)
+		requests = append(requests, dryRunPost("/api/v1/project-deployment/commit", command))
+
+		checks := append([]string{
+			fmt.Sprintf("Project %d (%s) has %d %s server(s)", args.ProjectID, projectName, len(plan.current), plan.role),
+			fmt.Sprintf("Flavor '%s' is bound to the project", plan.flavor),
+		}, response.Warnings...)
+		if budgetCheck != "" {
+			checks = append(checks, budgetCheck)
+		}
+		return nil, createDryRunResponse(
+			fmt.Sprintf("would add %s to the %s pool of project '%s' and commit", strings.Join(plan.add, ", "), plan.role, projectName),
+			checks, requests...,
+		)
+	}
+
+	ctx = withBudgetChecked(ctx)
+	for i, name := range plan.add {
+		reportProgress(ctx, float64(i), float64(len(plan.add)), fmt.Sprintf("Project %d: adding server %s", args.ProjectID, name))
+		added, err := addServersLocked(ctx, client, AddServerArgs{
+			ProjectId: args.ProjectID,
+			Name:      name,
+			Role:      plan.role,
+			Flavor:    plan.flavor,
+			DiskSize:  plan.diskSize,
+			Count:     1,
+		})
+		if err != nil {
+			added = createJSONResponse(errorResponseFromError(err, fmt.Sprintf("Adding server %s failed: %v", name, err)))
+		}
+		// A server that was created but not verified in time is still reported as added
+		var result struct {
+			Servers []ServerSummary `json:"servers"`
+		}
+		if len(added.Content) > 0 && added.Content[0].TextContent != nil {
+			_ = json.Unmarshal([]byte(added.Content[0].TextContent.Text), &result)
+		}
+		response.Added = append(response.Added, result.Servers...)
+		if envelope, ok := errorEnvelope(added); ok {
+			response.fail("add server "+name, envelope)
+			break
+		}
+	}
+	if response.Error != "" {
+		response.To = response.From + int32(len(response.Added))
+		if len(response.Added) > 0 {
+			response.Warnings = append(response.Warnings, "The servers already added were not committed; call commit-project or scale-node-pool again to finish")
+		}
+	}
+	return response, nil
+}
+
+func removePoolServers(ctx context.Context, client *taikungoclient.Client, args ScaleNodePoolArgs, plan *nodePoolPlan, projectName string, response *ScaleNodePoolResponse) (*ScaleNodePoolResponse, *mcp_golang.ToolResponse) {
+	if errorResp := requireTools("scale-node-pool", "delete-servers-from-project"); errorResp != nil {
+		return nil, errorResp
+	}
+
+	ids := make([]int32, 0, len(plan.remove))
+	idStrings := make([]string, 0, len(plan.remove))
+	removed := make([]string, 0, len(plan.remove))
+	for _, server := range plan.remove {
+		ids = append(ids, server.GetId())
+		idStrings = append(idStrings, strconv.Itoa(int(server.GetId())))
+		removed = append(removed, describeServer(server))
+	}
+
+	if isDryRun(args.DryRun) {
+		pods, warnings := loadPodsOnServers(ctx, client, args.ProjectID, plan.remove)
+		command := taikuncore.NewProjectDeploymentDeleteServersCommand()
+		command.SetProjectId(args.ProjectID)
+		command.SetServerIds(ids)
+		checks := append([]string{
+			fmt.Sprintf("Project %d (%s) has %d %s server(s)", args.ProjectID, projectName, len(plan.current), plan.role),
+			fmt.Sprintf("%d pod(s) run on the newest server(s) %s", len(pods), strings.Join(removed, ", ")),
+		}, append(response.Warnings, warnings...)...)
+		return nil, createDryRunResponse(
+			fmt.Sprintf("would delete %d server(s) from the %s pool of project '%s'", len(ids), plan.role, projectName),
+			checks, dryRunPost("/api/v1/project-deployment/delete", command),
+		)
+	}
+
+	action := fmt.Sprintf("scale-node-pool:%d:%s", args.ProjectID, strings.Join(idStrings, ","))
+	if confirmation := confirmDestructive(ctx, action, args.ConfirmationToken, "scale-node-pool", func() (*DestructionSummary, *mcp_golang.ToolResponse) {
+		summary, errorResp := summarizeProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return nil, errorResp
+		}
+		_, warnings := loadPodsOnServers(ctx, client, args.ProjectID, plan.remove)
+		summary.Removes = fmt.Sprintf("the %d newest of %d %s server(s) in project '%s'", len(ids), len(plan.current), plan.role, projectName)
+		summary.ServerCount = int32(len(ids))
+		summary.Servers = removed
+		summary.Warnings = append(response.Warnings, warnings...)
+		return summary, nil
+	}); confirmation != nil {
+		return nil, confirmation
+	}
+
+	pods, warnings := loadPodsOnServers(ctx, client, args.ProjectID, plan.remove)
+	response.Pods = pods
+	response.Warnings = append(response.Warnings, warnings...)
+	deleted, err := removeProjectServers(ctx, client, args.ProjectID, ids)
+	if err != nil {
+		deleted = createJSONResponse(errorResponseFromError(err, fmt.Sprintf("Deleting servers failed: %v", err)))
+	}
+	if envelope, ok := errorEnvelope(deleted); ok {
+		response.fail("delete servers", envelope)
+		response.To = response.From
+		return response, nil
+	}
+	response.Removed = removed
+	return response, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+const progressContextKey contextKey = "progress"
+
+// progressSink receives the progress reports of a tool call
+type progressSink interface {
+	report(ctx context.Context, progress, total float64, message string)
+}
+
+// progressReporter sends MCP progress notifications for a tool call whose client asked for
+// them by setting _meta.progressToken
+type progressReporter struct {
+	token json.RawMessage
+	send  func(ctx context.Context, message *transport.BaseJsonRpcMessage) error
+
+	mu       sync.Mutex
+	progress float64
+}
+
+// reportProgress tells the client how far a long-running call has come. total is 0 when unknown.
+// It does nothing when the client did not ask for progress.
+func reportProgress(ctx context.Context, progress, total float64, message string) {
+	if sink, ok := ctx.Value(progressContextKey).(progressSink); ok {
+		sink.report(ctx, progress, total, message)
+	}
+}
+
+func (reporter *progressReporter) report(ctx context.Context, progress, total float64, message string) {
+	// Progress never moves backwards, even when a server or health check regresses
+	reporter.mu.Lock()
+	progress = max(progress, reporter.progress)
+	reporter.progress = progress
+	reporter.mu.Unlock()
+
+	params := map[string]interface{}{
+		"progressToken": reporter.token,
+		"progress":      progress,
+		"message":       message,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	notification := transport.NewBaseMessageNotification(&transport.BaseJSONRPCNotification{
+		Jsonrpc: "2.0",
+		Method:  "notifications/progress",
+		Params:  data,
+	})
+	if err := reporter.send(ctx, notification); err != nil {
+		logger.DebugContext(ctx, "Failed to send progress notification", "error", err)
+	}
+}
+
+// progressRequested reports whether the client of the current tool call asked for progress, so
+// waits can skip lookups that only feed the notifications
+func progressRequested(ctx context.Context) bool {
+	_, ok := ctx.Value(progressContextKey).(progressSink)
+	return ok
+}
+
+// elapsedProgress estimates progress from the share of the timeout that has passed, for waits
+// without a better measure
+func elapsedProgress(start time.Time, timeout time.Duration) (float64, float64) {
+	return min(time.Since(start).Seconds(), timeout.Seconds()), timeout.Seconds()
+}
+
+// formatElapsed renders the time spent waiting for progress messages
+func formatElapsed(start time.Time) string {
+	return fmt.Sprintf("%s elapsed", time.Since(start).Round(time.Second))
+}
+
+// progressTransport attaches a progress reporter to the context of every tool call that carries
+// a progress token, so the wait loops can report progress on whichever transport is in use
+type progressTransport struct {
+	transport.Transport
+}
+
+func (t progressTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.Transport.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		if token := progressToken(message); token != nil {
+			ctx = context.WithValue(ctx, progressContextKey, &progressReporter{token: token, send: t.Transport.Send})
+		}
+		handler(ctx, message)
+	})
+}
+
+// progressToken returns the _meta.progressToken of a tools/call request, if set
+func progressToken(message *transport.BaseJsonRpcMessage) json.RawMessage {
+	if message == nil || message.Type != transport.BaseMessageTypeJSONRPCRequestType || message.JsonRpcRequest == nil ||
+		message.JsonRpcRequest.Method != "tools/call" {
+		return nil
+	}
+	var params struct {
+		Meta struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(message.JsonRpcRequest.Params, &params); err != nil {
+		return nil
+	}
+	if len(params.Meta.ProgressToken) == 0 || string(params.Meta.ProgressToken) == "null" {
+		return nil
+	}
+	return params.Meta.ProgressToken
+}
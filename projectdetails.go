@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// hoursPerMonth converts hourly costs into monthly estimates
+const hoursPerMonth = 730
+
+type ProfileRef struct {
+	ID   int32  `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// ServerRoleGroup holds the servers of one role with a count per status
+type ServerRoleGroup struct {
+	Role     string          `json:"role"`
+	Count    int             `json:"count"`
+	Statuses map[string]int  `json:"statuses"`
+	Servers  []ServerSummary `json:"servers"`
+}
+
+type ProjectAppSummary struct {
+	ID        int32  `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Status    string `json:"status"`
+	Version   string `json:"version"`
+	Catalog   string `json:"catalog"`
+	Package   string `json:"package"`
+	AutoSync  bool   `json:"autoSync"`
+}
+
+type ProjectCost struct {
+	HourlyCost      float64 `json:"hourlyCost"`
+	MonthlyEstimate float64 `json:"monthlyEstimate"`
+}
+
+// ProjectDetailsResponse keeps the fields of ProjectStatusResponse at the top level, so callers
+// that only read status and health are unaffected
+type ProjectDetailsResponse struct {
+	ProjectStatusResponse
+	Organization            string                  `json:"organization,omitempty"`
+	CloudCredential         *ProfileRef             `json:"cloudCredential,omitempty"`
+	KubernetesVersion       string                  `json:"kubernetesVersion"`
+	KubernetesTargetVersion string                  `json:"kubernetesTargetVersion,omitempty"`
+	KubernetesProfile       *ProfileRef             `json:"kubernetesProfile,omitempty"`
+	AlertingProfile         *ProfileRef             `json:"alertingProfile,omitempty"`
+	AccessProfile           *ProfileRef             `json:"accessProfile,omitempty"`
+	PolicyProfile           *ProfileRef             `json:"policyProfile,omitempty"`
+	AccessIP                string                  `json:"accessIp,omitempty"`
+	Monitoring              bool                    `json:"monitoring"`
+	Backup                  bool                    `json:"backup"`
+	AutoUpgrade             bool                    `json:"autoUpgrade"`
+	Locked                  bool                    `json:"locked"`
+	MaintenanceMode         bool                    `json:"maintenanceMode"`
+	ExpiresAt               string                  `json:"expiresAt,omitempty"`
+	DeleteOnExpiration      bool                    `json:"deleteOnExpiration"`
+	CertificateExpiresAt    string                  `json:"certificateExpiresAt,omitempty"`
+	Cost                    ProjectCost             `json:"cost"`
+	AlertsCount             int32                   `json:"alertsCount"`
+	Flavors                 []string                `json:"flavors"`
+	Servers                 []ServerRoleGroup       `json:"servers"`
+	Apps                    []ProjectAppSummary     `json:"apps"`
+	VirtualClusters         []VirtualClusterSummary `json:"virtualClusters"`
+	Catalogs                []string                `json:"catalogs"`
+	CreatedAt               string                  `json:"createdAt,omitempty"`
+	CreatedBy               string                  `json:"createdBy,omitempty"`
+	Warnings                []string                `json:"warnings,omitempty"`
+}
+
+func profileRef(id taikuncore.NullableInt32, name string) *ProfileRef {
+	if !id.IsSet() && name == "" {
+		return nil
+	}
+	ref := &ProfileRef{Name: name}
+	if id.Get() != nil {
+		ref.ID = *id.Get()
+	}
+	return ref
+}
+
+// groupServersByRole groups servers by role, bastion first, and counts their statuses
+func groupServersByRole(servers []taikuncore.ServerListDto) []ServerRoleGroup {
+	index := map[string]int{}
+	groups := []ServerRoleGroup{}
+	for _, server := range servers {
+		role := string(server.GetRole())
+		i, ok := index[role]
+		if !ok {
+			i = len(groups)
+			index[role] = i
+			groups = append(groups, ServerRoleGroup{Role: role, Statuses: map[string]int{}})
+		}
+		groups[i].Count++
+		groups[i].Statuses[server.GetStatus()]++
+		groups[i].Servers = append(groups[i].Servers, ServerSummary{
+			ID:        server.GetId(),
+			Name:      server.GetName(),
+			Role:      role,
+			Status:    server.GetStatus(),
+			IPAddress: server.GetIpAddress(),
+			Flavor:    server.GetFlavor(),
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return roleRank(groups[i].Role) < roleRank(groups[j].Role) })
+	return groups
+}
+
+// sectionError turns a failed or unsuccessful request into an error
+func sectionError(httpResponse *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if httpResponse == nil {
+		return fmt.Errorf("no response received")
+	}
+	if httpResponse.StatusCode < http.StatusOK || httpResponse.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("request failed with status %d", httpResponse.StatusCode)
+	}
+	return nil
+}
+
+func getProjectDetails(ctx context.Context, client *taikungoclient.Client, args GetProjectDetailsArgs) (*mcp_golang.ToolResponse, error) {
+	// Using ProjectsList because it contains status and health info
+	request := client.Client.ProjectsAPI.ProjectsList(ctx).
+		Id(args.ProjectId)
+
+	result, httpResponse, err := request.Execute()
+	if err != nil {
+		return createError(httpResponse, err), nil
+	}
+
+	if errorResp := checkResponse(httpResponse, "get project details"); errorResp != nil {
+		return errorResp, nil
+	}
+
+	if len(result.Data) == 0 {
+		return createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Project with ID %d not found", args.ProjectId),
+			Code:  errorCodeNotFound,
+		}), nil
+	}
+
+	project := result.Data[0]
+	response := ProjectDetailsResponse{
+		ProjectStatusResponse: ProjectStatusResponse{
+			ID:        project.GetId(),
+			Name:      project.GetName(),
+			Status:    string(project.GetStatus()),
+			Health:    string(project.GetHealth()),
+			CloudType: string(project.GetCloudType()),
+		},
+		Organization:       project.GetOrganizationName(),
+		KubernetesVersion:  project.GetKubernetesCurrentVersion(),
+		Monitoring:         project.GetIsMonitoringEnabled(),
+		Backup:             project.GetIsBackupEnabled(),
+		Locked:             project.GetIsLocked(),
+		MaintenanceMode:    project.GetIsMaintenanceModeEnabled() || project.GetIsProjectMaintenanceModeEnabled(),
+		ExpiresAt:          project.GetExpiredAt(),
+		DeleteOnExpiration: project.GetDeleteOnExpiration(),
+		Cost: ProjectCost{
+			HourlyCost:      project.GetTotalHourlyCost(),
+			MonthlyEstimate: project.GetTotalHourlyCost() * hoursPerMonth,
+		},
+		AlertsCount:     project.GetAlertsCount(),
+		Flavors:         []string{},
+		Servers:         []ServerRoleGroup{},
+		Apps:            []ProjectAppSummary{},
+		VirtualClusters: []VirtualClusterSummary{},
+		Catalogs:        []string{},
+		CreatedAt:       project.GetCreatedAt(),
+		CreatedBy:       project.GetCreatedBy(),
+	}
+	if target := project.GetKubernetesTargetVersion(); target != response.KubernetesVersion {
+		response.KubernetesTargetVersion = target
+	}
+	if project.CertificateExpiredAt.Get() != nil {
+		response.CertificateExpiresAt = project.GetCertificateExpiredAt()
+	}
+
+	// Every other section is best effort: a failure is reported as a warning with the rest of the view
+	warn := func(section string, err error) {
+		logger.WarnContext(ctx, "Failed to load project details section", "projectId", args.ProjectId, "section", section, "error", err)
+		response.Warnings = append(response.Warnings, fmt.Sprintf("Could not load %s: %v", section, err))
+	}
+
+	servers, httpResponse, err := client.Client.ServersAPI.ServersDetails(ctx, args.ProjectId).Execute()
+	if err := sectionError(httpResponse, err); err != nil {
+		warn("servers", err)
+	} else if servers != nil {
+		details := servers.Project
+		response.CloudCredential = &ProfileRef{ID: details.GetCloudId(), Name: details.GetCloudName()}
+		if details.GetKubernetesVersion() != "" {
+			response.KubernetesVersion = details.GetKubernetesVersion()
+		}
+		response.KubernetesProfile = profileRef(details.KubernetesProfileId, details.GetKubernetesProfileName())
+		response.AlertingProfile = profileRef(details.AlertingProfileId, details.GetAlertingProfileName())
+		response.AccessProfile = profileRef(details.AccessProfileId, details.GetAccessProfileName())
+		response.PolicyProfile = profileRef(details.OpaProfileId, details.GetOpaProfileName())
+		response.AccessIP = details.GetAccessIp()
+		response.AutoUpgrade = details.GetIsAutoUpgrade()
+		response.Servers = groupServersByRole(servers.Data)
+	}
+
+	flavors, httpResponse, err := client.Client.FlavorsAPI.FlavorsSelectedFlavorsForProject(ctx).ProjectId(args.ProjectId).Execute()
+	if err := sectionError(httpResponse, err); err != nil {
+		warn("flavors", err)
+	} else if flavors != nil {
+		for _, flavor := range flavors.Data {
+			response.Flavors = append(response.Flavors, flavor.GetName())
+		}
+	}
+
+	apps, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappList(ctx).ProjectId(args.ProjectId).Execute()
+	if err := sectionError(httpResponse, err); err != nil {
+		warn("applications", err)
+	} else if apps != nil {
+		for _, app := range apps.Data {
+			response.Apps = append(response.Apps, ProjectAppSummary{
+				ID:        app.GetId(),
+				Name:      app.GetName(),
+				Namespace: app.GetNamespace(),
+				Status:    string(app.GetStatus()),
+				Version:   app.GetVersion(),
+				Catalog:   app.GetCatalogName(),
+				Package:   app.GetCatalogAppName(),
+				AutoSync:  app.GetAutoSync(),
+			})
+		}
+	}
+
+	catalogs, httpResponse, err := client.Client.ProjectsAPI.ProjectsCatalogs(ctx, args.ProjectId).Execute()
+	if err := sectionError(httpResponse, err); err != nil {
+		warn("catalogs", err)
+	} else {
+		for _, catalog := range catalogs {
+			if catalog.GetIsBound() {
+				response.Catalogs = append(response.Catalogs, catalog.GetCatalogName())
+			}
+		}
+	}
+
+	// Virtual clusters cannot be nested
+	if !project.GetIsVirtualCluster() {
+		virtualClusters, httpResponse, err := client.Client.VirtualClusterAPI.VirtualClusterList(ctx, args.ProjectId).Execute()
+		if err := sectionError(httpResponse, err); err != nil {
+			warn("virtual clusters", err)
+		} else if virtualClusters != nil {
+			for _, virtualCluster := range virtualClusters.Data {
+				response.VirtualClusters = append(response.VirtualClusters, summarizeVirtualCluster(virtualCluster))
+			}
+		}
+	}
+
+	return createJSONResponse(response), nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/itera-io/taikungoclient"
@@ -18,9 +19,7 @@ type ListProjectsArgs struct {
 	VirtualClustersOnly bool   `json:"virtualClustersOnly,omitempty" jsonschema:"description=Return only virtual cluster projects (default: false)"`
 }
 
-func listProjects(client *taikungoclient.Client, args ListProjectsArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listProjects(ctx context.Context, client *taikungoclient.Client, args ListProjectsArgs) (*mcp_golang.ToolResponse, error) {
 	req := client.Client.ProjectsAPI.ProjectsList(ctx)
 
 	if args.Limit > 0 {
@@ -167,9 +166,7 @@ func getVirtualClusterReadinessReason(project taikuncore.ProjectListDetailDto) s
 	return "Unknown reason"
 }
 
-func createProject(client *taikungoclient.Client, args CreateProjectArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func createProject(ctx context.Context, client *taikungoclient.Client, args CreateProjectArgs) (*mcp_golang.ToolResponse, error) {
 	// Create the project command
 	createCmd := taikuncore.NewCreateProjectCommand()
 	createCmd.SetName(args.Name)
@@ -190,10 +187,52 @@ func createProject(client *taikungoclient.Client, args CreateProjectArgs) (*mcp_
 	if args.KubernetesVersion != "" {
 		createCmd.SetKubernetesVersion(args.KubernetesVersion)
 	}
-	
+
 	// Set monitoring
 	createCmd.SetIsMonitoringEnabled(args.Monitoring)
 
+	// The expiration is checked like set-project-expiration does: in the future, and required for deleteOnExpiration
+	if args.ExpiredAt != "" || args.DeleteOnExpiration {
+		expiration := SetProjectExpirationArgs{ExpiresAt: args.ExpiredAt, DeleteOnExpiration: args.DeleteOnExpiration, Clear: args.ExpiredAt == ""}
+		expTime, err := expirationFromArgs(expiration, time.Now())
+		if err != nil {
+			return createJSONResponse(ErrorResponse{
+				Error: fmt.Sprintf("Invalid expiration: %v", err),
+				Code:  errorCodeValidation,
+			}), nil
+		}
+		if expTime != nil {
+			createCmd.SetExpiredAt(*expTime)
+		}
+	}
+	createCmd.SetDeleteOnExpiration(args.DeleteOnExpiration)
+
+	if isDryRun(args.DryRun) && args.Name == "" {
+		return createJSONResponse(ErrorResponse{Error: "Project name is required", Code: errorCodeValidation}), nil
+	}
+
+	// A new project costs nothing yet, but is refused in an organization that is already over budget
+	budgetCheck, errorResp := checkNewProjectBudget(ctx, client, args.Name, args.CloudCredentialID, nil)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	if isDryRun(args.DryRun) {
+		credential, errorResp := resolveCloudCredential(ctx, client, args.CloudCredentialID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		checks := []string{fmt.Sprintf("Cloud credential %d (%s) exists", args.CloudCredentialID, credential.GetFullName())}
+		if budgetCheck != "" {
+			checks = append(checks, budgetCheck)
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would create project '%s' with cloud credential %d", args.Name, args.CloudCredentialID),
+			checks,
+			dryRunPost("/api/v1/projects", createCmd),
+		), nil
+	}
+
 	// Execute the API call
 	projectResponse, httpResponse, err := client.Client.ProjectsAPI.ProjectsCreate(ctx).
 		CreateProjectCommand(*createCmd).
@@ -236,13 +275,34 @@ func createProject(client *taikungoclient.Client, args CreateProjectArgs) (*mcp_
 	return createJSONResponse(response), nil
 }
 
-func deleteProject(client *taikungoclient.Client, args DeleteProjectArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func deleteProject(ctx context.Context, client *taikungoclient.Client, args DeleteProjectArgs) (*mcp_golang.ToolResponse, error) {
 	// Create the delete command
 	deleteCmd := taikuncore.NewDeleteProjectCommand()
 	deleteCmd.SetProjectId(args.ProjectID)
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would delete project '%s' (ID %d)", project.GetName(), args.ProjectID),
+			[]string{fmt.Sprintf("Project %d (%s) exists", args.ProjectID, project.GetName())},
+			dryRunPost("/api/v1/projects/delete", deleteCmd),
+		), nil
+	}
+
+	action := fmt.Sprintf("delete-project:%d", args.ProjectID)
+	if confirmation := confirmDestructive(ctx, action, args.ConfirmationToken, "delete-project", func() (*DestructionSummary, *mcp_golang.ToolResponse) {
+		summary, errorResp := summarizeProject(ctx, client, args.ProjectID)
+		if summary != nil {
+			summary.Removes = fmt.Sprintf("project '%s' with all of its servers and applications", summary.ProjectName)
+		}
+		return summary, errorResp
+	}); confirmation != nil {
+		return confirmation, nil
+	}
+
 	// Execute the API call to delete the project
 	httpResponse, err := client.Client.ProjectsAPI.ProjectsDelete(ctx).
 		DeleteProjectCommand(*deleteCmd).
@@ -265,8 +325,7 @@ func deleteProject(client *taikungoclient.Client, args DeleteProjectArgs) (*mcp_
 	return createJSONResponse(successResp), nil
 }
 
-func waitForProject(client *taikungoclient.Client, args WaitForProjectArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
+func waitForProject(ctx context.Context, client *taikungoclient.Client, args WaitForProjectArgs) (*mcp_golang.ToolResponse, error) {
 	timeout := 600 // Default 10 minutes for creation
 	if args.WaitDeleted {
 		timeout = 300 // Default 5 minutes for deletion
@@ -276,22 +335,28 @@ func waitForProject(client *taikungoclient.Client, args WaitForProjectArgs) (*mc
 	}
 
 	if args.WaitDeleted {
-		logger.Printf("Waiting for project %d to be deleted (timeout: %d seconds)", args.ProjectId, timeout)
+		logger.InfoContext(ctx, "Waiting for project to be deleted", "projectId", args.ProjectId, "timeoutSeconds", timeout)
 	} else {
-		logger.Printf("Waiting for project %d to be ready (timeout: %d seconds)", args.ProjectId, timeout)
+		logger.InfoContext(ctx, "Waiting for project to be ready", "projectId", args.ProjectId, "timeoutSeconds", timeout)
 	}
 
 	// Poll every 30 seconds
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
+	start := time.Now()
 	timeoutChan := time.After(time.Duration(timeout) * time.Second)
 
 	for {
 		select {
+		case <-ctx.Done():
+			return createJSONResponse(errorResponseFromError(ctx.Err(),
+				fmt.Sprintf("Stopped waiting for project %d: %v", args.ProjectId, ctx.Err()))), nil
 		case <-timeoutChan:
 			return createJSONResponse(ErrorResponse{
-				Error: fmt.Sprintf("Timeout waiting for project %d after %d seconds", args.ProjectId, timeout),
+				Error:     fmt.Sprintf("Timeout waiting for project %d after %d seconds", args.ProjectId, timeout),
+				Code:      errorCodeTimeout,
+				Retryable: true,
 			}), nil
 		case <-ticker.C:
 			// Check project status
@@ -314,12 +379,15 @@ func waitForProject(client *taikungoclient.Client, args WaitForProjectArgs) (*mc
 				}
 				return createJSONResponse(ErrorResponse{
 					Error: fmt.Sprintf("Project %d not found", args.ProjectId),
+					Code:  errorCodeNotFound,
 				}), nil
 			}
 
 			if args.WaitDeleted {
 				project := result.Data[0]
-				logger.Printf("Project %d still exists - Status: %s", args.ProjectId, project.GetStatus())
+				logger.InfoContext(ctx, "Project still exists", "projectId", args.ProjectId, "status", project.GetStatus())
+				progress, total := elapsedProgress(start, time.Duration(timeout)*time.Second)
+				reportProgress(ctx, progress, total, fmt.Sprintf("Project %d: %s, still exists (%s)", args.ProjectId, project.GetStatus(), formatElapsed(start)))
 				continue
 			}
 
@@ -327,7 +395,8 @@ func waitForProject(client *taikungoclient.Client, args WaitForProjectArgs) (*mc
 			status := project.GetStatus()
 			health := project.GetHealth()
 
-			logger.Printf("Project %d status: %s, health: %s", args.ProjectId, status, health)
+			logger.InfoContext(ctx, "Project status", "projectId", args.ProjectId, "status", status, "health", health)
+			reportProjectProgress(ctx, client, project, start)
 
 			if status == taikuncore.PROJECTSTATUS_READY && health == taikuncore.PROJECTHEALTH_HEALTHY {
 				return createJSONResponse(SuccessResponse{
@@ -336,11 +405,35 @@ func waitForProject(client *taikungoclient.Client, args WaitForProjectArgs) (*mc
 				}), nil
 			}
 
-			if status == taikuncore.PROJECTSTATUS_FAILURE || health == taikuncore.PROJECTHEALTH_UNHEALTHY {
+			if status == taikuncore.PROJECTSTATUS_FAILURE || status == taikuncore.PROJECTSTATUS_FAILED_UPGRADE || health == taikuncore.PROJECTHEALTH_UNHEALTHY {
 				return createJSONResponse(ErrorResponse{
 					Error: fmt.Sprintf("Project %d reached a failure state - Status: %s, Health: %s", args.ProjectId, status, health),
+					Code:  errorCodeResourceFailed,
 				}), nil
 			}
 		}
 	}
 }
+
+// reportProjectProgress reports how many of the project's servers are ready. Progress is
+// best effort, so a failure to list the servers only leaves the count out.
+func reportProjectProgress(ctx context.Context, client *taikungoclient.Client, project taikuncore.ProjectListDetailDto, start time.Time) {
+	if !progressRequested(ctx) {
+		return
+	}
+	message := fmt.Sprintf("Project %d: %s, %s", project.GetId(), project.GetStatus(), project.GetHealth())
+	servers, _, err := client.Client.ServersAPI.ServersDetails(ctx, project.GetId()).Execute()
+	if err != nil || servers == nil || len(servers.Data) == 0 {
+		reportProgress(ctx, 0, 0, fmt.Sprintf("%s (%s)", message, formatElapsed(start)))
+		return
+	}
+
+	ready := 0
+	for _, server := range servers.Data {
+		if strings.EqualFold(server.GetStatus(), "Ready") {
+			ready++
+		}
+	}
+	reportProgress(ctx, float64(ready), float64(len(servers.Data)),
+		fmt.Sprintf("%s, %d/%d servers ready (%s)", message, ready, len(servers.Data), formatElapsed(start)))
+}
@@ -0,0 +1,1261 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	projectSpecAPIVersion = "taikun-mcp/v1"
+	projectSpecKind       = "ProjectSpec"
+)
+
+// ProjectSpec is the declarative description of a project written by export-project-spec and
+// reconciled by apply-project-spec
+type ProjectSpec struct {
+	APIVersion          string         `json:"apiVersion"`
+	Kind                string         `json:"kind"`
+	Name                string         `json:"name"`
+	CloudCredentialID   int32          `json:"cloudCredentialId"`
+	CloudCredential     string         `json:"cloudCredential,omitempty"`
+	KubernetesVersion   string         `json:"kubernetesVersion,omitempty"`
+	KubernetesProfileID int32          `json:"kubernetesProfileId,omitempty"`
+	AlertingProfileID   int32          `json:"alertingProfileId,omitempty"`
+	Monitoring          bool           `json:"monitoring"`
+	Flavors             []string       `json:"flavors,omitempty"`
+	Servers             []NodePoolSpec `json:"servers"`
+	Catalogs            []string       `json:"catalogs,omitempty"`
+	Apps                []AppSpec      `json:"apps,omitempty"`
+}
+
+// AppSpec is an installed application. Parameters only hold the values that differ from the
+// catalog defaults.
+type AppSpec struct {
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Catalog    string            `json:"catalog"`
+	Package    string            `json:"package"`
+	Version    string            `json:"version,omitempty"`
+	AutoSync   bool              `json:"autoSync,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+type ExportProjectSpecArgs struct {
+	ProjectID int32 `json:"projectId" jsonschema:"required,description=ID of the project to export"`
+}
+
+type PlanProjectChangesArgs struct {
+	Spec      string `json:"spec" jsonschema:"required,description=Desired project spec in YAML as written by export-project-spec (raw or base64-encoded)"`
+	ProjectID int32  `json:"projectId,omitempty" jsonschema:"description=ID of the project to compare against (default: the project named in the spec)"`
+}
+
+type ApplyProjectSpecArgs struct {
+	Spec              string `json:"spec" jsonschema:"required,description=Project spec in YAML as written by export-project-spec (raw or base64-encoded)"`
+	ProjectID         int32  `json:"projectId,omitempty" jsonschema:"description=ID of the project to reconcile (default: the project named in the spec; created if it does not exist)"`
+	Timeout           int32  `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds when waiting for a deployment (default: 1800)"`
+	DryRun            bool   `json:"dryRun,omitempty" jsonschema:"description=Validate the spec and return the API requests that would be sent without executing them (default: false)"`
+	ConfirmationToken string `json:"confirmationToken,omitempty" jsonschema:"description=Token returned by the first call when the spec removes servers or reinstalls applications; those changes only run when this is provided"`
+	Async             bool   `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
+}
+
+func (a ApplyProjectSpecArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
+type ExportProjectSpecResponse struct {
+	ProjectID int32    `json:"projectId"`
+	Name      string   `json:"name"`
+	Spec      string   `json:"spec"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Message   string   `json:"message"`
+}
+
+// PlanSummary counts the resources a plan adds, changes and destroys. A reinstall counts as one
+// destroy and one add.
+type PlanSummary struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+}
+
+type PlanProjectChangesResponse struct {
+	ProjectID int32        `json:"projectId,omitempty"`
+	Name      string       `json:"name"`
+	Summary   PlanSummary  `json:"summary"`
+	Changes   []SpecChange `json:"changes"`
+	Warnings  []string     `json:"warnings,omitempty"`
+	Plan      string       `json:"plan"`
+	Message   string       `json:"message"`
+}
+
+// ApplyProjectSpecResponse reports the changes applied to a project and, when one fails, its error
+type ApplyProjectSpecResponse struct {
+	ProjectID int32           `json:"projectId,omitempty"`
+	Name      string          `json:"name"`
+	Success   bool            `json:"success"`
+	Message   string          `json:"message,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Code      string          `json:"code,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"`
+	Steps     []ProvisionStep `json:"steps"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+// parseProjectSpec decodes and validates a spec. It returns the server pools with defaults
+// applied, in the order they are added.
+func parseProjectSpec(text string) (*ProjectSpec, []NodePoolSpec, error) {
+	normalized, err := normalizeYamlInput(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	var spec ProjectSpec
+	if err := yaml.UnmarshalStrict([]byte(normalized), &spec); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
+	if spec.APIVersion != projectSpecAPIVersion || spec.Kind != projectSpecKind {
+		return nil, nil, fmt.Errorf("expected apiVersion %s and kind %s", projectSpecAPIVersion, projectSpecKind)
+	}
+	if spec.Name == "" {
+		return nil, nil, fmt.Errorf("name is required")
+	}
+	if spec.CloudCredentialID <= 0 {
+		return nil, nil, fmt.Errorf("cloudCredentialId is required")
+	}
+	pools, err := validateNodePools(spec.Servers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("servers: %w", err)
+	}
+
+	catalogs := map[string]bool{}
+	for _, catalog := range spec.Catalogs {
+		catalogs[catalog] = true
+	}
+	apps := map[string]bool{}
+	for i := range spec.Apps {
+		app := &spec.Apps[i]
+		if app.Name == "" || app.Catalog == "" || app.Package == "" {
+			return nil, nil, fmt.Errorf("app %d: name, catalog and package are required", i+1)
+		}
+		if app.Namespace == "" {
+			app.Namespace = app.Name
+		}
+		if !catalogs[app.Catalog] {
+			return nil, nil, fmt.Errorf("app %s: catalog %s is not listed in catalogs", app.Name, app.Catalog)
+		}
+		key := app.Namespace + "/" + app.Name
+		if apps[key] {
+			return nil, nil, fmt.Errorf("app %s is listed twice in namespace %s", app.Name, app.Namespace)
+		}
+		apps[key] = true
+	}
+	return &spec, pools, nil
+}
+
+// projectState is the live state of a project that a spec describes
+type projectState struct {
+	project  taikuncore.ProjectListDetailDto
+	details  taikuncore.ProjectDetailsForServersDto
+	servers  []taikuncore.ServerListDto
+	flavors  []string
+	catalogs []taikuncore.CatalogListDto
+	apps     []taikuncore.ProjectAppDetailsDto
+	// defaults holds the default parameters of the catalog apps the apps were installed from
+	defaults map[int32]map[string]string
+}
+
+// loadProjectState collects the project, its servers, bound flavors, catalogs and applications
+func loadProjectState(ctx context.Context, client *taikungoclient.Client, projectID int32) (*projectState, *mcp_golang.ToolResponse) {
+	project, errorResp := resolveProject(ctx, client, projectID)
+	if errorResp != nil {
+		return nil, errorResp
+	}
+	state := &projectState{project: *project}
+
+	servers, httpResponse, err := client.Client.ServersAPI.ServersDetails(ctx, projectID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list servers"); errorResp != nil {
+		return nil, errorResp
+	}
+	if servers != nil {
+		state.details = servers.Project
+		state.servers = servers.Data
+	}
+
+	flavors, httpResponse, err := client.Client.FlavorsAPI.FlavorsSelectedFlavorsForProject(ctx).ProjectId(projectID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list project flavors"); errorResp != nil {
+		return nil, errorResp
+	}
+	if flavors != nil {
+		for _, flavor := range flavors.Data {
+			state.flavors = append(state.flavors, flavor.GetName())
+		}
+	}
+
+	if state.catalogs, errorResp = loadCatalogs(ctx, client); errorResp != nil {
+		return nil, errorResp
+	}
+
+	apps, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappList(ctx).ProjectId(projectID).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list applications"); errorResp != nil {
+		return nil, errorResp
+	}
+	if apps != nil {
+		for _, app := range apps.Data {
+			// The list leaves out the parameters, so every app is read in full
+			details, errorResp := resolveProjectApp(ctx, client, app.GetId())
+			if errorResp != nil {
+				return nil, errorResp
+			}
+			state.apps = append(state.apps, *details)
+			if _, ok := state.defaults[details.GetCatalogAppId()]; ok {
+				continue
+			}
+			defaults, httpResponse, err := client.Client.CatalogAppAPI.CatalogAppParamDetails(ctx, details.GetCatalogAppId()).Execute()
+			if err != nil {
+				return nil, createError(httpResponse, err)
+			}
+			if errorResp := checkResponse(httpResponse, "get catalog app default parameters"); errorResp != nil {
+				return nil, errorResp
+			}
+			if state.defaults == nil {
+				state.defaults = map[int32]map[string]string{}
+			}
+			values := map[string]string{}
+			for _, param := range defaults {
+				values[param.GetKey()] = param.GetValue()
+			}
+			state.defaults[details.GetCatalogAppId()] = values
+		}
+	}
+	return state, nil
+}
+
+func loadCatalogs(ctx context.Context, client *taikungoclient.Client) ([]taikuncore.CatalogListDto, *mcp_golang.ToolResponse) {
+	catalogs, httpResponse, err := client.Client.CatalogAPI.CatalogList(ctx).Execute()
+	if err != nil {
+		return nil, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list catalogs"); errorResp != nil {
+		return nil, errorResp
+	}
+	if catalogs == nil {
+		return nil, nil
+	}
+	return catalogs.Data, nil
+}
+
+// boundCatalogs returns the catalogs bound to the project, by name
+func (s *projectState) boundCatalogs() map[string]taikuncore.CatalogListDto {
+	bound := map[string]taikuncore.CatalogListDto{}
+	for _, catalog := range s.catalogs {
+		for _, project := range catalog.BoundProjects {
+			if project.GetId() == s.project.GetId() {
+				bound[catalog.GetName()] = catalog
+				break
+			}
+		}
+	}
+	return bound
+}
+
+// findProjectByName returns the ID of the project with exactly this name, or 0
+func findProjectByName(ctx context.Context, client *taikungoclient.Client, name string) (int32, *mcp_golang.ToolResponse) {
+	result, httpResponse, err := client.Client.ProjectsAPI.ProjectsList(ctx).Search(name).Execute()
+	if err != nil {
+		return 0, createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "list projects"); errorResp != nil {
+		return 0, errorResp
+	}
+	if result != nil {
+		for _, project := range result.Data {
+			if project.GetName() == name {
+				return project.GetId(), nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+func exportProjectSpec(ctx context.Context, client *taikungoclient.Client, args ExportProjectSpecArgs) (*mcp_golang.ToolResponse, error) {
+	state, errorResp := loadProjectState(ctx, client, args.ProjectID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	spec := ProjectSpec{
+		APIVersion:          projectSpecAPIVersion,
+		Kind:                projectSpecKind,
+		Name:                state.project.GetName(),
+		CloudCredentialID:   state.details.GetCloudId(),
+		CloudCredential:     state.details.GetCloudName(),
+		KubernetesVersion:   state.project.GetKubernetesCurrentVersion(),
+		KubernetesProfileID: state.details.GetKubernetesProfileId(),
+		AlertingProfileID:   state.details.GetAlertingProfileId(),
+		Monitoring:          state.project.GetIsMonitoringEnabled(),
+		Flavors:             append([]string(nil), state.flavors...),
+		Servers:             serverPools(state.servers),
+	}
+	sort.Strings(spec.Flavors)
+
+	var warnings []string
+	for _, pool := range spec.Servers {
+		if _, ok := provisionRoleOrder[taikuncore.CloudRole(pool.Role)]; !ok {
+			warnings = append(warnings, fmt.Sprintf("%d %s server(s) cannot be applied: only Bastion, Kubemaster and Kubeworker servers are supported", pool.Count, pool.Role))
+		}
+	}
+
+	for name := range state.boundCatalogs() {
+		spec.Catalogs = append(spec.Catalogs, name)
+	}
+	sort.Strings(spec.Catalogs)
+
+	for _, app := range state.apps {
+		defaultValues := state.defaults[app.GetCatalogAppId()]
+		appSpec := AppSpec{
+			Name:      app.GetName(),
+			Namespace: app.GetNamespace(),
+			Catalog:   app.GetCatalogName(),
+			Package:   app.GetCatalogAppName(),
+			Version:   app.GetVersion(),
+			AutoSync:  app.GetAutoSync(),
+		}
+		for _, param := range app.ProjectAppParams {
+			if value, ok := defaultValues[param.GetKey()]; ok && value == param.GetValue() {
+				continue
+			}
+			if appSpec.Parameters == nil {
+				appSpec.Parameters = map[string]string{}
+			}
+			appSpec.Parameters[param.GetKey()] = param.GetValue()
+		}
+		if !slices.Contains(spec.Catalogs, appSpec.Catalog) {
+			warnings = append(warnings, fmt.Sprintf("App %s comes from catalog %s, which is no longer bound to the project", appSpec.Name, appSpec.Catalog))
+		}
+		spec.Apps = append(spec.Apps, appSpec)
+	}
+	sort.Slice(spec.Apps, func(i, j int) bool {
+		return spec.Apps[i].Namespace+"/"+spec.Apps[i].Name < spec.Apps[j].Namespace+"/"+spec.Apps[j].Name
+	})
+
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Failed to render spec: %v", err), Code: errorCodeInternal}), nil
+	}
+
+	return createJSONResponse(ExportProjectSpecResponse{
+		ProjectID: args.ProjectID,
+		Name:      spec.Name,
+		Spec:      string(data),
+		Warnings:  warnings,
+		Message:   fmt.Sprintf("Exported project '%s' with %d server pool(s), %d catalog(s) and %d app(s)", spec.Name, len(spec.Servers), len(spec.Catalogs), len(spec.Apps)),
+	}), nil
+}
+
+// serverDiskSize converts the disk size of a server, which the API reports in bytes, to GB
+func serverDiskSize(server taikuncore.ServerListDto) int64 {
+	return int64(server.GetDiskSize()) / (1024 * 1024 * 1024)
+}
+
+// serverPools groups servers by role, flavor and disk size
+func serverPools(servers []taikuncore.ServerListDto) []NodePoolSpec {
+	type poolKey struct {
+		role, flavor string
+		diskSize     int64
+	}
+	sorted := append([]taikuncore.ServerListDto(nil), servers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetId() < sorted[j].GetId() })
+
+	index := map[poolKey]int{}
+	var pools []NodePoolSpec
+	var names [][]string
+	for _, server := range sorted {
+		key := poolKey{string(server.GetRole()), server.GetFlavor(), serverDiskSize(server)}
+		i, ok := index[key]
+		if !ok {
+			i = len(pools)
+			index[key] = i
+			pools = append(pools, NodePoolSpec{Role: key.role, Flavor: key.flavor, DiskSize: key.diskSize})
+			names = append(names, nil)
+		}
+		pools[i].Count++
+		names[i] = append(names[i], server.GetName())
+	}
+	for i := range pools {
+		pools[i].Name = poolName(names[i])
+	}
+	sort.SliceStable(pools, func(i, j int) bool {
+		return roleRank(pools[i].Role) < roleRank(pools[j].Role)
+	})
+	return pools
+}
+
+// roleRank orders roles like provisionRoleOrder, with unsupported roles last
+func roleRank(role string) int {
+	if rank, ok := provisionRoleOrder[taikuncore.CloudRole(role)]; ok {
+		return rank
+	}
+	return len(provisionRoleOrder)
+}
+
+// poolName returns the name of a single server, or the prefix the servers of a pool share
+func poolName(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return strings.TrimRight(prefix, "-0123456789")
+}
+
+// Spec change actions, in the order they are applied
+const (
+	changeCreate    = "create"
+	changeBind      = "bind"
+	changeRemove    = "remove"
+	changeAdd       = "add"
+	changeCommit    = "commit"
+	changeUninstall = "uninstall"
+	changeUpgrade   = "upgrade"
+	changeReplace   = "replace"
+	changeInstall   = "install"
+	changeUnbind    = "unbind"
+)
+
+// SpecChange is one change that brings a project closer to its spec
+type SpecChange struct {
+	Action      string      `json:"action"`
+	Resource    string      `json:"resource"`
+	Description string      `json:"description"`
+	Destructive bool        `json:"destructive,omitempty"`
+	Diffs       []ValueDiff `json:"diffs,omitempty"`
+
+	request DryRunRequest
+	apply   func(ctx context.Context) (*mcp_golang.ToolResponse, error)
+}
+
+// tools names the tools whose work the change does, so the tools config applies to the spec too.
+func (c SpecChange) tools() []string {
+	switch c.Action {
+	case changeCreate:
+		return []string{"create-project"}
+	case changeBind:
+		if c.Resource == "flavors" {
+			return []string{"bind-flavors-to-project"}
+		}
+		return []string{"catalog-bind-projects"}
+	case changeUnbind:
+		return []string{"catalog-unbind-projects"}
+	case changeAdd:
+		return []string{"add-server-to-project"}
+	case changeRemove:
+		return []string{"delete-servers-from-project"}
+	case changeCommit:
+		return []string{"commit-project"}
+	case changeInstall:
+		return []string{"app-install"}
+	case changeUninstall:
+		return []string{"uninstall-app"}
+	case changeUpgrade, changeReplace:
+		return []string{"uninstall-app", "app-install"}
+	}
+	return nil
+}
+
+// ValueDiff is a value that a change sets; From or To is empty when the value is not set on that side
+type ValueDiff struct {
+	Key  string `json:"key"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+}
+
+// specPlan is the ordered list of changes between a spec and a project
+type specPlan struct {
+	// projectID is 0 until the project is created by the plan's create change
+	projectID         int32
+	projectName       string
+	cloudCredentialID int32
+	changes           []SpecChange
+	warnings          []string
+	// removedServers describes the servers the plan removes, for the confirmation summary
+	removedServers []string
+	// newServers counts the servers the plan adds by flavor, for the budget check
+	newServers map[string]int32
+}
+
+func (p *specPlan) add(change SpecChange) {
+	p.changes = append(p.changes, change)
+}
+
+func (p *specPlan) destructive() bool {
+	for _, change := range p.changes {
+		if change.Destructive {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprint identifies the exact changes of a plan, so a confirmation token only applies the
+// plan that was reviewed
+func (p *specPlan) fingerprint() string {
+	data, _ := json.Marshal(p.changes)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// planProjectSpec compares a spec with the live state of a project, or with nothing when state
+// is nil, and returns the changes that reconcile them
+func planProjectSpec(client *taikungoclient.Client, spec *ProjectSpec, pools []NodePoolSpec, state *projectState, catalogs []taikuncore.CatalogListDto, timeout int32) (*specPlan, error) {
+	plan := &specPlan{projectName: spec.Name, cloudCredentialID: spec.CloudCredentialID, newServers: map[string]int32{}}
+	if state != nil {
+		plan.projectID = state.project.GetId()
+		plan.warnings = settingsDrift(spec, state)
+	} else {
+		plan.add(createProjectChange(client, plan, spec))
+	}
+
+	// Flavors: the spec's flavors plus those its servers use must be bound
+	bound := map[string]bool{}
+	if state != nil {
+		for _, flavor := range state.flavors {
+			bound[flavor] = true
+		}
+	}
+	var missingFlavors []string
+	for _, flavor := range append(append([]string(nil), spec.Flavors...), provisionFlavors(pools)...) {
+		if !bound[flavor] {
+			bound[flavor] = true
+			missingFlavors = append(missingFlavors, flavor)
+		}
+	}
+	if len(missingFlavors) > 0 {
+		command := taikuncore.NewBindFlavorToProjectCommand()
+		command.SetProjectId(plan.projectID)
+		command.SetFlavors(missingFlavors)
+		plan.add(SpecChange{
+			Action:      changeBind,
+			Resource:    "flavors",
+			Description: fmt.Sprintf("Bind flavor(s) %s", strings.Join(missingFlavors, ", ")),
+			request:     dryRunPost("/api/v1/flavors/bind", command),
+			apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+				return bindFlavorsToProject(ctx, client, BindFlavorsArgs{ProjectId: plan.projectID, Flavors: missingFlavors})
+			},
+		})
+	}
+
+	planServers(client, plan, pools, state, timeout)
+
+	if err := planApps(client, plan, spec, state, catalogs); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func createProjectChange(client *taikungoclient.Client, plan *specPlan, spec *ProjectSpec) SpecChange {
+	args := CreateProjectArgs{
+		Name:                spec.Name,
+		CloudCredentialID:   spec.CloudCredentialID,
+		KubernetesProfileID: spec.KubernetesProfileID,
+		AlertingProfileID:   spec.AlertingProfileID,
+		Monitoring:          spec.Monitoring,
+		KubernetesVersion:   spec.KubernetesVersion,
+	}
+	command := taikuncore.NewCreateProjectCommand()
+	command.SetName(args.Name)
+	command.SetCloudCredentialId(args.CloudCredentialID)
+	command.SetIsKubernetes(true)
+	command.SetIsMonitoringEnabled(args.Monitoring)
+	if args.KubernetesProfileID != 0 {
+		command.SetKubernetesProfileId(args.KubernetesProfileID)
+	}
+	if args.AlertingProfileID != 0 {
+		command.SetAlertingProfileId(args.AlertingProfileID)
+	}
+	if args.KubernetesVersion != "" {
+		command.SetKubernetesVersion(args.KubernetesVersion)
+	}
+
+	return SpecChange{
+		Action:      changeCreate,
+		Resource:    "project " + spec.Name,
+		Description: fmt.Sprintf("Create project '%s' with cloud credential %d", spec.Name, spec.CloudCredentialID),
+		request:     dryRunPost("/api/v1/projects", command),
+		apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+			response, err := createProject(ctx, client, args)
+			if err != nil {
+				return response, err
+			}
+			if _, ok := errorEnvelope(response); ok {
+				return response, nil
+			}
+			var created struct {
+				ID string `json:"id"`
+			}
+			_ = json.Unmarshal([]byte(response.Content[0].TextContent.Text), &created)
+			id, err := strconv.ParseInt(created.ID, 10, 32)
+			if err != nil {
+				return createJSONResponse(ErrorResponse{
+					Error: fmt.Sprintf("Project '%s' was created but its ID was not returned", spec.Name),
+					Code:  errorCodeInternal,
+				}), nil
+			}
+			plan.projectID = int32(id)
+			return response, nil
+		},
+	}
+}
+
+// settingsDrift lists project settings that differ from the spec. They are fixed when the
+// project is created, so apply cannot change them.
+func settingsDrift(spec *ProjectSpec, state *projectState) []string {
+	var drift []string
+	if id := state.details.GetCloudId(); id != spec.CloudCredentialID {
+		drift = append(drift, fmt.Sprintf("Project uses cloud credential %d, not %d; this cannot be changed", id, spec.CloudCredentialID))
+	}
+	if id := state.details.GetKubernetesProfileId(); spec.KubernetesProfileID != 0 && id != spec.KubernetesProfileID {
+		drift = append(drift, fmt.Sprintf("Project uses Kubernetes profile %d, not %d; this cannot be changed", id, spec.KubernetesProfileID))
+	}
+	if id := state.details.GetAlertingProfileId(); spec.AlertingProfileID != 0 && id != spec.AlertingProfileID {
+		drift = append(drift, fmt.Sprintf("Project uses alerting profile %d, not %d; apply does not change it", id, spec.AlertingProfileID))
+	}
+	if enabled := state.project.GetIsMonitoringEnabled(); enabled != spec.Monitoring {
+		drift = append(drift, fmt.Sprintf("Project has monitoring enabled: %t, the spec asks for %t; apply does not change it", enabled, spec.Monitoring))
+	}
+	if version := state.project.GetKubernetesCurrentVersion(); spec.KubernetesVersion != "" && version != spec.KubernetesVersion {
+		drift = append(drift, fmt.Sprintf("Project runs Kubernetes %s, not %s; apply does not upgrade Kubernetes", version, spec.KubernetesVersion))
+	}
+	return drift
+}
+
+// planServers matches the live servers to the pools of the spec. Pools that have too few servers
+// get more; servers no pool claims are removed.
+func planServers(client *taikungoclient.Client, plan *specPlan, pools []NodePoolSpec, state *projectState, timeout int32) {
+	var live []taikuncore.ServerListDto
+	if state != nil {
+		live = append(live, state.servers...)
+		sort.Slice(live, func(i, j int) bool { return live[i].GetId() < live[j].GetId() })
+	}
+	claimed := map[int32]bool{}
+
+	var adds []SpecChange
+	for _, pool := range pools {
+		have := int32(0)
+		for _, server := range live {
+			if have == pool.Count {
+				break
+			}
+			if claimed[server.GetId()] || string(server.GetRole()) != pool.Role || server.GetFlavor() != pool.Flavor ||
+				(pool.DiskSize > 0 && serverDiskSize(server) != pool.DiskSize) {
+				continue
+			}
+			claimed[server.GetId()] = true
+			have++
+		}
+		if have == pool.Count {
+			continue
+		}
+
+		args := AddServerArgs{Name: pool.Name, Role: pool.Role, Flavor: pool.Flavor, DiskSize: pool.DiskSize, Count: pool.Count - have}
+		plan.newServers[pool.Flavor] += args.Count
+		serverDto := taikuncore.NewServerForCreateDto()
+		serverDto.SetName(args.Name)
+		serverDto.SetRole(taikuncore.CloudRole(args.Role))
+		serverDto.SetFlavor(args.Flavor)
+		serverDto.SetProjectId(plan.projectID)
+		serverDto.SetCount(args.Count)
+		if args.DiskSize > 0 {
+			serverDto.SetDiskSize(args.DiskSize * 1024 * 1024 * 1024)
+		}
+		adds = append(adds, SpecChange{
+			Action:      changeAdd,
+			Resource:    fmt.Sprintf("servers %s/%s", pool.Role, pool.Flavor),
+			Description: fmt.Sprintf("Add %d %s server(s) '%s' with flavor %s (%d of %d exist)", args.Count, pool.Role, pool.Name, pool.Flavor, have, pool.Count),
+			Diffs:       []ValueDiff{{Key: "count", From: strconv.Itoa(int(have)), To: strconv.Itoa(int(pool.Count))}},
+			request:     dryRunPost("/api/v1/servers/create", serverDto),
+			apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+				args.ProjectId = plan.projectID
+				return addServerToProject(ctx, client, args)
+			},
+		})
+	}
+
+	var removeIDs []int32
+	for _, server := range live {
+		if claimed[server.GetId()] {
+			continue
+		}
+		removeIDs = append(removeIDs, server.GetId())
+		plan.removedServers = append(plan.removedServers,
+			fmt.Sprintf("%s (ID %d, %s, %s)", server.GetName(), server.GetId(), server.GetRole(), server.GetFlavor()))
+	}
+	if len(removeIDs) > 0 {
+		command := taikuncore.NewProjectDeploymentDeleteServersCommand()
+		command.SetProjectId(plan.projectID)
+		command.SetServerIds(removeIDs)
+		plan.add(SpecChange{
+			Action:      changeRemove,
+			Resource:    "servers",
+			Description: fmt.Sprintf("Remove %d server(s): %s", len(removeIDs), strings.Join(plan.removedServers, ", ")),
+			Destructive: true,
+			request:     dryRunPost("/api/v1/project-deployment/delete", command),
+			apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+				return removeProjectServers(ctx, client, plan.projectID, removeIDs)
+			},
+		})
+	}
+
+	for _, change := range adds {
+		plan.add(change)
+	}
+	if len(adds) > 0 {
+		command := taikuncore.NewProjectDeploymentCommitCommand()
+		command.SetProjectId(plan.projectID)
+		plan.add(SpecChange{
+			Action:      changeCommit,
+			Resource:    "project " + plan.projectName,
+			Description: "Commit the new servers and wait for the project to be ready",
+			request:     dryRunPost("/api/v1/project-deployment/commit", command),
+			apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+				return commitProject(ctx, client, CommitProjectArgs{ProjectId: plan.projectID, WaitForReady: true, Timeout: timeout})
+			},
+		})
+	}
+}
+
+// planApps binds the catalogs of the spec and installs, reinstalls or uninstalls applications
+func planApps(client *taikungoclient.Client, plan *specPlan, spec *ProjectSpec, state *projectState, catalogs []taikuncore.CatalogListDto) error {
+	byName := map[string]taikuncore.CatalogListDto{}
+	for _, catalog := range catalogs {
+		byName[catalog.GetName()] = catalog
+	}
+	bound := map[string]taikuncore.CatalogListDto{}
+	if state != nil {
+		bound = state.boundCatalogs()
+	}
+
+	for _, name := range spec.Catalogs {
+		catalog, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("catalog %s does not exist", name)
+		}
+		if _, ok := bound[name]; ok {
+			continue
+		}
+		catalogID := catalog.GetId()
+		plan.add(SpecChange{
+			Action:      changeBind,
+			Resource:    "catalog " + name,
+			Description: fmt.Sprintf("Bind catalog '%s' to the project", name),
+			request:     catalogProjectsRequest(http.MethodPost, catalogID, []int32{plan.projectID}),
+			apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+				return bindProjectsToCatalog(ctx, client, BindProjectsToCatalogArgs{CatalogID: catalogID, ProjectIDs: []int32{plan.projectID}})
+			},
+		})
+	}
+
+	live := map[string]taikuncore.ProjectAppDetailsDto{}
+	if state != nil {
+		for _, app := range state.apps {
+			live[app.GetNamespace()+"/"+app.GetName()] = app
+		}
+	}
+
+	var uninstalls, reinstalls, installs []SpecChange
+	wanted := map[string]bool{}
+	for _, app := range spec.Apps {
+		key := app.Namespace + "/" + app.Name
+		wanted[key] = true
+
+		var catalogApp *taikuncore.AvailablePackagesDto
+		for _, candidate := range byName[app.Catalog].BoundApplications {
+			if candidate.GetName() == app.Package {
+				catalogApp = &candidate
+				break
+			}
+		}
+		if catalogApp == nil {
+			return fmt.Errorf("app %s: package %s is not in catalog %s", app.Name, app.Package, app.Catalog)
+		}
+		install := installAppChange(client, plan, app, catalogApp.GetCatalogAppId())
+
+		current, exists := live[key]
+		if !exists {
+			if app.Version != "" && catalogApp.GetVersion() != app.Version {
+				plan.warnings = append(plan.warnings, fmt.Sprintf("App %s: catalog %s offers version %s of %s, not %s", app.Name, app.Catalog, catalogApp.GetVersion(), app.Package, app.Version))
+			}
+			installs = append(installs, install)
+			continue
+		}
+
+		change := SpecChange{Resource: install.Resource, Destructive: true, request: install.request}
+		switch {
+		case current.GetCatalogName() != app.Catalog || current.GetCatalogAppName() != app.Package:
+			change.Action = changeReplace
+			change.Diffs = append(change.Diffs, ValueDiff{Key: "package",
+				From: current.GetCatalogName() + "/" + current.GetCatalogAppName(), To: app.Catalog + "/" + app.Package})
+		case app.Version != "" && current.GetVersion() != app.Version:
+			if catalogApp.GetVersion() != app.Version {
+				plan.warnings = append(plan.warnings, fmt.Sprintf("App %s runs version %s; catalog %s offers %s, not %s, so it is not upgraded",
+					app.Name, current.GetVersion(), app.Catalog, catalogApp.GetVersion(), app.Version))
+				break
+			}
+			change.Action = changeUpgrade
+			change.Diffs = append(change.Diffs, ValueDiff{Key: "version", From: current.GetVersion(), To: app.Version})
+		}
+
+		liveParams := map[string]string{}
+		for _, param := range current.ProjectAppParams {
+			liveParams[param.GetKey()] = param.GetValue()
+		}
+		// A live value the spec leaves out goes back to the catalog default on reinstall, so the
+		// keys of both sides are compared
+		defaults := state.defaults[current.GetCatalogAppId()]
+		var paramDiffs []ValueDiff
+		keys := map[string]bool{}
+		for key := range app.Parameters {
+			keys[key] = true
+		}
+		for key := range liveParams {
+			keys[key] = true
+		}
+		for key := range keys {
+			want, ok := app.Parameters[key]
+			if !ok {
+				want = defaults[key]
+			}
+			if liveParams[key] != want {
+				paramDiffs = append(paramDiffs, ValueDiff{Key: "parameters." + key, From: liveParams[key], To: want})
+			}
+		}
+		sort.Slice(paramDiffs, func(i, j int) bool { return paramDiffs[i].Key < paramDiffs[j].Key })
+		if len(paramDiffs) > 0 && change.Action == "" {
+			// Parameters of an installed app cannot be edited, so it is installed again
+			change.Action = changeReplace
+		}
+		if change.Action == "" {
+			continue
+		}
+		change.Diffs = append(change.Diffs, paramDiffs...)
+
+		projectAppID := current.GetId()
+		change.Description = fmt.Sprintf("Reinstall app '%s' in namespace '%s' (%s)", app.Name, app.Namespace, describeDiffs(change.Diffs))
+		change.apply = func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+			response, err := uninstallProjectApp(ctx, client, projectAppID)
+			if err != nil {
+				return response, err
+			}
+			if _, ok := errorEnvelope(response); ok {
+				return response, nil
+			}
+			return install.apply(ctx)
+		}
+		reinstalls = append(reinstalls, change)
+	}
+
+	var unusedApps []string
+	for key := range live {
+		if !wanted[key] {
+			unusedApps = append(unusedApps, key)
+		}
+	}
+	sort.Strings(unusedApps)
+	for _, key := range unusedApps {
+		app := live[key]
+		projectAppID := app.GetId()
+		uninstalls = append(uninstalls, SpecChange{
+			Action:      changeUninstall,
+			Resource:    "app " + key,
+			Description: fmt.Sprintf("Uninstall app '%s' from namespace '%s'", app.GetName(), app.GetNamespace()),
+			Destructive: true,
+			request:     dryRunPost(fmt.Sprintf("/api/v1/projectapp/uninstall/%d", projectAppID), nil),
+			apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+				return uninstallProjectApp(ctx, client, projectAppID)
+			},
+		})
+	}
+
+	for _, changes := range [][]SpecChange{uninstalls, reinstalls, installs} {
+		for _, change := range changes {
+			plan.add(change)
+		}
+	}
+
+	// Catalogs are unbound last, once no app of the spec needs them
+	var unbind []string
+	for name := range bound {
+		if !slices.Contains(spec.Catalogs, name) {
+			unbind = append(unbind, name)
+		}
+	}
+	sort.Strings(unbind)
+	for _, name := range unbind {
+		catalog := bound[name]
+		catalogID := catalog.GetId()
+		plan.add(SpecChange{
+			Action:      changeUnbind,
+			Resource:    "catalog " + name,
+			Description: fmt.Sprintf("Unbind catalog '%s' from the project", name),
+			request:     catalogProjectsRequest(http.MethodPut, catalogID, []int32{plan.projectID}),
+			apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+				return unbindProjectsFromCatalog(ctx, client, UnbindProjectsFromCatalogArgs{CatalogID: catalogID, ProjectIDs: []int32{plan.projectID}})
+			},
+		})
+	}
+	return nil
+}
+
+func installAppChange(client *taikungoclient.Client, plan *specPlan, app AppSpec, catalogAppID int32) SpecChange {
+	args := InstallAppArgs{
+		Name:         app.Name,
+		Namespace:    app.Namespace,
+		CatalogAppID: catalogAppID,
+		AutoSync:     app.AutoSync,
+		WaitForReady: true,
+		WaitTimeout:  600,
+	}
+	keys := make([]string, 0, len(app.Parameters))
+	for key := range app.Parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args.Parameters = append(args.Parameters, AppParameter{Key: key, Value: app.Parameters[key]})
+	}
+
+	command := taikuncore.NewCreateProjectAppCommand()
+	command.SetName(args.Name)
+	command.SetNamespace(args.Namespace)
+	command.SetProjectId(plan.projectID)
+	command.SetCatalogAppId(catalogAppID)
+	command.SetAutoSync(args.AutoSync)
+
+	var diffs []ValueDiff
+	for _, param := range args.Parameters {
+		diffs = append(diffs, ValueDiff{Key: "parameters." + param.Key, To: param.Value})
+	}
+
+	return SpecChange{
+		Action:      changeInstall,
+		Resource:    fmt.Sprintf("app %s/%s", app.Namespace, app.Name),
+		Description: fmt.Sprintf("Install %s from catalog '%s' as '%s' in namespace '%s'", app.Package, app.Catalog, app.Name, app.Namespace),
+		Diffs:       diffs,
+		request:     dryRunPost("/api/v1/projectapp/install", command),
+		apply: func(ctx context.Context) (*mcp_golang.ToolResponse, error) {
+			args.ProjectID = plan.projectID
+			return installApp(ctx, client, args)
+		},
+	}
+}
+
+func describeDiffs(diffs []ValueDiff) string {
+	parts := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		parts = append(parts, fmt.Sprintf("%s: %q -> %q", diff.Key, diff.From, diff.To))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// removeProjectServers deletes servers once apply-project-spec has been confirmed
+func removeProjectServers(ctx context.Context, client *taikungoclient.Client, projectID int32, serverIDs []int32) (*mcp_golang.ToolResponse, error) {
+	command := taikuncore.NewProjectDeploymentDeleteServersCommand()
+	command.SetProjectId(projectID)
+	command.SetServerIds(serverIDs)
+	httpResponse, err := client.Client.ProjectDeploymentAPI.ProjectDeploymentDelete(ctx).
+		ProjectDeploymentDeleteServersCommand(*command).
+		Execute()
+	if err != nil {
+		return createError(httpResponse, err), nil
+	}
+	if errorResp := checkResponse(httpResponse, "delete servers from project"); errorResp != nil {
+		return errorResp, nil
+	}
+	return createJSONResponse(SuccessResponse{
+		Message: fmt.Sprintf("Successfully deleted %d server(s) from project %d", len(serverIDs), projectID),
+		Success: true,
+	}), nil
+}
+
+// uninstallProjectApp uninstalls an application once apply-project-spec has been confirmed and
+// waits until it is gone, so it can be installed again under the same name
+func uninstallProjectApp(ctx context.Context, client *taikungoclient.Client, projectAppID int32) (*mcp_golang.ToolResponse, error) {
+	_, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappDelete(ctx, projectAppID).Execute()
+	if err != nil {
+		return createError(httpResponse, err), nil
+	}
+	if errorResp := checkResponse(httpResponse, "uninstall application"); errorResp != nil {
+		return errorResp, nil
+	}
+	if err := waitForAppReady(ctx, client, projectAppID, 300, true); err != nil {
+		return createJSONResponse(errorResponseFromError(err, fmt.Sprintf("Application %d uninstall initiated but failed during wait: %v", projectAppID, err))), nil
+	}
+	return createJSONResponse(SuccessResponse{
+		Message: fmt.Sprintf("Application ID %d uninstalled", projectAppID),
+		Success: true,
+	}), nil
+}
+
+// resolveSpecPlan parses a spec, loads the project it targets and plans the changes
+func resolveSpecPlan(ctx context.Context, client *taikungoclient.Client, text string, projectID, timeout int32) (*specPlan, *mcp_golang.ToolResponse) {
+	spec, pools, err := parseProjectSpec(text)
+	if err != nil {
+		return nil, createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid project spec: %v", err), Code: errorCodeValidation})
+	}
+
+	if projectID == 0 {
+		var errorResp *mcp_golang.ToolResponse
+		if projectID, errorResp = findProjectByName(ctx, client, spec.Name); errorResp != nil {
+			return nil, errorResp
+		}
+	}
+
+	var state *projectState
+	var catalogs []taikuncore.CatalogListDto
+	var errorResp *mcp_golang.ToolResponse
+	if projectID != 0 {
+		state, errorResp = loadProjectState(ctx, client, projectID)
+		if state != nil {
+			catalogs = state.catalogs
+		}
+	} else {
+		catalogs, errorResp = loadCatalogs(ctx, client)
+	}
+	if errorResp != nil {
+		return nil, errorResp
+	}
+
+	plan, err := planProjectSpec(client, spec, pools, state, catalogs, timeout)
+	if err != nil {
+		return nil, createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid project spec: %v", err), Code: errorCodeValidation})
+	}
+	return plan, nil
+}
+
+// changeSymbol marks each change of a rendered plan the way Terraform does
+func changeSymbol(action string) string {
+	switch action {
+	case changeCreate, changeBind, changeAdd, changeInstall:
+		return "+"
+	case changeRemove, changeUninstall, changeUnbind:
+		return "-"
+	case changeUpgrade, changeReplace:
+		return "-/+"
+	default:
+		return "~"
+	}
+}
+
+func (p *specPlan) summary() PlanSummary {
+	var summary PlanSummary
+	for _, change := range p.changes {
+		switch changeSymbol(change.Action) {
+		case "+":
+			summary.Add++
+		case "-":
+			summary.Destroy++
+		case "-/+":
+			summary.Add++
+			summary.Destroy++
+		default:
+			summary.Change++
+		}
+	}
+	return summary
+}
+
+// render formats the plan as text, one change per line followed by the values it sets
+func (p *specPlan) render() string {
+	var b strings.Builder
+	if p.projectID != 0 {
+		fmt.Fprintf(&b, "Plan for project '%s' (ID %d):\n\n", p.projectName, p.projectID)
+	} else {
+		fmt.Fprintf(&b, "Plan for new project '%s':\n\n", p.projectName)
+	}
+	for _, change := range p.changes {
+		fmt.Fprintf(&b, "%3s %s: %s\n", changeSymbol(change.Action), change.Resource, change.Description)
+		for _, diff := range change.Diffs {
+			if diff.From == "" {
+				fmt.Fprintf(&b, "      %s: %q\n", diff.Key, diff.To)
+			} else {
+				fmt.Fprintf(&b, "      %s: %q -> %q\n", diff.Key, diff.From, diff.To)
+			}
+		}
+	}
+	if len(p.changes) == 0 {
+		b.WriteString("No changes. The project matches the spec.\n")
+	}
+	for _, warning := range p.warnings {
+		fmt.Fprintf(&b, "\nWarning: %s", warning)
+	}
+	if len(p.warnings) > 0 {
+		b.WriteString("\n")
+	}
+	summary := p.summary()
+	fmt.Fprintf(&b, "\nPlan: %d to add, %d to change, %d to destroy.\n", summary.Add, summary.Change, summary.Destroy)
+	return b.String()
+}
+
+func planProjectChanges(ctx context.Context, client *taikungoclient.Client, args PlanProjectChangesArgs) (*mcp_golang.ToolResponse, error) {
+	plan, errorResp := resolveSpecPlan(ctx, client, args.Spec, args.ProjectID, 0)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	summary := plan.summary()
+	message := fmt.Sprintf("%d change(s): %d to add, %d to change, %d to destroy. Nothing was changed; run apply-project-spec with the same spec to apply them.",
+		len(plan.changes), summary.Add, summary.Change, summary.Destroy)
+	if len(plan.changes) == 0 {
+		message = fmt.Sprintf("Project '%s' matches the spec", plan.projectName)
+	}
+	return createJSONResponse(PlanProjectChangesResponse{
+		ProjectID: plan.projectID,
+		Name:      plan.projectName,
+		Summary:   summary,
+		Changes:   append([]SpecChange{}, plan.changes...),
+		Warnings:  plan.warnings,
+		Plan:      plan.render(),
+		Message:   message,
+	}), nil
+}
+
+func applyProjectSpec(ctx context.Context, client *taikungoclient.Client, args ApplyProjectSpecArgs) (*mcp_golang.ToolResponse, error) {
+	timeout := args.Timeout
+	if timeout <= 0 {
+		timeout = 1800 // Deployments take up to 30 minutes
+	}
+	plan, errorResp := resolveSpecPlan(ctx, client, args.Spec, args.ProjectID, timeout)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	var tools []string
+	for _, change := range plan.changes {
+		for _, tool := range change.tools() {
+			if !slices.Contains(tools, tool) {
+				tools = append(tools, tool)
+			}
+		}
+	}
+	if errorResp := requireTools("apply-project-spec", tools...); errorResp != nil {
+		return errorResp, nil
+	}
+
+	// The whole spec is checked against the budget before the first change, together with the
+	// servers of the project that are still waiting for a commit
+	var budgetCheck string
+	if len(plan.changes) > 0 {
+		if plan.projectID == 0 {
+			budgetCheck, errorResp = checkNewProjectBudget(ctx, client, plan.projectName, plan.cloudCredentialID, plan.newServers)
+		} else {
+			budgetCheck, errorResp = checkBudget(ctx, client, budgetRequest{
+				action:    fmt.Sprintf("apply the spec of project '%s'", plan.projectName),
+				projectID: plan.projectID,
+				servers:   plan.newServers,
+			})
+		}
+		if errorResp != nil {
+			return errorResp, nil
+		}
+	}
+
+	if isDryRun(args.DryRun) {
+		checks := make([]string, 0, len(plan.changes)+len(plan.warnings)+1)
+		requests := make([]DryRunRequest, 0, len(plan.changes))
+		for _, change := range plan.changes {
+			checks = append(checks, change.Description)
+			requests = append(requests, change.request)
+		}
+		checks = append(checks, plan.warnings...)
+		if budgetCheck != "" {
+			checks = append(checks, budgetCheck)
+		}
+		return createDryRunResponse(fmt.Sprintf("would apply %d change(s) to project '%s'", len(plan.changes), plan.projectName), checks, requests...), nil
+	}
+
+	if len(plan.changes) == 0 {
+		return createJSONResponse(ApplyProjectSpecResponse{
+			ProjectID: plan.projectID,
+			Name:      plan.projectName,
+			Success:   true,
+			Message:   fmt.Sprintf("Project '%s' already matches the spec", plan.projectName),
+			Steps:     []ProvisionStep{},
+			Warnings:  plan.warnings,
+		}), nil
+	}
+
+	if plan.destructive() {
+		action := fmt.Sprintf("apply-project-spec:%d:%s", plan.projectID, plan.fingerprint())
+		if confirmation := confirmDestructive(ctx, action, args.ConfirmationToken, "apply-project-spec", func() (*DestructionSummary, *mcp_golang.ToolResponse) {
+			summary, errorResp := summarizeProject(ctx, client, plan.projectID)
+			if summary == nil {
+				return nil, errorResp
+			}
+			var apps []string
+			for _, change := range plan.changes {
+				if change.Action == changeUninstall || change.Action == changeUpgrade || change.Action == changeReplace {
+					apps = append(apps, change.Description)
+				}
+			}
+			summary.Removes = fmt.Sprintf("%d server(s) and %d application(s) of project '%s' that the spec drops or reinstalls: %s",
+				len(plan.removedServers), len(apps), summary.ProjectName, strings.Join(apps, "; "))
+			summary.Servers = plan.removedServers
+			summary.ServerCount = int32(len(plan.removedServers))
+			return summary, nil
+		}); confirmation != nil {
+			return confirmation, nil
+		}
+	}
+
+	ctx = withBudgetChecked(ctx)
+	logger.InfoContext(ctx, "Applying project spec", "projectId", plan.projectID, "name", plan.projectName, "changes", len(plan.changes))
+	run := &provisionRun{
+		response: ProvisionClusterResponse{Name: plan.projectName, ProjectID: plan.projectID},
+		total:    float64(len(plan.changes)),
+	}
+	response := ApplyProjectSpecResponse{Name: plan.projectName, Warnings: plan.warnings}
+	for _, change := range plan.changes {
+		_, ok := run.step(ctx, change.Action+" "+change.Resource, func() (*mcp_golang.ToolResponse, error) {
+			return change.apply(ctx)
+		})
+		run.response.ProjectID = plan.projectID
+		if !ok {
+			response.ProjectID = plan.projectID
+			response.Steps = run.response.Steps
+			response.Error = run.failure.Error
+			response.Code = run.failure.Code
+			response.Retryable = run.failure.Retryable
+			logger.WarnContext(ctx, "Applying project spec failed", "projectId", plan.projectID, "error", response.Error)
+			return createJSONResponse(response), nil
+		}
+	}
+
+	response.ProjectID = plan.projectID
+	response.Steps = run.response.Steps
+	response.Success = true
+	response.Message = fmt.Sprintf("Applied %d change(s); project '%s' matches the spec", len(plan.changes), plan.projectName)
+	return createJSONResponse(response), nil
+}
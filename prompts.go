@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// MCP prompt arguments are always strings, so numbers are parsed by the prompt handlers.
+// mcp-golang names prompt arguments after the struct fields, and JSON matching is case
+// insensitive, so clients can send either form.
+
+type ProvisionClusterPromptArgs struct {
+	ProjectName       string `json:"projectName" jsonschema:"required,description=Name of the project to create (3-30 alphanumeric characters or hyphens)"`
+	CloudCredentialId string `json:"cloudCredentialId" jsonschema:"description=ID of the cloud credential to use (optional; chosen from list-cloud-credentials otherwise)"`
+	Flavor            string `json:"flavor" jsonschema:"description=Flavor for the master and worker servers (optional; chosen from list-flavors otherwise)"`
+	WorkerCount       string `json:"workerCount" jsonschema:"description=Number of worker servers (default: 2)"`
+	KubernetesVersion string `json:"kubernetesVersion" jsonschema:"description=Kubernetes version to install (optional)"`
+}
+
+type TriageProjectPromptArgs struct {
+	ProjectId string `json:"projectId" jsonschema:"required,description=ID of the unhealthy project"`
+}
+
+type OnboardAppPromptArgs struct {
+	PackageName string `json:"packageName" jsonschema:"required,description=Name of the package to add such as nginx"`
+	Repository  string `json:"repository" jsonschema:"description=Repository of the package (optional; looked up with available-apps-list otherwise)"`
+	CatalogName string `json:"catalogName" jsonschema:"required,description=Catalog to add the application to; created if it does not exist"`
+	ProjectId   string `json:"projectId" jsonschema:"description=Project to install the application in once it is in the catalog (optional)"`
+	Namespace   string `json:"namespace" jsonschema:"description=Namespace to install the application in (default: the package name)"`
+}
+
+// workflowPrompt is an MCP prompt that walks an agent through a platform workflow. It is only
+// offered while every tool in tools is available; optional tools only change the wording.
+type workflowPrompt struct {
+	name        string
+	description string
+	tools       []string
+	handler     any
+}
+
+var workflowPrompts = []workflowPrompt{
+	{
+		name:        "provision-cluster",
+		description: "Step-by-step plan to create a project, add servers and deploy a Kubernetes cluster",
+		tools:       []string{"list-cloud-credentials", "list-flavors", "create-project", "bind-flavors-to-project", "add-server-to-project", "commit-project", "get-kubeconfig"},
+		handler:     provisionClusterPrompt,
+	},
+	{
+		name:        "triage-unhealthy-project",
+		description: "Read-only investigation of why a project is unhealthy, from servers down to pods and apps",
+		tools:       []string{"get-project-details", "list-servers", "list-kubernetes-resources", "describe-kubernetes-resource", "list-apps", "get-app"},
+		handler:     triageProjectPrompt,
+	},
+	{
+		name:        "onboard-app-to-catalog",
+		description: "Add a package to a catalog, review its default parameters and optionally install it",
+		tools:       []string{"catalog-list", "catalog-create", "available-apps-list", "catalog-app-add", "catalog-apps-list", "catalog-app-params", "catalog-app-defaults-set", "app-install", "get-app"},
+		handler:     onboardAppPrompt,
+	},
+}
+
+// registerPrompts registers the workflow prompts whose tools are all available
+func registerPrompts(server *mcp_golang.Server) error {
+	for _, prompt := range workflowPrompts {
+		if missing := unavailableTools(prompt.tools); len(missing) > 0 {
+			logger.Debug("Skipped prompt with unavailable tools", "prompt", prompt.name, "tools", missing)
+			continue
+		}
+		if err := server.RegisterPrompt(prompt.name, prompt.description, prompt.handler); err != nil {
+			return fmt.Errorf("failed to register prompt %s: %w", prompt.name, err)
+		}
+		logger.Debug("Registered prompt", "prompt", prompt.name)
+	}
+	return nil
+}
+
+func unavailableTools(names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if !toolAvailable(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func provisionClusterPrompt(args ProvisionClusterPromptArgs) (*mcp_golang.PromptResponse, error) {
+	if args.ProjectName == "" {
+		return nil, fmt.Errorf("projectName is required")
+	}
+	if err := requireNumber("cloudCredentialId", args.CloudCredentialId, false); err != nil {
+		return nil, err
+	}
+	workers := 2
+	if args.WorkerCount != "" {
+		count, err := strconv.Atoi(args.WorkerCount)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("workerCount must be a positive number, got %q", args.WorkerCount)
+		}
+		workers = count
+	}
+
+	var steps promptSteps
+	if args.CloudCredentialId != "" {
+		steps.add("Use cloud credential %s. Call `list-cloud-credentials` to confirm it exists and is not locked.", args.CloudCredentialId)
+	} else {
+		steps.add("Call `list-cloud-credentials` and pick the credential for the target cloud. If there is more than one candidate, ask me which one to use.")
+	}
+	if args.Flavor != "" {
+		steps.add("Call `list-flavors` with the cloudCredentialId and check that flavor `%s` is offered.", args.Flavor)
+	} else if toolAvailable("recommend-flavors") {
+		steps.add("Call `recommend-flavors` with the cloudCredentialId for role `Bastion` and again for role `Kubeworker`, and pick the top-ranked flavor for the bastion and one for the master and workers.")
+	} else {
+		steps.add("Call `list-flavors` with the cloudCredentialId and pick one flavor for the bastion and one with at least 2 CPUs and 4 GB of RAM for the master and workers.")
+	}
+	createProject := fmt.Sprintf("Call `create-project` with name `%s` and the cloudCredentialId", args.ProjectName)
+	if args.KubernetesVersion != "" {
+		createProject += fmt.Sprintf(" and kubernetesVersion `%s`", args.KubernetesVersion)
+	}
+	steps.add("%s. Keep the project ID it returns for every later step.", createProject)
+	steps.add("Call `bind-flavors-to-project` with the project ID and every flavor you picked. Servers can only use flavors bound to their project.")
+	steps.add("Call `add-server-to-project` once per role, in this order: role `Bastion` with count 1, role `Kubemaster` with count 1, role `Kubeworker` with count %d. Check that each call reports its servers as verified before the next one.", workers)
+	steps.add("Call `commit-project` with the project ID and waitForReady true. Deployment takes 10 to 30 minutes; with async true instead, poll `job-status` until the job finishes.")
+	steps.add("Once the project is ready and healthy, call `get-kubeconfig` with the project ID.")
+
+	text := fmt.Sprintf("Provision a Kubernetes cluster named `%s` in Cloudera Cloud Factory. Follow these steps in order and stop to report the error if any step fails.\n\n%s\n"+
+		"You may call `create-project` with dryRun true first to validate the name and credential. Finish with the project ID, its servers and its status.",
+		args.ProjectName, steps)
+	return mcp_golang.NewPromptResponse("Provision a Kubernetes cluster", mcp_golang.NewPromptMessage(mcp_golang.NewTextContent(text), mcp_golang.RoleUser)), nil
+}
+
+func triageProjectPrompt(args TriageProjectPromptArgs) (*mcp_golang.PromptResponse, error) {
+	if err := requireNumber("projectId", args.ProjectId, true); err != nil {
+		return nil, err
+	}
+
+	var steps promptSteps
+	steps.add("Call `get-project-details` with projectId %s and note its status and health.", args.ProjectId)
+	steps.add("Call `list-servers` with projectId %s. Servers that are not `Ready` (for example `Failed`, `Pending` or `Deleting`) usually explain an unhealthy project.", args.ProjectId)
+	steps.add("Call `list-kubernetes-resources` with projectId %s and kind `Nodes`. Look for nodes that are not ready or report memory, disk or PID pressure.", args.ProjectId)
+	steps.add("Call `list-kubernetes-resources` with projectId %s and kind `Pods`. Look for pods that are `Pending`, in `CrashLoopBackOff` or restarting often, then call `describe-kubernetes-resource` on the worst few to read their events.", args.ProjectId)
+	steps.add("Call `list-apps` with projectId %s and `get-app` for every application that is not `Ready`.", args.ProjectId)
+
+	text := fmt.Sprintf("Project %s in Cloudera Cloud Factory is unhealthy. Find out why. Only read: do not call any tool that changes the project, its servers or its applications.\n\n%s\n"+
+		"Finish with the most likely root cause, the evidence for it, and the fix you recommend, including which tools would apply it.",
+		args.ProjectId, steps)
+	return mcp_golang.NewPromptResponse("Triage an unhealthy project", mcp_golang.NewPromptMessage(mcp_golang.NewTextContent(text), mcp_golang.RoleUser)), nil
+}
+
+func onboardAppPrompt(args OnboardAppPromptArgs) (*mcp_golang.PromptResponse, error) {
+	if args.PackageName == "" || args.CatalogName == "" {
+		return nil, fmt.Errorf("packageName and catalogName are required")
+	}
+	if err := requireNumber("projectId", args.ProjectId, false); err != nil {
+		return nil, err
+	}
+	namespace := args.Namespace
+	if namespace == "" {
+		namespace = args.PackageName
+	}
+
+	var steps promptSteps
+	steps.add("Call `catalog-list` with search `%s`. If the catalog does not exist, call `catalog-create` with that name and a short description. Keep the catalog ID.", args.CatalogName)
+	if args.Repository != "" {
+		steps.add("Call `available-apps-list` with repository `%s` and search `%s` to confirm the package exists there.", args.Repository, args.PackageName)
+	} else {
+		steps.add("Call `available-apps-list` with search `%s` to find the repository that provides the package. If several do, ask me which one to use.", args.PackageName)
+	}
+	steps.add("Call `catalog-app-add` with the catalog ID, the repository and packageName `%s`. Skip this if the catalog already contains the package.", args.PackageName)
+	steps.add("Call `catalog-apps-list` with the catalog ID to get the catalogAppId of `%s`.", args.PackageName)
+	steps.add("Call `catalog-app-params` with the catalogAppId and review the parameters. Only if a default has to change, call `catalog-app-defaults-set`; say which values you change and why.")
+	if args.ProjectId != "" {
+		steps.add("Check in the `catalog-list` result that project %s is among the catalog's bound projects; applications can only be installed from catalogs bound to their project.", args.ProjectId)
+		steps.add("Call `app-install` with name `%s`, namespace `%s`, projectId %s, the catalogAppId and waitForReady true.", args.PackageName, namespace, args.ProjectId)
+		steps.add("Call `get-app` with the projectAppId it returns and confirm the application is `Ready`.")
+	}
+
+	text := fmt.Sprintf("Onboard the package `%s` to the catalog `%s` in Cloudera Cloud Factory. Follow these steps in order and stop to report the error if any step fails.\n\n%s\n"+
+		"Finish with the catalog ID, the catalogAppId and any parameters you changed.",
+		args.PackageName, args.CatalogName, steps)
+	return mcp_golang.NewPromptResponse("Onboard an application to a catalog", mcp_golang.NewPromptMessage(mcp_golang.NewTextContent(text), mcp_golang.RoleUser)), nil
+}
+
+// promptSteps renders a numbered list of instructions
+type promptSteps []string
+
+func (s *promptSteps) add(format string, args ...interface{}) {
+	*s = append(*s, fmt.Sprintf(format, args...))
+}
+
+func (s promptSteps) String() string {
+	var b strings.Builder
+	for i, step := range s {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+	}
+	return b.String()
+}
+
+// requireNumber checks that a prompt argument holds an ID
+func requireNumber(name, value string, required bool) error {
+	if value == "" {
+		if required {
+			return fmt.Errorf("%s is required", name)
+		}
+		return nil
+	}
+	if _, err := strconv.ParseInt(value, 10, 32); err != nil {
+		return fmt.Errorf("%s must be a number, got %q", name, value)
+	}
+	return nil
+}
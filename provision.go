@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// rollbackTimeout bounds the project deletion that undoes a failed provisioning, which runs even
+// after the call itself was cancelled
+const rollbackTimeout = 2 * time.Minute
+
+type NodePoolSpec struct {
+	Name     string `json:"name,omitempty" jsonschema:"description=Server name or name prefix (default: bastion/master/worker after the role)"`
+	Role     string `json:"role" jsonschema:"required,description=Role of the servers: Bastion or Kubemaster or Kubeworker"`
+	Flavor   string `json:"flavor" jsonschema:"required,description=Flavor name for the servers"`
+	Count    int32  `json:"count,omitempty" jsonschema:"description=Number of servers (default: 1)"`
+	DiskSize int64  `json:"diskSize,omitempty" jsonschema:"description=Disk size in GB (optional)"`
+}
+
+type ProvisionClusterArgs struct {
+	Name                string         `json:"name" jsonschema:"required,description=Project name (3-30 alphanumeric characters or hyphens)"`
+	CloudCredentialID   int32          `json:"cloudCredentialId" jsonschema:"required,description=ID of the cloud credential to use for the project"`
+	KubernetesVersion   string         `json:"kubernetesVersion,omitempty" jsonschema:"description=Kubernetes version to install (optional)"`
+	KubernetesProfileID int32          `json:"kubernetesProfileId,omitempty" jsonschema:"description=ID of the Kubernetes profile to use (optional)"`
+	Monitoring          bool           `json:"monitoring,omitempty" jsonschema:"description=Enable monitoring for the project (default: false)"`
+	NodePools           []NodePoolSpec `json:"nodePools" jsonschema:"required,description=Node pools of the cluster: exactly one Bastion server and at least one Kubemaster and one Kubeworker"`
+	Timeout             int32          `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds when waiting for the deployment (default: 1800)"`
+	KeepOnFailure       bool           `json:"keepOnFailure,omitempty" jsonschema:"description=Keep the project when a step fails instead of deleting it so it can be inspected (default: false)"`
+	DryRun              bool           `json:"dryRun,omitempty" jsonschema:"description=Validate the spec and return the API requests that would be sent without executing them (default: false)"`
+	Async               bool           `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
+}
+
+func (a ProvisionClusterArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
+// Provisioning step statuses
+const (
+	stepSucceeded = "succeeded"
+	stepFailed    = "failed"
+	stepSkipped   = "skipped"
+)
+
+type ProvisionStep struct {
+	Step     string          `json:"step"`
+	Status   string          `json:"status"`
+	Duration string          `json:"duration,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+}
+
+// ProvisionClusterResponse reports the steps of a provisioning and, when one fails, its error
+type ProvisionClusterResponse struct {
+	ProjectID  int32           `json:"projectId,omitempty"`
+	Name       string          `json:"name"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Code       string          `json:"code,omitempty"`
+	Retryable  bool            `json:"retryable,omitempty"`
+	Steps      []ProvisionStep `json:"steps"`
+	RolledBack bool            `json:"rolledBack"`
+	Rollback   *ProvisionStep  `json:"rollback,omitempty"`
+}
+
+// provisionRoleOrder is the order in which node pools are added; the bastion has to exist before
+// the cluster nodes
+var provisionRoleOrder = map[taikuncore.CloudRole]int{
+	taikuncore.CLOUDROLE_BASTION:    0,
+	taikuncore.CLOUDROLE_KUBEMASTER: 1,
+	taikuncore.CLOUDROLE_KUBEWORKER: 2,
+}
+
+var provisionDefaultNames = map[taikuncore.CloudRole]string{
+	taikuncore.CLOUDROLE_BASTION:    "bastion",
+	taikuncore.CLOUDROLE_KUBEMASTER: "master",
+	taikuncore.CLOUDROLE_KUBEWORKER: "worker",
+}
+
+// validateProvisionSpec checks the spec and returns its node pools in the order they are added
+func validateProvisionSpec(args ProvisionClusterArgs) ([]NodePoolSpec, error) {
+	if args.Name == "" {
+		return nil, fmt.Errorf("project name is required")
+	}
+	if args.CloudCredentialID <= 0 {
+		return nil, fmt.Errorf("cloudCredentialId is required")
+	}
+	return validateNodePools(args.NodePools)
+}
+
+// validateNodePools checks the node pools of a cluster and returns them with defaults applied,
+// in the order they are added
+func validateNodePools(nodePools []NodePoolSpec) ([]NodePoolSpec, error) {
+	if len(nodePools) == 0 {
+		return nil, fmt.Errorf("at least one node pool is required")
+	}
+
+	counts := map[taikuncore.CloudRole]int32{}
+	pools := make([]NodePoolSpec, 0, len(nodePools))
+	for i, pool := range nodePools {
+		role, err := taikuncore.NewCloudRoleFromValue(pool.Role)
+		if err != nil {
+			return nil, fmt.Errorf("node pool %d: invalid role %q", i+1, pool.Role)
+		}
+		if _, ok := provisionRoleOrder[*role]; !ok {
+			return nil, fmt.Errorf("node pool %d: role %s is not supported; use Bastion, Kubemaster or Kubeworker", i+1, pool.Role)
+		}
+		if pool.Flavor == "" {
+			return nil, fmt.Errorf("node pool %d: flavor is required", i+1)
+		}
+		if pool.Count < 0 || pool.DiskSize < 0 {
+			return nil, fmt.Errorf("node pool %d: count and diskSize cannot be negative", i+1)
+		}
+		if pool.Count == 0 {
+			pool.Count = 1
+		}
+		if pool.Name == "" {
+			pool.Name = provisionDefaultNames[*role]
+		}
+		pool.Role = string(*role)
+		counts[*role] += pool.Count
+		pools = append(pools, pool)
+	}
+
+	if counts[taikuncore.CLOUDROLE_BASTION] != 1 {
+		return nil, fmt.Errorf("exactly one Bastion server is required, got %d", counts[taikuncore.CLOUDROLE_BASTION])
+	}
+	if counts[taikuncore.CLOUDROLE_KUBEMASTER] < 1 || counts[taikuncore.CLOUDROLE_KUBEWORKER] < 1 {
+		return nil, fmt.Errorf("at least one Kubemaster and one Kubeworker server are required")
+	}
+
+	sort.SliceStable(pools, func(i, j int) bool {
+		return provisionRoleOrder[taikuncore.CloudRole(pools[i].Role)] < provisionRoleOrder[taikuncore.CloudRole(pools[j].Role)]
+	})
+	return pools, nil
+}
+
+// provisionFlavors returns the distinct flavors of the node pools
+func provisionFlavors(pools []NodePoolSpec) []string {
+	var flavors []string
+	seen := map[string]bool{}
+	for _, pool := range pools {
+		if !seen[pool.Flavor] {
+			seen[pool.Flavor] = true
+			flavors = append(flavors, pool.Flavor)
+		}
+	}
+	return flavors
+}
+
+func provisionCluster(ctx context.Context, client *taikungoclient.Client, args ProvisionClusterArgs) (*mcp_golang.ToolResponse, error) {
+	pools, err := validateProvisionSpec(args)
+	if err != nil {
+		return createJSONResponse(ErrorResponse{Error: fmt.Sprintf("Invalid cluster spec: %v", err), Code: errorCodeValidation}), nil
+	}
+	flavors := provisionFlavors(pools)
+
+	steps := []string{"create-project", "bind-flavors-to-project", "add-server-to-project", "commit-project"}
+	if !args.KeepOnFailure {
+		steps = append(steps, "delete-project")
+	}
+	if errorResp := requireTools("provision-cluster", steps...); errorResp != nil {
+		return errorResp, nil
+	}
+
+	// The whole spec is checked against the budget policy before anything is created
+	servers := map[string]int32{}
+	for _, pool := range pools {
+		servers[pool.Flavor] += pool.Count
+	}
+	budgetCheck, errorResp := checkNewProjectBudget(ctx, client, args.Name, args.CloudCredentialID, servers)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	if isDryRun(args.DryRun) {
+		return provisionClusterDryRun(ctx, client, args, pools, flavors, budgetCheck)
+	}
+	ctx = withBudgetChecked(ctx)
+
+	timeout := args.Timeout
+	if timeout <= 0 {
+		timeout = 1800 // Deployments take up to 30 minutes
+	}
+
+	run := &provisionRun{
+		response: ProvisionClusterResponse{Name: args.Name},
+		total:    float64(len(pools) + 3),
+	}
+
+	logger.InfoContext(ctx, "Provisioning cluster", "name", args.Name, "cloudCredentialId", args.CloudCredentialID, "nodePools", len(pools))
+
+	createResponse, ok := run.step(ctx, "create-project", func() (*mcp_golang.ToolResponse, error) {
+		return createProject(ctx, client, CreateProjectArgs{
+			Name:                args.Name,
+			CloudCredentialID:   args.CloudCredentialID,
+			KubernetesProfileID: args.KubernetesProfileID,
+			Monitoring:          args.Monitoring,
+			KubernetesVersion:   args.KubernetesVersion,
+		})
+	})
+	if !ok {
+		return run.fail(ctx, client, args)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(createResponse.Content[0].TextContent.Text), &created); err == nil {
+		if id, err := strconv.ParseInt(created.ID, 10, 32); err == nil {
+			run.response.ProjectID = int32(id)
+		}
+	}
+	if run.response.ProjectID == 0 {
+		run.failure = ErrorResponse{Error: fmt.Sprintf("Project '%s' was created but its ID was not returned", args.Name), Code: errorCodeInternal}
+		return run.fail(ctx, client, args)
+	}
+	projectID := run.response.ProjectID
+
+	if _, ok := run.step(ctx, "bind-flavors-to-project", func() (*mcp_golang.ToolResponse, error) {
+		return bindFlavorsToProject(ctx, client, BindFlavorsArgs{ProjectId: projectID, Flavors: flavors})
+	}); !ok {
+		return run.fail(ctx, client, args)
+	}
+
+	for _, pool := range pools {
+		name := fmt.Sprintf("add-server-to-project %s x%d (%s)", pool.Role, pool.Count, pool.Flavor)
+		if _, ok := run.step(ctx, name, func() (*mcp_golang.ToolResponse, error) {
+			return addServerToProject(ctx, client, AddServerArgs{
+				ProjectId: projectID,
+				Name:      pool.Name,
+				Role:      pool.Role,
+				Flavor:    pool.Flavor,
+				DiskSize:  pool.DiskSize,
+				Count:     pool.Count,
+			})
+		}); !ok {
+			return run.fail(ctx, client, args)
+		}
+	}
+
+	if _, ok := run.step(ctx, "commit-project", func() (*mcp_golang.ToolResponse, error) {
+		return commitProject(ctx, client, CommitProjectArgs{ProjectId: projectID, WaitForReady: true, Timeout: timeout})
+	}); !ok {
+		return run.fail(ctx, client, args)
+	}
+
+	run.response.Success = true
+	run.response.Message = fmt.Sprintf("Cluster '%s' is ready and healthy in project %d", args.Name, projectID)
+	logger.InfoContext(ctx, "Cluster provisioned", "projectId", projectID, "name", args.Name)
+	return createJSONResponse(run.response), nil
+}
+
+// provisionRun records the steps of one provisioning and the failure that ended it, if any
+type provisionRun struct {
+	response ProvisionClusterResponse
+	failure  ErrorResponse
+	total    float64
+}
+
+// step runs one provisioning step and records its result. It reports whether the step succeeded;
+// add-server-to-project only succeeds once its servers are verified.
+func (r *provisionRun) step(ctx context.Context, name string, fn func() (*mcp_golang.ToolResponse, error)) (*mcp_golang.ToolResponse, bool) {
+	reportProgress(ctx, float64(len(r.response.Steps)), r.total, fmt.Sprintf("Provisioning '%s': %s", r.response.Name, name))
+	start := time.Now()
+	response, err := fn()
+	step := ProvisionStep{Step: name, Status: stepSucceeded, Duration: time.Since(start).Round(time.Second).String()}
+	if err != nil {
+		response = createJSONResponse(errorResponseFromError(err, fmt.Sprintf("%s failed: %v", name, err)))
+	}
+	if response != nil && len(response.Content) > 0 && response.Content[0].TextContent != nil {
+		step.Result = upstreamBody([]byte(response.Content[0].TextContent.Text))
+	}
+
+	if envelope, ok := errorEnvelope(response); ok {
+		step.Status = stepFailed
+		r.failure = failureFromEnvelope(envelope)
+		r.failure.Error = fmt.Sprintf("%s failed: %s", name, r.failure.Error)
+	} else {
+		var verification struct {
+			Verified *bool  `json:"verified"`
+			Message  string `json:"message"`
+		}
+		if json.Unmarshal(step.Result, &verification) == nil && verification.Verified != nil && !*verification.Verified {
+			step.Status = stepFailed
+			r.failure = ErrorResponse{Error: fmt.Sprintf("%s failed: %s", name, verification.Message), Code: errorCodeTimeout, Retryable: true}
+			if ctx.Err() != nil {
+				r.failure.Code = errorResponseFromError(ctx.Err(), "").Code
+			}
+		}
+	}
+
+	r.response.Steps = append(r.response.Steps, step)
+	logger.InfoContext(ctx, "Provisioning step finished", "step", name, "status", step.Status, "projectId", r.response.ProjectID)
+	return response, step.Status == stepSucceeded
+}
+
+// fail rolls the provisioning back by deleting the project, unless keepOnFailure is set, and
+// returns the failure together with every step
+func (r *provisionRun) fail(ctx context.Context, client *taikungoclient.Client, args ProvisionClusterArgs) (*mcp_golang.ToolResponse, error) {
+	r.response.Error = r.failure.Error
+	r.response.Code = r.failure.Code
+	r.response.Retryable = r.failure.Retryable
+	if r.response.Code == "" {
+		r.response.Code = errorCodeInternal
+	}
+
+	switch {
+	case r.response.ProjectID == 0:
+		// Nothing was created, so there is nothing to roll back
+	case args.KeepOnFailure:
+		r.response.Rollback = &ProvisionStep{
+			Step:   "delete-project",
+			Status: stepSkipped,
+			Result: upstreamBody([]byte(fmt.Sprintf("Project %d was kept because keepOnFailure is set", r.response.ProjectID))),
+		}
+	default:
+		r.response.Rollback = rollbackProject(ctx, client, r.response.ProjectID)
+		r.response.RolledBack = r.response.Rollback.Status == stepSucceeded
+	}
+
+	logger.WarnContext(ctx, "Cluster provisioning failed", "name", args.Name, "projectId", r.response.ProjectID,
+		"error", r.response.Error, "rolledBack", r.response.RolledBack)
+	return createJSONResponse(r.response), nil
+}
+
+// rollbackProject deletes a partially provisioned project along with its servers. It does not
+// ask for confirmation because the project was created by the same call.
+func rollbackProject(ctx context.Context, client *taikungoclient.Client, projectID int32) *ProvisionStep {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	logger.InfoContext(ctx, "Rolling back provisioning", "projectId", projectID)
+	start := time.Now()
+	command := taikuncore.NewDeleteProjectCommand()
+	command.SetProjectId(projectID)
+	httpResponse, err := client.Client.ProjectsAPI.ProjectsDelete(ctx).DeleteProjectCommand(*command).Execute()
+
+	var response *mcp_golang.ToolResponse
+	if err != nil {
+		response = createError(httpResponse, err)
+	} else {
+		response = checkResponse(httpResponse, "delete project")
+	}
+	step := &ProvisionStep{Step: "delete-project", Status: stepSucceeded, Duration: time.Since(start).Round(time.Second).String()}
+	if response != nil {
+		step.Status = stepFailed
+		step.Result = upstreamBody([]byte(response.Content[0].TextContent.Text))
+		logger.ErrorContext(ctx, "Failed to roll back provisioning", "projectId", projectID, "error", response.Content[0].TextContent.Text)
+		return step
+	}
+	step.Result = upstreamBody([]byte(fmt.Sprintf("Project %d deleted", projectID)))
+	return step
+}
+
+func provisionClusterDryRun(ctx context.Context, client *taikungoclient.Client, args ProvisionClusterArgs, pools []NodePoolSpec, flavors []string, budgetCheck string) (*mcp_golang.ToolResponse, error) {
+	credential, errorResp := resolveCloudCredential(ctx, client, args.CloudCredentialID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+	checks := []string{fmt.Sprintf("Cloud credential %d (%s) exists", args.CloudCredentialID, credential.GetFullName())}
+
+	for _, flavor := range flavors {
+		result, httpResponse, err := client.Client.CloudCredentialAPI.CloudcredentialsAllFlavors(ctx, args.CloudCredentialID).Search(flavor).Execute()
+		if err != nil {
+			return createError(httpResponse, err), nil
+		}
+		if errorResp := checkResponse(httpResponse, "list flavors"); errorResp != nil {
+			return errorResp, nil
+		}
+		found := false
+		for _, f := range result.Data {
+			if f.GetName() == flavor {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return createJSONResponse(ErrorResponse{
+				Error: fmt.Sprintf("Flavor '%s' is not offered by cloud credential %d", flavor, args.CloudCredentialID),
+				Code:  errorCodeValidation,
+			}), nil
+		}
+		checks = append(checks, fmt.Sprintf("Flavor '%s' is offered by the cloud credential", flavor))
+	}
+
+	if budgetCheck != "" {
+		checks = append(checks, budgetCheck)
+	}
+
+	createCmd := taikuncore.NewCreateProjectCommand()
+	createCmd.SetName(args.Name)
+	createCmd.SetCloudCredentialId(args.CloudCredentialID)
+	createCmd.SetIsKubernetes(true)
+	if args.KubernetesProfileID != 0 {
+		createCmd.SetKubernetesProfileId(args.KubernetesProfileID)
+	}
+	if args.KubernetesVersion != "" {
+		createCmd.SetKubernetesVersion(args.KubernetesVersion)
+	}
+	createCmd.SetIsMonitoringEnabled(args.Monitoring)
+
+	// The project ID is only known once the project exists, so the later requests show 0
+	bindCmd := taikuncore.NewBindFlavorToProjectCommand()
+	bindCmd.SetFlavors(flavors)
+	requests := []DryRunRequest{
+		dryRunPost("/api/v1/projects", createCmd),
+		dryRunPost("/api/v1/flavors/bind", bindCmd),
+	}
+	var servers []string
+	for _, pool := range pools {
+		serverDto := taikuncore.NewServerForCreateDto()
+		serverDto.SetName(pool.Name)
+		serverDto.SetRole(taikuncore.CloudRole(pool.Role))
+		serverDto.SetFlavor(pool.Flavor)
+		serverDto.SetCount(pool.Count)
+		if pool.DiskSize > 0 {
+			serverDto.SetDiskSize(pool.DiskSize * 1024 * 1024 * 1024)
+		}
+		requests = append(requests, dryRunPost("/api/v1/servers/create", serverDto))
+		servers = append(servers, fmt.Sprintf("%d %s", pool.Count, pool.Role))
+	}
+	requests = append(requests, dryRunPost("/api/v1/project-deployment/commit", taikuncore.NewProjectDeploymentCommitCommand()))
+
+	return createDryRunResponse(
+		fmt.Sprintf("would create project '%s' with %s servers, deploy it and wait for it to be ready", args.Name, strings.Join(servers, ", ")),
+		checks,
+		requests...,
+	), nil
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// resourceWatchInterval is how often subscribed projects are checked for status or health changes
+const resourceWatchInterval = 30 * time.Second
+
+// JSON-RPC error codes used by resource requests
+const (
+	rpcInvalidRequest   = -32600
+	rpcInvalidParams    = -32602
+	rpcInternalError    = -32603
+	rpcResourceNotFound = -32002
+)
+
+// resourceReadName stands in for the tool name in the logs and audit records of resource reads
+const resourceReadName = "resources/read"
+
+// taikunResource is a family of MCP resources identified by a URI template such as
+// taikun://projects/{id}. Each is served by the read tool that returns the same data and is
+// only available while that tool is enabled.
+type taikunResource struct {
+	Template    string
+	Name        string
+	Description string
+	MimeType    string
+	Tool        string
+	// Watched resources belong to a project and can be subscribed to
+	Watched bool
+
+	pattern *regexp.Regexp
+	read    func(ctx context.Context, client *taikungoclient.Client, ids []int32) (*mcp_golang.ToolResponse, error)
+	// verify, when set, checks that the IDs of a URI belong together before it is read or watched
+	verify func(ctx context.Context, client *taikungoclient.Client, ids []int32) *mcp_golang.ToolResponse
+}
+
+var taikunResources = []*taikunResource{
+	{
+		Template:    "taikun://projects/{id}",
+		Name:        "project",
+		Description: "Status, health, servers, apps, profiles and cost of a project",
+		MimeType:    "application/json",
+		Tool:        "get-project-details",
+		Watched:     true,
+		read: func(ctx context.Context, client *taikungoclient.Client, ids []int32) (*mcp_golang.ToolResponse, error) {
+			return getProjectDetails(ctx, client, GetProjectDetailsArgs{ProjectId: ids[0]})
+		},
+	},
+	{
+		Template:    "taikun://projects/{id}/servers",
+		Name:        "project-servers",
+		Description: "Servers of a project with their role, flavor and status",
+		MimeType:    "application/json",
+		Tool:        "list-servers",
+		Watched:     true,
+		read: func(ctx context.Context, client *taikungoclient.Client, ids []int32) (*mcp_golang.ToolResponse, error) {
+			return listServers(ctx, client, ListServersArgs{ProjectId: ids[0]})
+		},
+	},
+	{
+		Template:    "taikun://projects/{id}/apps/{appId}",
+		Name:        "project-app",
+		Description: "An application installed in a project, with its status and parameters",
+		MimeType:    "application/json",
+		Tool:        "get-app",
+		Watched:     true,
+		read: func(ctx context.Context, client *taikungoclient.Client, ids []int32) (*mcp_golang.ToolResponse, error) {
+			return getApp(ctx, client, GetAppArgs{ProjectAppID: ids[1]})
+		},
+		verify: appInProject,
+	},
+	{
+		Template:    "taikun://projects/{id}/kubeconfig",
+		Name:        "project-kubeconfig",
+		Description: "Kubeconfig of a project",
+		MimeType:    "application/yaml",
+		Tool:        "get-kubeconfig",
+		Watched:     true,
+		read: func(ctx context.Context, client *taikungoclient.Client, ids []int32) (*mcp_golang.ToolResponse, error) {
+			response, err := getKubeConfig(ctx, client, GetKubeConfigArgs{ProjectID: ids[0]})
+			if err != nil || response == nil {
+				return response, err
+			}
+			if _, ok := errorEnvelope(response); ok {
+				return response, nil
+			}
+			// Serve the YAML itself rather than the JSON the tool wraps it in
+			var data struct {
+				KubeConfig string `json:"kubeConfig"`
+			}
+			if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &data); err != nil {
+				return nil, err
+			}
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(data.KubeConfig)), nil
+		},
+	},
+	{
+		Template:    "taikun://catalogs/{id}",
+		Name:        "catalog",
+		Description: "A catalog with its applications",
+		MimeType:    "application/json",
+		Tool:        "catalog-list",
+		read: func(ctx context.Context, client *taikungoclient.Client, ids []int32) (*mcp_golang.ToolResponse, error) {
+			return listCatalogs(ctx, client, ListCatalogsArgs{ID: ids[0]})
+		},
+	},
+}
+
+func init() {
+	// Templates only contain letters, digits, ':' and '/', so each placeholder becomes an ID group
+	placeholder := regexp.MustCompile(`\{[A-Za-z]+\}`)
+	for _, resource := range taikunResources {
+		resource.pattern = regexp.MustCompile("^" + placeholder.ReplaceAllString(resource.Template, `(\d+)`) + "$")
+	}
+}
+
+// registerResources lists the resource templates whose backing tool is enabled
+func registerResources(server *mcp_golang.Server) error {
+	for _, resource := range taikunResources {
+		if !toolAvailable(resource.Tool) {
+			logger.Debug("Skipped resource of disabled tool", "resource", resource.Template, "tool", resource.Tool)
+			continue
+		}
+		if err := server.RegisterResourceTemplate(resource.Template, resource.Name, resource.Description, resource.MimeType); err != nil {
+			return err
+		}
+		logger.Debug("Registered resource template", "resource", resource.Template)
+	}
+	return nil
+}
+
+// matchResource finds the enabled resource a URI belongs to and the IDs in it
+func matchResource(uri string) (*taikunResource, []int32, bool) {
+	for _, resource := range taikunResources {
+		match := resource.pattern.FindStringSubmatch(uri)
+		if match == nil || !toolAvailable(resource.Tool) {
+			continue
+		}
+		ids := make([]int32, 0, len(match)-1)
+		for _, value := range match[1:] {
+			id, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, nil, false
+			}
+			ids = append(ids, int32(id))
+		}
+		return resource, ids, true
+	}
+	return nil, nil, false
+}
+
+type ReadResourceArgs struct {
+	URI string `json:"uri"`
+}
+
+// resourceTransport serves resources/read for the taikun:// URI templates and resource
+// subscriptions, which mcp-golang does not support, before requests reach the MCP server
+type resourceTransport struct {
+	transport.Transport
+	// notifications is false on transports that cannot deliver server notifications
+	notifications bool
+}
+
+func (t resourceTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.Transport.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		if message.Type == transport.BaseMessageTypeJSONRPCRequestType {
+			switch message.JsonRpcRequest.Method {
+			case "resources/read", "resources/subscribe", "resources/unsubscribe":
+				go t.handleRequest(ctx, message.JsonRpcRequest)
+				return
+			}
+		}
+		handler(ctx, message)
+	})
+}
+
+// Send advertises resource subscriptions in the initialize result
+func (t resourceTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	if t.notifications && message != nil && message.Type == transport.BaseMessageTypeJSONRPCResponseType && message.JsonRpcResponse != nil {
+		message.JsonRpcResponse.Result = withResourceSubscribe(message.JsonRpcResponse.Result)
+	}
+	return t.Transport.Send(ctx, message)
+}
+
+func withResourceSubscribe(result json.RawMessage) json.RawMessage {
+	if !strings.Contains(string(result), `"serverInfo"`) {
+		return result
+	}
+	var initialize map[string]interface{}
+	if err := json.Unmarshal(result, &initialize); err != nil {
+		return result
+	}
+	capabilities, ok := initialize["capabilities"].(map[string]interface{})
+	if !ok {
+		return result
+	}
+	resources, ok := capabilities["resources"].(map[string]interface{})
+	if !ok {
+		return result
+	}
+	resources["subscribe"] = true
+	patched, err := json.Marshal(initialize)
+	if err != nil {
+		return result
+	}
+	return patched
+}
+
+func (t resourceTransport) handleRequest(ctx context.Context, request *transport.BaseJSONRPCRequest) {
+	var args ReadResourceArgs
+	if err := json.Unmarshal(request.Params, &args); err != nil || args.URI == "" {
+		t.sendError(ctx, request.Id, rpcInvalidParams, "uri is required", nil)
+		return
+	}
+
+	switch request.Method {
+	case "resources/read":
+		t.read(ctx, request.Id, args)
+	case "resources/subscribe":
+		t.subscribe(ctx, request.Id, args)
+	case "resources/unsubscribe":
+		resourceWatches.stop(sessionIDFromContext(ctx), args.URI)
+		t.sendResult(ctx, request.Id, map[string]interface{}{})
+	}
+}
+
+func (t resourceTransport) read(ctx context.Context, id transport.RequestId, args ReadResourceArgs) {
+	resource, ids, ok := matchResource(args.URI)
+	if !ok {
+		t.sendError(ctx, id, rpcResourceNotFound, fmt.Sprintf("Resource not found: %s", args.URI), nil)
+		return
+	}
+
+	read := withRequestLogging(resourceReadName, withAudit(resourceReadName, withTaikunClient(
+		func(ctx context.Context, client *taikungoclient.Client, _ ReadResourceArgs) (*mcp_golang.ToolResponse, error) {
+			if resource.verify != nil {
+				if errorResp := resource.verify(ctx, client, ids); errorResp != nil {
+					return errorResp, nil
+				}
+			}
+			return resource.read(ctx, client, ids)
+		})))
+	response, err := read(ctx, args)
+	if err != nil {
+		t.sendError(ctx, id, rpcInternalError, err.Error(), nil)
+		return
+	}
+	if envelope, ok := errorEnvelope(response); ok {
+		t.sendEnvelope(ctx, id, envelope)
+		return
+	}
+
+	text := ""
+	if len(response.Content) > 0 && response.Content[0].TextContent != nil {
+		text = response.Content[0].TextContent.Text
+	}
+	t.sendResult(ctx, id, mcp_golang.NewResourceResponse(mcp_golang.NewTextEmbeddedResource(args.URI, text, resource.MimeType)))
+}
+
+func (t resourceTransport) subscribe(ctx context.Context, id transport.RequestId, args ReadResourceArgs) {
+	if !t.notifications {
+		t.sendError(ctx, id, rpcInvalidRequest, "Resource subscriptions need the stdio or sse transport", nil)
+		return
+	}
+	resource, ids, ok := matchResource(args.URI)
+	if !ok {
+		t.sendError(ctx, id, rpcResourceNotFound, fmt.Sprintf("Resource not found: %s", args.URI), nil)
+		return
+	}
+	if !resource.Watched {
+		t.sendError(ctx, id, rpcInvalidParams, fmt.Sprintf("Resource %s does not support subscriptions", args.URI), nil)
+		return
+	}
+	client, err := clientForContext(ctx)
+	if err != nil {
+		t.sendError(ctx, id, rpcInvalidRequest, err.Error(), nil)
+		return
+	}
+	if resource.verify != nil {
+		if errorResp := resource.verify(ctx, client, ids); errorResp != nil {
+			envelope, _ := errorEnvelope(errorResp)
+			t.sendEnvelope(ctx, id, envelope)
+			return
+		}
+	}
+
+	resourceWatches.start(ctx, args.URI, func(ctx context.Context) {
+		watchProject(ctx, client, ids[0], args.URI, t.Transport.Send)
+	})
+	logger.InfoContext(ctx, "Resource subscription started", "uri", args.URI)
+	t.sendResult(ctx, id, map[string]interface{}{})
+}
+
+// appInProject refuses an app URI whose app belongs to another project than the one it names
+func appInProject(ctx context.Context, client *taikungoclient.Client, ids []int32) *mcp_golang.ToolResponse {
+	app, httpResponse, err := client.Client.ProjectAppsAPI.ProjectappDetails(ctx, ids[1]).Execute()
+	if err != nil {
+		return createError(httpResponse, err)
+	}
+	if errorResp := checkResponse(httpResponse, "get application details"); errorResp != nil {
+		return errorResp
+	}
+	if app == nil || app.GetProjectId() != ids[0] {
+		return createJSONResponse(ErrorResponse{
+			Error: fmt.Sprintf("Application with ID %d not found in project %d", ids[1], ids[0]),
+			Code:  errorCodeNotFound,
+		})
+	}
+	return nil
+}
+
+func (t resourceTransport) sendResult(ctx context.Context, id transport.RequestId, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.sendError(ctx, id, rpcInternalError, fmt.Sprintf("failed to marshal result: %v", err), nil)
+		return
+	}
+	response := transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Jsonrpc: "2.0", Id: id, Result: data})
+	if err := t.Transport.Send(ctx, response); err != nil {
+		logger.WarnContext(ctx, "Failed to send resource response", "error", err)
+	}
+}
+
+// sendEnvelope answers with the JSON-RPC error matching a tool's error envelope
+func (t resourceTransport) sendEnvelope(ctx context.Context, id transport.RequestId, envelope string) {
+	var errorResp ErrorResponse
+	_ = json.Unmarshal([]byte(envelope), &errorResp)
+	code := rpcInternalError
+	if errorResp.Code == errorCodeNotFound {
+		code = rpcResourceNotFound
+	}
+	t.sendError(ctx, id, code, errorResp.Error, json.RawMessage(envelope))
+}
+
+func (t resourceTransport) sendError(ctx context.Context, id transport.RequestId, code int, message string, data interface{}) {
+	response := transport.NewBaseMessageError(&transport.BaseJSONRPCError{
+		Jsonrpc: "2.0",
+		Id:      id,
+		Error:   transport.BaseJSONRPCErrorInner{Code: code, Message: message, Data: data},
+	})
+	if err := t.Transport.Send(ctx, response); err != nil {
+		logger.WarnContext(ctx, "Failed to send resource error", "error", err)
+	}
+}
+
+// resourceWatches tracks the subscriptions of every session, keyed by session and URI
+var resourceWatches = &watchSet{watches: make(map[string]*resourceWatch)}
+
+type resourceWatch struct {
+	cancel context.CancelFunc
+}
+
+type watchSet struct {
+	mu      sync.Mutex
+	watches map[string]*resourceWatch
+}
+
+// start runs watch until the subscription is cancelled or the session closes. Subscribing
+// again to the same URI replaces the previous watch.
+func (w *watchSet) start(ctx context.Context, uri string, watch func(ctx context.Context)) {
+	key := sessionIDFromContext(ctx) + " " + uri
+	watchCtx, cancel := context.WithCancel(ctx)
+	current := &resourceWatch{cancel: cancel}
+
+	w.mu.Lock()
+	if previous, ok := w.watches[key]; ok {
+		previous.cancel()
+	}
+	w.watches[key] = current
+	w.mu.Unlock()
+
+	go func() {
+		defer func() {
+			w.mu.Lock()
+			// Only remove our own entry, a later subscription may have replaced it
+			if w.watches[key] == current {
+				delete(w.watches, key)
+			}
+			w.mu.Unlock()
+			cancel()
+		}()
+		watch(watchCtx)
+	}()
+}
+
+func (w *watchSet) stop(sessionID, uri string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := sessionID + " " + uri
+	if watch, ok := w.watches[key]; ok {
+		watch.cancel()
+		delete(w.watches, key)
+	}
+}
+
+// watchProject sends notifications/resources/updated for uri whenever the status or health of
+// the project changes
+func watchProject(ctx context.Context, client *taikungoclient.Client, projectID int32, uri string, send func(context.Context, *transport.BaseJsonRpcMessage) error) {
+	var last string
+	for {
+		result, httpResponse, err := client.Client.ProjectsAPI.ProjectsList(ctx).Id(projectID).Execute()
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil || httpResponse == nil || httpResponse.StatusCode >= 300:
+			logger.DebugContext(ctx, "Failed to check subscribed project", "projectId", projectID, "error", err)
+		default:
+			state := "deleted"
+			if len(result.Data) > 0 {
+				state = fmt.Sprintf("%s/%s", result.Data[0].GetStatus(), result.Data[0].GetHealth())
+			}
+			if last != "" && state != last {
+				logger.InfoContext(ctx, "Subscribed project changed", "projectId", projectID, "uri", uri, "from", last, "to", state)
+				params, _ := json.Marshal(map[string]string{"uri": uri})
+				notification := transport.NewBaseMessageNotification(&transport.BaseJSONRPCNotification{
+					Jsonrpc: "2.0",
+					Method:  "notifications/resources/updated",
+					Params:  params,
+				})
+				if err := send(ctx, notification); err != nil {
+					logger.DebugContext(ctx, "Failed to send resource update", "uri", uri, "error", err)
+				}
+			}
+			last = state
+		}
+
+		if err := sleepContext(ctx, resourceWatchInterval); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const retryMutatingContextKey contextKey = "retry-mutating"
+
+// retryPolicy controls how Taikun API requests that failed transiently are repeated
+type retryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// MutatingTools are tool name patterns whose POST/PUT/DELETE calls are safe to repeat
+	MutatingTools []string
+}
+
+var taikunRetryPolicy = retryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// withRetries wraps the HTTP transport of a Taikun client with the retry policy
+func withRetries(client *taikungoclient.Client) *taikungoclient.Client {
+	for _, httpClient := range []*http.Client{client.Client.GetConfig().HTTPClient, client.ShowbackClient.GetConfig().HTTPClient} {
+		next := httpClient.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		httpClient.Transport = &retryTransport{next: next, policy: &taikunRetryPolicy, sleep: sleepContext}
+	}
+	return client
+}
+
+// withMutatingRetries lets the Taikun calls of a tool retry even when they are not GETs
+func withMutatingRetries[T any](handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		return handler(context.WithValue(ctx, retryMutatingContextKey, true), args)
+	}
+}
+
+// retryTransport repeats requests that hit rate limits, gateway errors or dropped connections
+type retryTransport struct {
+	next   http.RoundTripper
+	policy *retryPolicy
+	sleep  func(ctx context.Context, delay time.Duration) error
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	maxAttempts := t.policy.MaxAttempts
+	if !isRetryableRequest(req) || maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= maxAttempts || !shouldRetry(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := t.policy.backoff(attempt, resp)
+		if resp != nil {
+			logger.WarnContext(ctx, "Retrying Taikun API request", "method", req.Method, "path", req.URL.Path,
+				"attempt", attempt, "status", resp.StatusCode, "delay", delay)
+			// Drain so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			logger.WarnContext(ctx, "Retrying Taikun API request", "method", req.Method, "path", req.URL.Path,
+				"attempt", attempt, "error", err, "delay", delay)
+		}
+
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// isRetryableRequest reports whether a request may be sent again: GETs always, other methods
+// only for tools that opted in and when the body can be replayed
+func isRetryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	if optedIn, _ := req.Context().Value(retryMutatingContextKey).(bool); !optedIn {
+		return false
+	}
+	return req.Body == nil || req.GetBody != nil
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		var netErr net.Error
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+			(errors.As(err, &netErr) && netErr.Timeout())
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the next attempt: the server's Retry-After when present,
+// otherwise exponential backoff with jitter. Both are capped at MaxDelay.
+func (p *retryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return min(delay, p.MaxDelay)
+		}
+	}
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Equal jitter: keep half the delay and randomize the other half
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// toolAccess classifies whether a tool only reads state or can change it
+type toolAccess int
+
+const (
+	toolRead toolAccess = iota
+	toolWrite
+)
+
+// toolAccessLevels is the single source of truth for tool classification.
+// Tools missing from this table are treated as write tools.
+var toolAccessLevels = map[string]toolAccess{
+	// Client
+	"refresh-taikun-client": toolRead,
+
+	// Virtual clusters
+	"create-virtual-cluster": toolWrite,
+	"delete-virtual-cluster": toolWrite,
+	"list-virtual-clusters":  toolRead,
+
+	// Catalogs
+	"catalog-create":           toolWrite,
+	"catalog-list":             toolRead,
+	"catalog-delete":           toolWrite,
+	"catalog-bind-projects":    toolWrite,
+	"catalog-unbind-projects":  toolWrite,
+	"available-apps-list":      toolRead,
+	"catalog-app-add":          toolWrite,
+	"catalog-apps-list":        toolRead,
+	"catalog-app-params":       toolRead,
+	"catalog-app-defaults-set": toolWrite,
+
+	// Applications
+	"app-install":     toolWrite,
+	"list-apps":       toolRead,
+	"get-app":         toolRead,
+	"update-sync-app": toolWrite,
+	"uninstall-app":   toolWrite,
+	"wait-for-app":    toolRead,
+
+	// Projects
+	"list-projects":              toolRead,
+	"create-project":             toolWrite,
+	"delete-project":             toolWrite,
+	"wait-for-project":           toolRead,
+	"lock-project":               toolWrite,
+	"unlock-project":             toolWrite,
+	"set-project-expiration":     toolWrite,
+	"list-expiring-projects":     toolRead,
+	"cost-report":                toolRead,
+	"list-kubernetes-versions":   toolRead,
+	"upgrade-project-kubernetes": toolWrite,
+	"export-project-spec":        toolRead,
+	"apply-project-spec":         toolWrite,
+	"plan-project-changes":       toolRead,
+
+	// Kubernetes
+	"deploy-kubernetes-resources":  toolWrite,
+	"create-kubeconfig":            toolWrite,
+	"get-kubeconfig":               toolRead,
+	"list-kubeconfig-roles":        toolRead,
+	"list-kubernetes-resources":    toolRead,
+	"describe-kubernetes-resource": toolRead,
+	"delete-kubernetes-resource":   toolWrite,
+	"patch-kubernetes-resource":    toolWrite,
+	"list-cloud-credentials":       toolRead,
+
+	// Servers
+	"bind-flavors-to-project":     toolWrite,
+	"add-server-to-project":       toolWrite,
+	"commit-project":              toolWrite,
+	"provision-cluster":           toolWrite,
+	"get-project-details":         toolRead,
+	"list-flavors":                toolRead,
+	"recommend-flavors":           toolRead,
+	"list-servers":                toolRead,
+	"delete-servers-from-project": toolWrite,
+	"scale-node-pool":             toolWrite,
+
+	// Jobs only touch the server's own job table, so they stay available in read-only mode
+	"job-status": toolRead,
+	"job-list":   toolRead,
+	"job-cancel": toolRead,
+}
+
+var (
+	// readOnlyMode hides and blocks every tool that is not classified as read
+	readOnlyMode bool
+
+	// toolSettings holds the optional allow/deny lists and description overrides
+	toolSettings = &toolConfig{}
+
+	// defaultToolTimeout bounds every tool call without its own entry in the tools config; 0 disables it
+	defaultToolTimeout = time.Hour
+)
+
+// toolConfig is loaded from the YAML or JSON file given by --tools-config.
+// Entries in allow and deny are tool names or globs such as "catalog-*".
+type toolConfig struct {
+	// Allow, when not empty, exposes only the matching tools
+	Allow []string `json:"allow,omitempty"`
+	// Deny hides the matching tools and takes precedence over Allow
+	Deny []string `json:"deny,omitempty"`
+	// Descriptions replaces the description text of individual tools
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+	// Timeouts sets the maximum duration of individual tools, such as "20m"
+	Timeouts map[string]string `json:"timeouts,omitempty"`
+
+	timeouts map[string]time.Duration
+}
+
+// loadToolConfig reads and validates a tool configuration file
+func loadToolConfig(filename string) (*toolConfig, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tools config: %w", err)
+	}
+	defer file.Close()
+
+	var cfg toolConfig
+	if err := yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse tools config %s: %w", filename, err)
+	}
+
+	for _, pattern := range append(append([]string{}, cfg.Allow...), cfg.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q in %s: %w", pattern, filename, err)
+		}
+		if !matchesKnownTool(pattern) {
+			logger.Warn("Tool pattern matches no tool", "pattern", pattern, "file", filename)
+		}
+	}
+	for name := range cfg.Descriptions {
+		if _, ok := toolAccessLevels[name]; !ok {
+			logger.Warn("Description override for unknown tool", "tool", name, "file", filename)
+		}
+	}
+	cfg.timeouts = make(map[string]time.Duration, len(cfg.Timeouts))
+	for name, value := range cfg.Timeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q for tool %s in %s", value, name, filename)
+		}
+		if _, ok := toolAccessLevels[name]; !ok {
+			logger.Warn("Timeout for unknown tool", "tool", name, "file", filename)
+		}
+		cfg.timeouts[name] = timeout
+	}
+
+	return &cfg, nil
+}
+
+// enabled reports whether the allow/deny lists expose the tool
+func (c *toolConfig) enabled(name string) bool {
+	if matchesAny(c.Deny, name) {
+		return false
+	}
+	return len(c.Allow) == 0 || matchesAny(c.Allow, name)
+}
+
+// description returns the configured override, or the built-in text
+func (c *toolConfig) description(name, fallback string) string {
+	if override, ok := c.Descriptions[name]; ok && override != "" {
+		return override
+	}
+	return fallback
+}
+
+// toolAvailable reports whether a tool is registered, given read-only mode and the tools config
+func toolAvailable(name string) bool {
+	return toolSettings.enabled(name) && (!readOnlyMode || isReadTool(name))
+}
+
+// requireTools refuses a composite tool whose steps need a tool that read-only mode or the tools
+// config disabled, so provisioning several resources at once cannot do what the tool itself could not
+func requireTools(tool string, names ...string) *mcp_golang.ToolResponse {
+	for _, name := range names {
+		if !toolAvailable(name) {
+			return createJSONResponse(ErrorResponse{
+				Error: fmt.Sprintf("%s needs %s, which is disabled on this server", tool, name),
+				Code:  errorCodeValidation,
+			})
+		}
+	}
+	return nil
+}
+
+// timeout returns the maximum duration of a tool call
+func (c *toolConfig) timeout(name string) time.Duration {
+	if timeout, ok := c.timeouts[name]; ok {
+		return timeout
+	}
+	return defaultToolTimeout
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesKnownTool(pattern string) bool {
+	for name := range toolAccessLevels {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func isReadTool(name string) bool {
+	access, ok := toolAccessLevels[name]
+	return ok && access == toolRead
+}
+
+// readOnlyRefusal is returned by write tools when the server runs in read-only mode
+func readOnlyRefusal(name string) *mcp_golang.ToolResponse {
+	return createJSONResponse(ErrorResponse{
+		Error:   fmt.Sprintf("Tool %s is disabled: the server is running in read-only mode", name),
+		Code:    errorCodeUnauthorized,
+		Details: "Only list, get, describe and wait tools are available. Restart the server without --read-only to make changes.",
+	})
+}
+
+// registerTool registers a tool with the MCP server, applying the server-wide tool policy
+func registerTool[T any](server *mcp_golang.Server, name, description string, handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) error {
+	if readOnlyMode && !isReadTool(name) {
+		logger.Debug("Skipped tool in read-only mode", "tool", name)
+		return nil
+	}
+	if !toolSettings.enabled(name) {
+		logger.Debug("Skipped tool disabled by tools config", "tool", name)
+		return nil
+	}
+	description = toolSettings.description(name, description)
+
+	if !isReadTool(name) {
+		next := handler
+		handler = func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+			// Checked per call as well, so write handlers invoked indirectly are refused too
+			if readOnlyMode {
+				logger.WarnContext(ctx, "Refused tool call in read-only mode", "tool", name)
+				return readOnlyRefusal(name), nil
+			}
+			return next(ctx, args)
+		}
+	}
+
+	if !isReadTool(name) && matchesAny(taikunRetryPolicy.MutatingTools, name) {
+		handler = withMutatingRetries(handler)
+	}
+	handler = withDeadline(toolSettings.timeout(name), handler)
+	// The audit record wraps the call itself, so a job is audited with its final result rather
+	// than when it is queued
+	handler = withAsync(name, withAudit(name, handler))
+
+	if err := server.RegisterTool(name, description, withErrorFlag(withRequestLogging(name, handler))); err != nil {
+		return err
+	}
+	logger.Debug("Registered tool", "tool", name)
+	return nil
+}
+
+// withDeadline cancels a tool call, including its wait loops, once it runs longer than timeout
+func withDeadline[T any](timeout time.Duration, handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	if timeout <= 0 {
+		return handler
+	}
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, args)
+	}
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/metoro-io/mcp-golang/transport"
+	"github.com/metoro-io/mcp-golang/transport/stdio"
+)
+
+// Supported MCP transports
+const (
+	transportStdio = "stdio"
+	transportHTTP  = "http"
+	transportSSE   = "sse"
+)
+
+const (
+	mcpEndpoint        = "/mcp"
+	sseEndpoint        = "/sse"
+	sseMessageEndpoint = "/message"
+	maxMessageBytes    = 10 << 20
+)
+
+type contextKey string
+
+const (
+	sessionIDContextKey  contextKey = "mcp-session-id"
+	remoteAddrContextKey contextKey = "remote-addr"
+	// plainHTTPContextKey marks requests answered in an HTTP response body, which cannot carry notifications
+	plainHTTPContextKey contextKey = "plain-http"
+)
+
+// sessionIDFromContext returns the transport session the request arrived on, if any
+func sessionIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	sessionID, _ := ctx.Value(sessionIDContextKey).(string)
+	return sessionID
+}
+
+// remoteAddrFromContext returns the network address of the client that sent the request, if any
+func remoteAddrFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	remoteAddr, _ := ctx.Value(remoteAddrContextKey).(string)
+	return remoteAddr
+}
+
+// newServerTransport builds the transport selected by the server configuration
+func newServerTransport(cfg serverConfig) transport.Transport {
+	switch cfg.Transport {
+	case transportHTTP, transportSSE:
+		return newHTTPServerTransport(cfg.Transport, cfg.ListenAddr)
+	default:
+		return stdio.NewStdioServerTransport()
+	}
+}
+
+// shutdownTransport stops the transport, letting in-flight HTTP requests finish when possible
+func shutdownTransport(ctx context.Context, t transport.Transport) error {
+	if httpTransport, ok := t.(*httpServerTransport); ok {
+		return httpTransport.Shutdown(ctx)
+	}
+	return t.Close()
+}
+
+// pendingCall tracks a request that has been handed to the MCP server and awaits its response
+type pendingCall struct {
+	originalID transport.RequestId
+	sessionID  string
+	reply      chan *transport.BaseJsonRpcMessage
+	session    *sseSession
+}
+
+type sseSession struct {
+	id          string
+	credentials taikunCredentials
+	events      chan []byte
+	done        chan struct{}
+	once        sync.Once
+	// ctx is cancelled when the stream closes, stopping the tool calls still running for the session
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *sseSession) close() {
+	s.once.Do(func() {
+		s.cancel()
+		close(s.done)
+	})
+}
+
+// httpServerTransport serves MCP over HTTP, either as plain JSON request/response on /mcp
+// or as the SSE flavour (GET /sse for the event stream, POST /message for requests).
+// Request IDs are rewritten to server-unique values so several clients can share one MCP server.
+type httpServerTransport struct {
+	mode   string
+	addr   string
+	server *http.Server
+
+	mu       sync.Mutex
+	nextID   int64
+	pending  map[transport.RequestId]*pendingCall
+	sessions map[string]*sseSession
+
+	onMessage func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	onClose   func()
+	onError   func(error)
+}
+
+func newHTTPServerTransport(mode, addr string) *httpServerTransport {
+	return &httpServerTransport{
+		mode:     mode,
+		addr:     addr,
+		pending:  make(map[transport.RequestId]*pendingCall),
+		sessions: make(map[string]*sseSession),
+	}
+}
+
+// Start implements transport.Transport. It binds the listener and serves in the background.
+func (t *httpServerTransport) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	switch t.mode {
+	case transportSSE:
+		mux.HandleFunc(sseEndpoint, t.handleSSE)
+		mux.HandleFunc(sseMessageEndpoint, t.handleSSEMessage)
+	default:
+		mux.HandleFunc(mcpEndpoint, t.handleMCP)
+	}
+
+	listener, err := net.Listen("tcp", t.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", t.addr, err)
+	}
+
+	t.server = &http.Server{Handler: mux}
+	logger.Info("Serving MCP", "transport", t.mode, "addr", listener.Addr().String())
+
+	go func() {
+		if err := t.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.handleError(fmt.Errorf("http server error: %w", err))
+		}
+	}()
+	return nil
+}
+
+// Send implements transport.Transport, routing responses back to the caller that issued the request
+func (t *httpServerTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	var id transport.RequestId
+	switch message.Type {
+	case transport.BaseMessageTypeJSONRPCResponseType:
+		id = message.JsonRpcResponse.Id
+	case transport.BaseMessageTypeJSONRPCErrorType:
+		id = message.JsonRpcError.Id
+	default:
+		return t.sendNotification(ctx, message)
+	}
+
+	t.mu.Lock()
+	call, ok := t.pending[id]
+	delete(t.pending, id)
+	t.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no pending request for id %d", id)
+	}
+
+	if message.Type == transport.BaseMessageTypeJSONRPCResponseType {
+		message.JsonRpcResponse.Id = call.originalID
+	} else {
+		message.JsonRpcError.Id = call.originalID
+	}
+
+	if call.session != nil {
+		return t.writeSessionEvent(call.session, message)
+	}
+	call.reply <- message
+	return nil
+}
+
+// sendNotification delivers server notifications to SSE clients; plain HTTP has no channel for them
+func (t *httpServerTransport) sendNotification(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	if plainHTTP, _ := ctx.Value(plainHTTPContextKey).(bool); plainHTTP {
+		// Notifications about a plain HTTP request, such as progress, must not reach other clients
+		return nil
+	}
+
+	t.mu.Lock()
+	var targets []*sseSession
+	if sessionID := sessionIDFromContext(ctx); sessionID != "" {
+		// Notifications about a request, such as progress, only go to the session that sent it
+		if session, ok := t.sessions[sessionID]; ok {
+			targets = append(targets, session)
+		}
+	} else {
+		// Only notifications that no request caused are broadcast
+		for _, session := range t.sessions {
+			targets = append(targets, session)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, session := range targets {
+		if err := t.writeSessionEvent(session, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *httpServerTransport) writeSessionEvent(session *sseSession, message *transport.BaseJsonRpcMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	select {
+	case session.events <- data:
+		return nil
+	case <-session.done:
+		return fmt.Errorf("session %s is closed", session.id)
+	}
+}
+
+// Close implements transport.Transport
+func (t *httpServerTransport) Close() error {
+	t.closeSessions()
+	var err error
+	if t.server != nil {
+		err = t.server.Close()
+	}
+	t.handleClose()
+	return err
+}
+
+// Shutdown closes SSE streams and waits for in-flight HTTP requests to complete
+func (t *httpServerTransport) Shutdown(ctx context.Context) error {
+	t.closeSessions()
+	var err error
+	if t.server != nil {
+		err = t.server.Shutdown(ctx)
+	}
+	t.handleClose()
+	return err
+}
+
+func (t *httpServerTransport) closeSessions() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, session := range t.sessions {
+		session.close()
+		delete(t.sessions, id)
+	}
+}
+
+// SetCloseHandler implements transport.Transport
+func (t *httpServerTransport) SetCloseHandler(handler func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onClose = handler
+}
+
+// SetErrorHandler implements transport.Transport
+func (t *httpServerTransport) SetErrorHandler(handler func(error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onError = handler
+}
+
+// SetMessageHandler implements transport.Transport
+func (t *httpServerTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onMessage = handler
+}
+
+func (t *httpServerTransport) handleClose() {
+	t.mu.Lock()
+	handler := t.onClose
+	t.mu.Unlock()
+	if handler != nil {
+		handler()
+	}
+}
+
+func (t *httpServerTransport) handleError(err error) {
+	logger.Error("Transport error", "error", err)
+	t.mu.Lock()
+	handler := t.onError
+	t.mu.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// dispatch registers requests as pending and hands the message to the MCP server
+func (t *httpServerTransport) dispatch(ctx context.Context, message *transport.BaseJsonRpcMessage, call *pendingCall) {
+	sessionID := sessionIDFromContext(ctx)
+	t.mu.Lock()
+	switch message.Type {
+	case transport.BaseMessageTypeJSONRPCRequestType:
+		t.nextID++
+		serverID := transport.RequestId(t.nextID)
+		call.originalID = message.JsonRpcRequest.Id
+		call.sessionID = sessionID
+		message.JsonRpcRequest.Id = serverID
+		t.pending[serverID] = call
+	case transport.BaseMessageTypeJSONRPCNotificationType:
+		if message.JsonRpcNotification.Method == "notifications/cancelled" {
+			t.translateCancellation(message.JsonRpcNotification, sessionID)
+		}
+	}
+	handler := t.onMessage
+	t.mu.Unlock()
+
+	if handler != nil {
+		handler(ctx, message)
+	}
+}
+
+// translateCancellation points a client's cancellation at the server ID its request was given.
+// Request IDs are only unique per client, so the lookup is limited to the sender's session and
+// a cancellation that matches nothing is redirected to an ID no request uses.
+// Must be called with t.mu held.
+func (t *httpServerTransport) translateCancellation(notification *transport.BaseJSONRPCNotification, sessionID string) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(notification.Params, &params); err != nil {
+		return
+	}
+	requestID, _ := params["requestId"].(float64)
+	target := transport.RequestId(-1)
+	for serverID, call := range t.pending {
+		if sessionID != "" && call.sessionID == sessionID && call.originalID == transport.RequestId(requestID) {
+			target = serverID
+			break
+		}
+	}
+	params["requestId"] = target
+	if translated, err := json.Marshal(params); err == nil {
+		notification.Params = translated
+	}
+}
+
+func (t *httpServerTransport) forget(call *pendingCall) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, pending := range t.pending {
+		if pending == call {
+			delete(t.pending, id)
+		}
+	}
+}
+
+// handleMCP serves the plain HTTP transport: one JSON-RPC message per POST, answered in the response body
+func (t *httpServerTransport) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	message, err := readJSONRPCMessage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only a session ID the server issued is accepted, so a caller cannot cancel the requests of
+	// another session or receive its notifications by sending that session's ID
+	sessionID := r.Header.Get("Mcp-Session-Id")
+	t.mu.Lock()
+	if _, ok := t.sessions[sessionID]; !ok {
+		sessionID = ""
+	}
+	t.mu.Unlock()
+
+	ctx := context.WithValue(r.Context(), sessionIDContextKey, sessionID)
+	ctx = context.WithValue(ctx, plainHTTPContextKey, true)
+	ctx = context.WithValue(ctx, remoteAddrContextKey, r.RemoteAddr)
+	ctx = withCredentials(ctx, credentialsFromHeaders(r.Header))
+
+	if message.Type != transport.BaseMessageTypeJSONRPCRequestType {
+		t.dispatch(ctx, message, nil)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	call := &pendingCall{reply: make(chan *transport.BaseJsonRpcMessage, 1)}
+	t.dispatch(ctx, message, call)
+
+	select {
+	case response := <-call.reply:
+		data, err := json.Marshal(response)
+		if err != nil {
+			http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	case <-r.Context().Done():
+		t.forget(call)
+	}
+}
+
+// handleSSE opens an event stream and tells the client where to POST its messages
+func (t *httpServerTransport) handleSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Credentials sent when opening the stream apply to every message of the session
+	session := &sseSession{
+		id:          newSessionID(),
+		credentials: credentialsFromHeaders(r.Header),
+		events:      make(chan []byte, 16),
+		done:        make(chan struct{}),
+	}
+	session.ctx, session.cancel = context.WithCancel(context.Background())
+	t.mu.Lock()
+	t.sessions[session.id] = session
+	t.mu.Unlock()
+	logger.Info("SSE session opened", "sessionId", session.id, "remoteAddr", r.RemoteAddr)
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.sessions, session.id)
+		t.mu.Unlock()
+		session.close()
+		logger.Info("SSE session closed", "sessionId", session.id)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprintf(w, "event: endpoint\ndata: %s?sessionId=%s\n\n", sseMessageEndpoint, session.id)
+	flusher.Flush()
+
+	for {
+		select {
+		case data := <-session.events:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-session.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleSSEMessage accepts a JSON-RPC message for an open SSE session; the reply is sent on the stream
+func (t *httpServerTransport) handleSSEMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("sessionId")
+	t.mu.Lock()
+	session, ok := t.sessions[sessionID]
+	t.mu.Unlock()
+	if !ok {
+		http.Error(w, "Unknown or expired session", http.StatusNotFound)
+		return
+	}
+
+	message, err := readJSONRPCMessage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The request outlives this POST, so it is bound to the session rather than to r.Context()
+	ctx := context.WithValue(session.ctx, sessionIDContextKey, session.id)
+	credentials := credentialsFromHeaders(r.Header)
+	if credentials.isEmpty() {
+		credentials = session.credentials
+	}
+	ctx = context.WithValue(ctx, remoteAddrContextKey, r.RemoteAddr)
+	ctx = withCredentials(ctx, credentials)
+	t.dispatch(ctx, message, &pendingCall{session: session})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// readJSONRPCMessage decodes a single JSON-RPC request, notification or response from the body
+func readJSONRPCMessage(r *http.Request) (*transport.BaseJsonRpcMessage, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return decodeJSONRPCMessage(body)
+}
+
+func decodeJSONRPCMessage(body []byte) (*transport.BaseJsonRpcMessage, error) {
+	var request transport.BaseJSONRPCRequest
+	if err := json.Unmarshal(body, &request); err == nil {
+		return transport.NewBaseMessageRequest(&request), nil
+	}
+
+	var notification transport.BaseJSONRPCNotification
+	if err := json.Unmarshal(body, &notification); err == nil {
+		// The library's decoder drops the params, which cancellations need for their request ID
+		var params struct {
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(body, &params); err == nil {
+			notification.Params = params.Params
+		}
+		return transport.NewBaseMessageNotification(&notification), nil
+	}
+
+	var response transport.BaseJSONRPCResponse
+	if err := json.Unmarshal(body, &response); err == nil {
+		return transport.NewBaseMessageResponse(&response), nil
+	}
+
+	var errorResponse transport.BaseJSONRPCError
+	if err := json.Unmarshal(body, &errorResponse); err == nil && errorResponse.Jsonrpc != "" {
+		return transport.NewBaseMessageError(&errorResponse), nil
+	}
+
+	return nil, errors.New("body is not a valid JSON-RPC message")
+}
+
+func newSessionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to generate session id: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itera-io/taikungoclient"
+	taikuncore "github.com/itera-io/taikungoclient/client"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// upgradeStartTimeout bounds the wait for a triggered upgrade to move the project out of Ready
+const upgradeStartTimeout = 2 * time.Minute
+
+type ListKubernetesVersionsArgs struct {
+	CloudCredentialID int32 `json:"cloudCredentialId" jsonschema:"required,description=ID of the cloud credential to list Kubernetes versions for"`
+}
+
+type UpgradeProjectKubernetesArgs struct {
+	ProjectID int32 `json:"projectId" jsonschema:"required,description=ID of the project to upgrade"`
+	Timeout   int32 `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds when waiting for the upgrade to finish (default: 3600)"`
+	DryRun    bool  `json:"dryRun,omitempty" jsonschema:"description=Check the preconditions and return the API request that would be sent without executing it (default: false)"`
+	Async     bool  `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
+}
+
+func (a UpgradeProjectKubernetesArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
+type KubernetesVersionSummary struct {
+	Version          string `json:"version"`
+	KubevapSupported bool   `json:"kubevapSupported,omitempty"`
+}
+
+type KubernetesVersionListResponse struct {
+	CloudCredentialID int32                      `json:"cloudCredentialId"`
+	CloudType         string                     `json:"cloudType"`
+	Versions          []KubernetesVersionSummary `json:"versions"`
+	Total             int32                      `json:"total"`
+	Message           string                     `json:"message"`
+}
+
+// NodeVersion is the kubelet version of one node before and after an upgrade
+type NodeVersion struct {
+	Name   string `json:"name"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// UpgradeProjectKubernetesResponse reports the node versions around an upgrade and, when it fails, its error
+type UpgradeProjectKubernetesResponse struct {
+	ProjectID int32           `json:"projectId"`
+	Name      string          `json:"name"`
+	Before    string          `json:"before"`
+	After     string          `json:"after"`
+	Nodes     []NodeVersion   `json:"nodes,omitempty"`
+	Wait      json.RawMessage `json:"wait,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+	Success   bool            `json:"success"`
+	Message   string          `json:"message"`
+	Error     string          `json:"error,omitempty"`
+	Code      string          `json:"code,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"`
+}
+
+func listKubernetesVersions(ctx context.Context, client *taikungoclient.Client, args ListKubernetesVersionsArgs) (*mcp_golang.ToolResponse, error) {
+	credential, errorResp := resolveCloudCredential(ctx, client, args.CloudCredentialID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	response := KubernetesVersionListResponse{
+		CloudCredentialID: args.CloudCredentialID,
+		CloudType:         string(credential.GetCloudType()),
+		Versions:          []KubernetesVersionSummary{},
+	}
+
+	// Tanzu clusters run the versions their supervisor offers; every other cloud uses the versions Taikun supports
+	if credential.GetCloudType() == taikuncore.CLOUDTYPE_TANZU {
+		versions, httpResponse, err := client.Client.TanzuAPI.TanzuKubernetesVersions(ctx, args.CloudCredentialID).Execute()
+		if err != nil {
+			return createError(httpResponse, err), nil
+		}
+		if errorResp := checkResponse(httpResponse, "list Tanzu Kubernetes versions"); errorResp != nil {
+			return errorResp, nil
+		}
+		for _, version := range versions {
+			response.Versions = append(response.Versions, KubernetesVersionSummary{Version: version})
+		}
+	} else {
+		versions, httpResponse, err := client.Client.KubernetesAPI.KubernetesGetSupportedList(ctx).Execute()
+		if err != nil {
+			return createError(httpResponse, err), nil
+		}
+		if errorResp := checkResponse(httpResponse, "list Kubernetes versions"); errorResp != nil {
+			return errorResp, nil
+		}
+		for _, version := range versions {
+			response.Versions = append(response.Versions, KubernetesVersionSummary{
+				Version:          version.GetVersion(),
+				KubevapSupported: version.GetIsKubevapEnabled(),
+			})
+		}
+	}
+
+	response.Total = int32(len(response.Versions))
+	response.Message = fmt.Sprintf("Found %d Kubernetes versions for cloud credential %d (%s)", response.Total, args.CloudCredentialID, response.CloudType)
+	return createJSONResponse(response), nil
+}
+
+// upgradePreconditions lists the reasons a project cannot be upgraded; visibility is optional
+func upgradePreconditions(project taikuncore.ProjectListDetailDto, visibility *taikuncore.ProjectActionVisibilityDto) []string {
+	var problems []string
+	if project.GetStatus() != taikuncore.PROJECTSTATUS_READY {
+		problems = append(problems, fmt.Sprintf("project status is %s, not Ready", project.GetStatus()))
+	}
+	if project.GetHealth() != taikuncore.PROJECTHEALTH_HEALTHY {
+		problems = append(problems, fmt.Sprintf("project health is %s, not Healthy", project.GetHealth()))
+	}
+	if project.GetIsLocked() {
+		problems = append(problems, "project is locked")
+	}
+	if visibility != nil {
+		upgrade := visibility.GetUpgrade()
+		if upgrade.Enable != nil && !*upgrade.Enable {
+			if len(upgrade.Reasons) == 0 {
+				problems = append(problems, "Taikun does not offer an upgrade for the project")
+			}
+			problems = append(problems, upgrade.Reasons...)
+		}
+	}
+	return problems
+}
+
+// clusterVersion summarizes the kubelet versions of the nodes, or falls back to the version the
+// project reports when the nodes cannot be listed
+func clusterVersion(nodes []nodeListItem, fallback string) string {
+	seen := map[string]bool{}
+	var versions []string
+	for _, node := range nodes {
+		if node.Version != "" && !seen[node.Version] {
+			seen[node.Version] = true
+			versions = append(versions, node.Version)
+		}
+	}
+	if len(versions) == 0 {
+		return fallback
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ", ")
+}
+
+// nodeVersions pairs the node versions before and after an upgrade, by node name
+func nodeVersions(before, after []nodeListItem) []NodeVersion {
+	index := map[string]int{}
+	var result []NodeVersion
+	for _, node := range before {
+		index[node.Name] = len(result)
+		result = append(result, NodeVersion{Name: node.Name, Before: node.Version})
+	}
+	for _, node := range after {
+		if i, ok := index[node.Name]; ok {
+			result[i].After = node.Version
+			continue
+		}
+		result = append(result, NodeVersion{Name: node.Name, After: node.Version})
+	}
+	return result
+}
+
+func upgradeProjectKubernetes(ctx context.Context, client *taikungoclient.Client, args UpgradeProjectKubernetesArgs) (*mcp_golang.ToolResponse, error) {
+	project, errorResp := resolveProject(ctx, client, args.ProjectID)
+	if errorResp != nil {
+		return errorResp, nil
+	}
+
+	// The visibility check adds Taikun's own reasons; without it the explicit checks still apply
+	visibility, _, err := client.Client.ProjectsAPI.ProjectsVisibility(ctx, args.ProjectID).Execute()
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to check project actions before upgrading", "projectId", args.ProjectID, "error", err)
+		visibility = nil
+	}
+	if problems := upgradePreconditions(*project, visibility); len(problems) > 0 {
+		return createJSONResponse(ErrorResponse{
+			Error:   fmt.Sprintf("Project '%s' cannot be upgraded: %s", project.GetName(), strings.Join(problems, "; ")),
+			Code:    errorCodeConflict,
+			Details: "Wait for the project to be Ready and Healthy or unlock it, then try again",
+		}), nil
+	}
+
+	response := UpgradeProjectKubernetesResponse{ProjectID: args.ProjectID, Name: project.GetName()}
+	before, _, err := fetchKubernetesListItems[nodeListItem](ctx, client, args.ProjectID, "nodes", 0, 0, "")
+	if err != nil {
+		response.Warnings = append(response.Warnings, fmt.Sprintf("Could not list the nodes before the upgrade: %v", err))
+	}
+	response.Before = clusterVersion(before, project.GetKubernetesCurrentVersion())
+
+	if isDryRun(args.DryRun) {
+		return createDryRunResponse(
+			fmt.Sprintf("would upgrade project '%s' from Kubernetes %s to the next available version", project.GetName(), response.Before),
+			append([]string{
+				fmt.Sprintf("Project %d (%s) is Ready and Healthy", args.ProjectID, project.GetName()),
+				fmt.Sprintf("Project %d (%s) is not locked", args.ProjectID, project.GetName()),
+			}, response.Warnings...),
+			DryRunRequest{Method: http.MethodPost, Path: fmt.Sprintf("/api/v1/project-deployment/upgrade/%d", args.ProjectID)},
+		), nil
+	}
+
+	httpResponse, err := client.Client.ProjectDeploymentAPI.ProjectDeploymentUpgrade(ctx, args.ProjectID).Execute()
+	if err != nil {
+		return createError(httpResponse, err), nil
+	}
+	if errorResp := checkResponse(httpResponse, "upgrade project"); errorResp != nil {
+		return errorResp, nil
+	}
+	logger.InfoContext(ctx, "Project upgrade started", "projectId", args.ProjectID, "from", response.Before)
+	reportProgress(ctx, 0, 0, fmt.Sprintf("Project %d: upgrade from Kubernetes %s started", args.ProjectID, response.Before))
+
+	// waitForProject would take a project that has not left Ready yet for a finished upgrade
+	if err := waitForUpgradeStart(ctx, client, args.ProjectID); err != nil {
+		response.Warnings = append(response.Warnings, err.Error())
+	}
+
+	timeout := args.Timeout
+	if timeout <= 0 {
+		timeout = 3600 // Upgrades replace every node one at a time
+	}
+	wait, err := waitForProject(ctx, client, WaitForProjectArgs{ProjectId: args.ProjectID, Timeout: timeout})
+	if err != nil {
+		wait = createJSONResponse(errorResponseFromError(err, fmt.Sprintf("Waiting for the upgrade failed: %v", err)))
+	}
+	if len(wait.Content) > 0 && wait.Content[0].TextContent != nil {
+		response.Wait = upstreamBody([]byte(wait.Content[0].TextContent.Text))
+	}
+
+	// The versions are reported even when the wait failed, so a partial upgrade is visible
+	after, _, err := fetchKubernetesListItems[nodeListItem](context.WithoutCancel(ctx), client, args.ProjectID, "nodes", 0, 0, "")
+	if err != nil {
+		response.Warnings = append(response.Warnings, fmt.Sprintf("Could not list the nodes after the upgrade: %v", err))
+	}
+	current := response.Before
+	if upgraded, errorResp := resolveProject(context.WithoutCancel(ctx), client, args.ProjectID); errorResp == nil {
+		current = upgraded.GetKubernetesCurrentVersion()
+	}
+	response.After = clusterVersion(after, current)
+	response.Nodes = nodeVersions(before, after)
+
+	if envelope, ok := errorEnvelope(wait); ok {
+		failure := failureFromEnvelope(envelope)
+		response.Error = fmt.Sprintf("Upgrade of project '%s' did not finish: %s", project.GetName(), failure.Error)
+		response.Code = failure.Code
+		response.Retryable = failure.Retryable
+		response.Message = fmt.Sprintf("Kubernetes %s before, %s now", response.Before, response.After)
+		return createJSONResponse(response), nil
+	}
+
+	response.Success = true
+	response.Message = fmt.Sprintf("Upgraded project '%s' from Kubernetes %s to %s", project.GetName(), response.Before, response.After)
+	if response.After == response.Before {
+		response.Message = fmt.Sprintf("Project '%s' is Ready again but still runs Kubernetes %s", project.GetName(), response.After)
+		response.Warnings = append(response.Warnings, "The node versions did not change; the project may already run the newest version Taikun offers")
+	}
+	return createJSONResponse(response), nil
+}
+
+// waitForUpgradeStart polls until the project leaves Ready, which shows the upgrade was picked up
+func waitForUpgradeStart(ctx context.Context, client *taikungoclient.Client, projectID int32) error {
+	deadline := time.Now().Add(upgradeStartTimeout)
+	for {
+		project, errorResp := resolveProject(ctx, client, projectID)
+		if errorResp == nil && project.GetStatus() != taikuncore.PROJECTSTATUS_READY {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the project did not leave Ready within %s of starting the upgrade", upgradeStartTimeout)
+		}
+		if err := sleepContext(ctx, 5*time.Second); err != nil {
+			return err
+		}
+	}
+}
@@ -11,8 +11,7 @@ import (
 )
 
 // waitForVirtualClusterReady polls the virtual cluster status until it's ready or times out
-func waitForVirtualClusterReady(client *taikungoclient.Client, parentProjectID int32, clusterName string, timeoutSeconds int32) error {
-	ctx := context.Background()
+func waitForVirtualClusterReady(ctx context.Context, client *taikungoclient.Client, parentProjectID int32, clusterName string, timeoutSeconds int32) error {
 	timeout := time.Duration(timeoutSeconds) * time.Second
 	if timeoutSeconds == 0 {
 		timeout = 15 * time.Minute // Default 15 minutes
@@ -22,7 +21,7 @@ func waitForVirtualClusterReady(client *taikungoclient.Client, parentProjectID i
 	for {
 		// Check if we've exceeded the timeout
 		if time.Since(start) > timeout {
-			return fmt.Errorf("timeout waiting for virtual cluster '%s' to be ready after %v", clusterName, timeout)
+			return newCodedError(errorCodeTimeout, "timeout waiting for virtual cluster '%s' to be ready after %v", clusterName, timeout)
 		}
 
 		// Query the virtual cluster status
@@ -31,11 +30,15 @@ func waitForVirtualClusterReady(client *taikungoclient.Client, parentProjectID i
 		virtualClusterList, response, err := req.Execute()
 
 		if err != nil {
-			return fmt.Errorf("error checking virtual cluster status: %v", err)
+			return upstreamError(response, err)
 		}
 
 		if response.StatusCode < 200 || response.StatusCode >= 300 {
-			return fmt.Errorf("HTTP error checking virtual cluster status: %d", response.StatusCode)
+			return &codedError{
+				code:       errorCodeForStatus(response.StatusCode),
+				httpStatus: response.StatusCode,
+				message:    fmt.Sprintf("HTTP error checking virtual cluster status: %d", response.StatusCode),
+			}
 		}
 
 		// Find our specific virtual cluster
@@ -46,7 +49,12 @@ func waitForVirtualClusterReady(client *taikungoclient.Client, parentProjectID i
 					health := string(vc.Health)
 
 					// Log current status
-					logger.Printf("Virtual cluster '%s' status: %s, health: %s", clusterName, status, health)
+					logger.InfoContext(ctx, "Virtual cluster status", "name", clusterName, "status", status, "health", health)
+					progress, total := elapsedProgress(start, timeout)
+					if status == "Ready" && health == "Healthy" {
+						progress = total
+					}
+					reportProgress(ctx, progress, total, fmt.Sprintf("Virtual cluster '%s': %s, %s (%s)", clusterName, status, health, formatElapsed(start)))
 
 					// Check if cluster is ready
 					if status == "Ready" && health == "Healthy" {
@@ -55,7 +63,7 @@ func waitForVirtualClusterReady(client *taikungoclient.Client, parentProjectID i
 
 					// Check for failure states
 					if status == "Failed" || health == "Unhealthy" {
-						return fmt.Errorf("virtual cluster '%s' creation failed - status: %s, health: %s", clusterName, status, health)
+						return newCodedError(errorCodeResourceFailed, "virtual cluster '%s' creation failed - status: %s, health: %s", clusterName, status, health)
 					}
 
 					// Continue polling if still updating/pending
@@ -64,8 +72,10 @@ func waitForVirtualClusterReady(client *taikungoclient.Client, parentProjectID i
 			}
 		}
 
-		// Wait before next poll
-		time.Sleep(10 * time.Second)
+		// Wait before next poll, giving up as soon as the request is cancelled
+		if err := sleepContext(ctx, 10*time.Second); err != nil {
+			return fmt.Errorf("stopped waiting for virtual cluster '%s': %w", clusterName, err)
+		}
 	}
 }
 
@@ -77,10 +87,16 @@ type CreateVirtualClusterArgs struct {
 	AlertingProfileID  int32  `json:"alertingProfileId,omitempty" jsonschema:"description=ID of alerting profile to use (optional)"`
 	WaitForCreation    bool   `json:"waitForCreation,omitempty" jsonschema:"description=Wait for virtual cluster to be fully created before returning (default: false)"`
 	Timeout            int32  `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds when waiting for creation (default: 900)"`
+	DryRun             bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	Async              bool   `json:"async,omitempty" jsonschema:"description=Run as a background job and return its ID right away; follow it with job-status (default: false)"`
 }
 
+func (a CreateVirtualClusterArgs) runAsync() bool { return a.Async && !isDryRun(a.DryRun) }
+
 type DeleteVirtualClusterArgs struct {
-	ProjectID int32 `json:"projectId" jsonschema:"required,description=The project ID of the virtual cluster to delete"`
+	ProjectID         int32  `json:"projectId" jsonschema:"required,description=The project ID of the virtual cluster to delete"`
+	DryRun            bool   `json:"dryRun,omitempty" jsonschema:"description=Validate inputs and return the API request that would be sent without executing it (default: false)"`
+	ConfirmationToken string `json:"confirmationToken,omitempty" jsonschema:"description=Token returned by the first call after reviewing its summary; the deletion only runs when this is provided"`
 }
 
 type ListVirtualClustersArgs struct {
@@ -90,9 +106,7 @@ type ListVirtualClustersArgs struct {
 	Search          string `json:"search,omitempty" jsonschema:"description=Search term to filter results (optional)"`
 }
 
-func createVirtualCluster(client *taikungoclient.Client, args CreateVirtualClusterArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func createVirtualCluster(ctx context.Context, client *taikungoclient.Client, args CreateVirtualClusterArgs) (*mcp_golang.ToolResponse, error) {
 	createCmd := taikuncore.NewCreateVirtualClusterCommand()
 	createCmd.SetProjectId(args.ProjectID)
 	createCmd.SetName(args.Name)
@@ -103,6 +117,7 @@ func createVirtualCluster(client *taikungoclient.Client, args CreateVirtualClust
 		if err != nil {
 			errorResp := ErrorResponse{
 				Error: fmt.Sprintf("Error parsing expiration date: %v", err),
+				Code:  errorCodeValidation,
 			}
 			return createJSONResponse(errorResp), nil
 		}
@@ -113,6 +128,24 @@ func createVirtualCluster(client *taikungoclient.Client, args CreateVirtualClust
 		createCmd.SetAlertingProfileId(args.AlertingProfileID)
 	}
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		if !isProjectReadyForVirtualCluster(*project) {
+			return createJSONResponse(ErrorResponse{
+				Error: fmt.Sprintf("Project %d cannot host a virtual cluster: %s", args.ProjectID, getVirtualClusterReadinessReason(*project)),
+				Code:  errorCodeConflict,
+			}), nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would create virtual cluster '%s' in project %d", args.Name, args.ProjectID),
+			[]string{fmt.Sprintf("Parent project %d (%s) exists and can host virtual clusters", args.ProjectID, project.GetName())},
+			dryRunPost("/api/v1/virtual-cluster/create", createCmd),
+		), nil
+	}
+
 	response, err := client.Client.VirtualClusterAPI.VirtualClusterCreate(ctx).
 		CreateVirtualClusterCommand(*createCmd).
 		Execute()
@@ -128,16 +161,14 @@ func createVirtualCluster(client *taikungoclient.Client, args CreateVirtualClust
 	// Wait for creation if requested
 	var message string
 	if args.WaitForCreation {
-		logger.Printf("Waiting for virtual cluster '%s' to be ready...", args.Name)
-		err := waitForVirtualClusterReady(client, args.ProjectID, args.Name, args.Timeout)
+		logger.InfoContext(ctx, "Waiting for virtual cluster to be ready", "name", args.Name)
+		err := waitForVirtualClusterReady(ctx, client, args.ProjectID, args.Name, args.Timeout)
 		if err != nil {
-			errorResp := ErrorResponse{
-				Error: fmt.Sprintf("Virtual cluster creation initiated but failed during wait: %v", err),
-			}
+			errorResp := errorResponseFromError(err, fmt.Sprintf("Virtual cluster creation initiated but failed during wait: %v", err))
 			return createJSONResponse(errorResp), nil
 		}
 		message = fmt.Sprintf("Virtual cluster '%s' created and is ready in project %d", args.Name, args.ProjectID)
-		logger.Printf("Virtual cluster '%s' is now ready", args.Name)
+		logger.InfoContext(ctx, "Virtual cluster is ready", "name", args.Name)
 	} else {
 		message = fmt.Sprintf("Virtual cluster '%s' creation initiated in project %d", args.Name, args.ProjectID)
 	}
@@ -150,12 +181,39 @@ func createVirtualCluster(client *taikungoclient.Client, args CreateVirtualClust
 	return createJSONResponse(successResp), nil
 }
 
-func deleteVirtualCluster(client *taikungoclient.Client, args DeleteVirtualClusterArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func deleteVirtualCluster(ctx context.Context, client *taikungoclient.Client, args DeleteVirtualClusterArgs) (*mcp_golang.ToolResponse, error) {
 	deleteCmd := taikuncore.NewDeleteVirtualClusterCommand()
 	deleteCmd.SetProjectId(args.ProjectID)
 
+	if isDryRun(args.DryRun) {
+		project, errorResp := resolveProject(ctx, client, args.ProjectID)
+		if errorResp != nil {
+			return errorResp, nil
+		}
+		if !project.GetIsVirtualCluster() {
+			return createJSONResponse(ErrorResponse{
+				Error: fmt.Sprintf("Project %d (%s) is not a virtual cluster", args.ProjectID, project.GetName()),
+				Code:  errorCodeValidation,
+			}), nil
+		}
+		return createDryRunResponse(
+			fmt.Sprintf("would delete virtual cluster '%s' (project %d)", project.GetName(), args.ProjectID),
+			[]string{fmt.Sprintf("Virtual cluster %d (%s) exists", args.ProjectID, project.GetName())},
+			dryRunPost("/api/v1/virtual-cluster/delete", deleteCmd),
+		), nil
+	}
+
+	action := fmt.Sprintf("delete-virtual-cluster:%d", args.ProjectID)
+	if confirmation := confirmDestructive(ctx, action, args.ConfirmationToken, "delete-virtual-cluster", func() (*DestructionSummary, *mcp_golang.ToolResponse) {
+		summary, errorResp := summarizeProject(ctx, client, args.ProjectID)
+		if summary != nil {
+			summary.Removes = fmt.Sprintf("virtual cluster '%s' with all of its applications", summary.ProjectName)
+		}
+		return summary, errorResp
+	}); confirmation != nil {
+		return confirmation, nil
+	}
+
 	response, err := client.Client.VirtualClusterAPI.VirtualClusterDelete(ctx).
 		DeleteVirtualClusterCommand(*deleteCmd).
 		Execute()
@@ -176,9 +234,7 @@ func deleteVirtualCluster(client *taikungoclient.Client, args DeleteVirtualClust
 	return createJSONResponse(successResp), nil
 }
 
-func listVirtualClusters(client *taikungoclient.Client, args ListVirtualClustersArgs) (*mcp_golang.ToolResponse, error) {
-	ctx := context.Background()
-
+func listVirtualClusters(ctx context.Context, client *taikungoclient.Client, args ListVirtualClustersArgs) (*mcp_golang.ToolResponse, error) {
 	req := client.Client.VirtualClusterAPI.VirtualClusterList(ctx, args.ParentProjectID)
 
 	if args.Limit > 0 {
@@ -203,25 +259,7 @@ func listVirtualClusters(client *taikungoclient.Client, args ListVirtualClusters
 	// Prepare response data
 	var virtualClusters []VirtualClusterSummary
 	for _, virtualCluster := range virtualClusterList.Data {
-		vcSummary := VirtualClusterSummary{
-			ID:                 virtualCluster.Id,
-			Name:               virtualCluster.Name,
-			Status:             string(virtualCluster.Status),
-			Health:             string(virtualCluster.Health),
-			KubernetesVersion:  virtualCluster.KubernetesVersion,
-			CreatedAt:          virtualCluster.CreatedAt,
-			CreatedBy:          virtualCluster.CreatedBy,
-			DeleteOnExpiration: virtualCluster.DeleteOnExpiration,
-			Organization:       virtualCluster.OrganizationName,
-			IsLocked:           virtualCluster.IsLocked,
-			HasKubeconfig:      virtualCluster.HasKubeConfigFile,
-		}
-
-		if virtualCluster.ExpiredAt != "" {
-			vcSummary.ExpiresAt = virtualCluster.ExpiredAt
-		}
-
-		virtualClusters = append(virtualClusters, vcSummary)
+		virtualClusters = append(virtualClusters, summarizeVirtualCluster(virtualCluster))
 	}
 
 	// Create response
@@ -238,4 +276,25 @@ func listVirtualClusters(client *taikungoclient.Client, args ListVirtualClusters
 	}
 
 	return createJSONResponse(listResp), nil
-}
\ No newline at end of file
+}
+
+func summarizeVirtualCluster(virtualCluster taikuncore.VClusterListDto) VirtualClusterSummary {
+	vcSummary := VirtualClusterSummary{
+		ID:                 virtualCluster.Id,
+		Name:               virtualCluster.Name,
+		Status:             string(virtualCluster.Status),
+		Health:             string(virtualCluster.Health),
+		KubernetesVersion:  virtualCluster.KubernetesVersion,
+		CreatedAt:          virtualCluster.CreatedAt,
+		CreatedBy:          virtualCluster.CreatedBy,
+		DeleteOnExpiration: virtualCluster.DeleteOnExpiration,
+		Organization:       virtualCluster.OrganizationName,
+		IsLocked:           virtualCluster.IsLocked,
+		HasKubeconfig:      virtualCluster.HasKubeConfigFile,
+	}
+
+	if virtualCluster.ExpiredAt != "" {
+		vcSummary.ExpiresAt = virtualCluster.ExpiredAt
+	}
+	return vcSummary
+}